@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/config"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/pgrepo"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services"
+)
+
+const apiKeyUsage = `Usage: app api-key <command> [arguments]
+
+Commands:
+  create --name <name> [--scopes read,submit] [--request-quota 600] [--enqueue-quota 60]
+                        issue a key, its secret is printed once
+  list                  print every key including revoked ones
+  revoke --id <id>      revoke a key
+`
+
+// runAPIKey manages API keys directly in Postgres, it works without ADMIN_TOKEN
+func runAPIKey(cfg config.Config, logger zerolog.Logger, args []string) error {
+	if len(args) == 0 || args[0] == "help" {
+		fmt.Print(apiKeyUsage)
+		return nil
+	}
+	command, args := args[0], args[1:]
+
+	pgDB, err := connectPostgres(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = pgDB.Close()
+	}()
+	apiKeyRepo := pgrepo.NewAPIKeyRepo(pgDB)
+	apiKeyService := services.NewAPIKeyService(&apiKeyRepo)
+	ctx := context.Background()
+
+	switch command {
+	case "create":
+		flags := flag.NewFlagSet("api-key create", flag.ContinueOnError)
+		name := flags.String("name", "", "who or what the key is for")
+		scopes := flags.String("scopes", "read", "comma separated scopes: read, submit")
+		requestQuota := flags.Int("request-quota", 600, "requests per minute, 0 means unlimited")
+		enqueueQuota := flags.Int("enqueue-quota", 60, "submitted commands per hour, 0 means unlimited")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		var keyScopes []domain.APIKeyScope
+		for _, scope := range strings.Split(*scopes, ",") {
+			keyScopes = append(keyScopes, domain.APIKeyScope(strings.TrimSpace(scope)))
+		}
+		key, secret, err := apiKeyService.CreateAPIKey(ctx, domain.NewAPIKeyData{
+			Name:         *name,
+			Scopes:       keyScopes,
+			RequestQuota: *requestQuota,
+			EnqueueQuota: *enqueueQuota,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create api key: %w", err)
+		}
+		fmt.Printf("id: %s\nsecret: %s\nThe secret is not stored, keep it now.\n", key.GetID(), secret)
+		return nil
+	case "list":
+		keys, err := apiKeyService.ListAPIKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list api keys: %w", err)
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "ID\tNAME\tPREFIX\tSCOPES\tREQUESTS/MIN\tENQUEUES/HOUR\tREVOKED")
+		for _, key := range keys {
+			scopes := make([]string, 0, len(key.GetScopes()))
+			for _, scope := range key.GetScopes() {
+				scopes = append(scopes, string(scope))
+			}
+			revoked := ""
+			if key.IsRevoked() {
+				revoked = key.GetRevokedAt().Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", key.GetID(), key.GetName(), key.GetPrefix(),
+				strings.Join(scopes, ","), key.GetRequestQuota(), key.GetEnqueueQuota(), revoked)
+		}
+		return writer.Flush()
+	case "revoke":
+		flags := flag.NewFlagSet("api-key revoke", flag.ContinueOnError)
+		id := flags.String("id", "", "id of the key to revoke")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *id == "" {
+			return errors.New("--id is required")
+		}
+		if err := apiKeyService.RevokeAPIKey(ctx, *id); err != nil {
+			return fmt.Errorf("failed to revoke api key %s: %w", *id, err)
+		}
+		fmt.Printf("revoked: %s\n", *id)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, apiKeyUsage)
+		return fmt.Errorf("unknown api-key command %q", command)
+	}
+}
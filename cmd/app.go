@@ -0,0 +1,467 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/config"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/events"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/memrepo"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/pgrepo"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/graphqlserver"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/grpcserver"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// appMode selects which parts of the application run in this process
+type appMode struct {
+	// api serves the HTTP API and relays accepted commands from the outbox to the queue
+	api bool
+	// worker consumes commands, calls the NASA API and delivers webhooks
+	worker bool
+}
+
+// validate reports the settings mode needs on top of config.Validate as config.Problems
+func (m appMode) validate(cfg config.Config) error {
+	var problems config.Problems
+	if m.worker && cfg.APIKey == "" {
+		problems = append(problems, "API_KEY is required to run workers")
+	}
+	if cfg.QueueBackend == config.QueueBackendMemory && !(m.api && m.worker) {
+		problems = append(problems, "memory queue backend works only when serve and worker run in one process, use the all command")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// runApp wires the dependencies needed by mode and runs them until a signal is received
+// The settings mode needs are checked by run, see appMode.validate.
+func runApp(cfg config.Config, logger zerolog.Logger, mode appMode) error {
+	lifecycle := pkg.NewLifecycle(logger)
+	// Resources are added to the lifecycle as soon as they are opened, if wiring fails before Run they are released here
+	running := false
+	defer func() {
+		if !running {
+			_ = lifecycle.Release()
+		}
+	}()
+
+	// The tracer provider is added first so it is stopped last and flushes the spans of every other component
+	if err := setupTracing(cfg, logger, lifecycle); err != nil {
+		return err
+	}
+
+	pgDB, err := connectPostgres(cfg, logger)
+	if err != nil {
+		return err
+	}
+	lifecycle.Add(pkg.Component{
+		Name: "postgres",
+		Stop: func(ctx context.Context) error { return pgDB.Close() },
+	})
+
+	mq, err := newCommandQueue(cfg, pgDB, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create %s command queue: %w", cfg.QueueBackend, err)
+	}
+	logger.Info().Str("backend", cfg.QueueBackend).Msg("using command queue")
+	lifecycle.Add(pkg.Component{
+		Name: cfg.QueueBackend + " command queue",
+		Stop: func(ctx context.Context) error {
+			mq.Close()
+			return nil
+		},
+	})
+
+	pgPictureRepo := pgrepo.NewPictureRepo(pgDB)
+	pictureRepo := memrepo.NewCachedPictureRepo(&pgPictureRepo, cfg.PictureCacheSize, cfg.PictureCacheTTL)
+	jobRepo := pgrepo.NewJobRepo(pgDB)
+	// Events go through Postgres, so the API streams events of jobs run by workers in other processes
+	jobEventRepo := pgrepo.NewJobEventRepo(pgDB)
+	jobEvents := events.NewStoreBroker(&jobEventRepo, events.DefaultPollInterval, logger)
+	webhookRepo := pgrepo.NewWebhookRepo(pgDB)
+	webhookService := services.NewWebhookService(&webhookRepo, services.NewWebhookClient(10*time.Second), logger)
+
+	// The API only reads results and enqueues commands, it never calls NASA
+	var nasaApiClient services.NasaAPIClient
+	if mode.worker {
+		nasaApiClient, err = newNasaApiClient(cfg, pgDB, logger)
+		if err != nil {
+			return err
+		}
+	}
+	largestPictureService := services.NewLargestPictureService(
+		mq,
+		pictureRepo,
+		nasaApiClient,
+		&jobRepo,
+		jobEvents,
+		webhookService,
+		logger,
+	).WithCommandCoalesceWindow(cfg.CommandCoalesceWindow)
+
+	// Liveness fails only when the process cannot recover on its own, readiness whenever it should get no traffic
+	livenessChecks := map[string]httpserver.HealthCheck{}
+	readinessChecks := map[string]httpserver.HealthCheck{
+		"shutdown": lifecycle.Ready,
+		"postgres": pgDB.PingContext,
+		"queue":    func(ctx context.Context) error { return mq.Ready() },
+	}
+
+	if mode.worker {
+		lifecycle.Add(pkg.Component{
+			Name: "webhook workers",
+			Start: func(ctx context.Context) error {
+				webhookService.Start(ctx, 4)
+				return nil
+			},
+			Stop: webhookService.Stop,
+		})
+
+		workers := services.NewCommandWorkers(largestPictureService, mq, cfg.Workers, cfg.JobTimeout, logger)
+		lifecycle.Add(pkg.Component{
+			Name: "command workers",
+			Start: func(ctx context.Context) error {
+				workers.Start(ctx)
+				return nil
+			},
+			Stop:        workers.Stop,
+			StopTimeout: cfg.WorkerShutdownTimeout,
+		})
+		livenessChecks["consumers"] = workers.Alive
+		readinessChecks["consumers"] = workers.Alive
+		if cfg.NasaHealthCheck {
+			nasaPing := clients.NewNasaApiClient(cfg.APIKey, cfg.APIUrl, logger).Ping
+			readinessChecks["nasa"] = httpserver.CachedHealthCheck(nasaPing, cfg.NasaHealthCheckTTL)
+		}
+	}
+
+	router := mux.NewRouter()
+	router.Use(httpserver.NewTracingMiddleware())
+	router.Use(httpserver.NewLoggingMiddleware(logger))
+	router.Use(httpserver.NewMetricsMiddleware())
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.HandleFunc("/healthz", httpserver.NewHealthHandler(livenessChecks)).Methods("GET")
+	router.HandleFunc("/readyz", httpserver.NewHealthHandler(readinessChecks)).Methods("GET")
+
+	if mode.api {
+		outboxRepo := pgrepo.NewOutboxRepo(pgDB)
+		outboxRelay := services.NewOutboxRelay(
+			&outboxRepo,
+			mq,
+			services.DefaultOutboxPollInterval,
+			services.DefaultOutboxBatchSize,
+			logger,
+		)
+		lifecycle.Add(pkg.Component{
+			Name: "outbox relay",
+			Start: func(ctx context.Context) error {
+				outboxRelay.Start(ctx)
+				return nil
+			},
+			Stop: outboxRelay.Stop,
+		})
+
+		openAPI, err := httpserver.LoadOpenAPI()
+		if err != nil {
+			return err
+		}
+		requestValidator, err := httpserver.NewRequestValidator(openAPI)
+		if err != nil {
+			return err
+		}
+		apiKeyRepo := pgrepo.NewAPIKeyRepo(pgDB)
+		apiKeyService := services.NewAPIKeyService(&apiKeyRepo)
+		// authenticator stays nil when authentication is disabled
+		var authenticator httpserver.APIKeyAuthenticator
+		if cfg.APIAuth {
+			authenticator = apiKeyService
+			router.Use(httpserver.NewAuthMiddleware(apiKeyService, httpserver.PublicPaths))
+		}
+		if err := useRateLimits(cfg, pgDB, router, logger, lifecycle); err != nil {
+			return err
+		}
+		// Quotas are spent only by requests the rate limiter let through
+		if cfg.APIAuth {
+			router.Use(httpserver.NewQuotaMiddleware(apiKeyService))
+		}
+		router.Use(requestValidator)
+		idempotencyStore := pgrepo.NewIdempotencyStore(pgDB)
+		addPruner(lifecycle, logger, "idempotency key pruner", func(ctx context.Context) error {
+			_, err := idempotencyStore.PruneExpired(ctx, time.Now())
+			return err
+		})
+		addPruner(lifecycle, logger, "job event pruner", func(ctx context.Context) error {
+			_, err := jobEventRepo.PruneBefore(ctx, time.Now().Add(-events.DefaultRetention))
+			return err
+		})
+		httpserver.NewHttpServer(largestPictureService, webhookService).
+			WithIdempotency(&idempotencyStore, cfg.IdempotencyTTL, logger).
+			RegisterRoutes(router)
+		if cfg.AdminToken != "" {
+			httpserver.NewAdminServer(apiKeyService, cfg.AdminToken).RegisterRoutes(router)
+		}
+
+		graphqlServer, err := graphqlserver.NewGraphqlServer(largestPictureService, pictureRepo, cfg.GraphqlMaxDepth, cfg.GraphqlMaxComplexity)
+		if err != nil {
+			return err
+		}
+		router.Handle("/graphql", graphqlServer.WithEnqueueQuota(apiKeyService)).Methods(http.MethodPost)
+
+		grpcServer := grpcserver.NewGrpcServer(largestPictureService, authenticator)
+		lifecycle.Add(pkg.Component{
+			Name: "grpc server",
+			Start: func(ctx context.Context) error {
+				lis, err := net.Listen("tcp", cfg.GRPCAddr)
+				if err != nil {
+					return fmt.Errorf("failed to listen on %s: %w", cfg.GRPCAddr, err)
+				}
+				logger.Info().Str("addr", cfg.GRPCAddr).Msg("starting gRPC server")
+				go func() {
+					if err := grpcServer.Serve(lis); err != nil {
+						lifecycle.Abort(fmt.Errorf("gRPC server Serve Error: %w", err))
+					}
+				}()
+				return nil
+			},
+			Stop: grpcServer.Stop,
+		})
+	}
+
+	srv := &http.Server{
+		Addr:    cfg.HTTPAddr,
+		Handler: router,
+	}
+	var stopServing context.CancelFunc
+	lifecycle.Add(pkg.Component{
+		Name: "http server",
+		Start: func(ctx context.Context) error {
+			// Long lived requests such as event streams end when the server stops, after the drain delay
+			serveCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			stopServing = cancel
+			srv.BaseContext = func(net.Listener) context.Context { return serveCtx }
+			logger.Info().Str("addr", cfg.HTTPAddr).Msg("starting HTTP server")
+			go func() {
+				if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+					lifecycle.Abort(fmt.Errorf("HTTP server ListenAndServe Error: %w", err))
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			// Readiness fails from now on, requests keep being served until load balancers have noticed
+			select {
+			case <-time.After(cfg.ShutdownDrainDelay):
+			case <-ctx.Done():
+			}
+			stopServing()
+			return srv.Shutdown(ctx)
+		},
+		StopTimeout: cfg.ShutdownDrainDelay + pkg.DefaultStopTimeout,
+	})
+
+	// listen to OS signals and gracefully shutdown every component
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	running = true
+	if err := lifecycle.Run(ctx); err != nil {
+		return err
+	}
+
+	logger.Info().Msg("have a nice day!")
+	return nil
+}
+
+// setupTracing installs the tracer provider exporting spans to the exporter selected by TRACING_EXPORTER
+func setupTracing(cfg config.Config, logger zerolog.Logger, lifecycle *pkg.Lifecycle) error {
+	var exporter sdktrace.SpanExporter
+	switch cfg.TracingExporter {
+	case config.TracingExporterNone:
+	case config.TracingExporterOTLP:
+		otlpExporter, err := otlptracegrpc.New(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to create otlp span exporter: %w", err)
+		}
+		exporter = otlpExporter
+	case config.TracingExporterStdout:
+		var w io.Writer = os.Stdout
+		if cfg.TracingFile != "" {
+			f, err := os.OpenFile(cfg.TracingFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return fmt.Errorf("failed to open tracing file: %w", err)
+			}
+			lifecycle.Add(pkg.Component{
+				Name: "tracing file",
+				Stop: func(ctx context.Context) error { return f.Close() },
+			})
+			w = f
+		}
+		stdoutExporter, err := stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return fmt.Errorf("failed to create stdout span exporter: %w", err)
+		}
+		exporter = stdoutExporter
+	default:
+		return fmt.Errorf("unknown tracing exporter %q", cfg.TracingExporter)
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), exporter, cfg.TracingSampleRatio)
+	if err != nil {
+		return err
+	}
+	logger.Info().Str("exporter", cfg.TracingExporter).Msg("exporting traces")
+	lifecycle.Add(pkg.Component{
+		Name: "tracer provider",
+		Stop: shutdown,
+	})
+	return nil
+}
+
+// dialPostgres connects to Postgres without touching the schema
+func dialPostgres(cfg config.Config) (*pkg.DB, error) {
+	pgDB, err := pkg.Dial(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	return pgDB, nil
+}
+
+// connectPostgres dials Postgres and brings the schema up to date
+func connectPostgres(cfg config.Config, logger zerolog.Logger) (*pkg.DB, error) {
+	pgDB, err := dialPostgres(cfg)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info().Msg("running postgres migrations")
+	if err := runPgMigrations(cfg.DSN, cfg.MigrationsPath); err != nil {
+		_ = pgDB.Close()
+		return nil, fmt.Errorf("runPgMigrations failed: %w", err)
+	}
+	return pgDB, nil
+}
+
+// newNasaApiClient creates the NASA API client wrapped in the response cache selected by NASA_CACHE
+func newNasaApiClient(cfg config.Config, pgDB *pkg.DB, logger zerolog.Logger) (services.NasaAPIClient, error) {
+	nasaApiClient := clients.NewNasaApiClient(cfg.APIKey, cfg.APIUrl, logger)
+	var cache clients.ResponseCache
+	switch cfg.NasaCache {
+	case config.NasaCacheNone:
+		return nasaApiClient, nil
+	case config.NasaCacheMemory:
+		memoryCache, err := memrepo.NewNasaCacheRepo(cfg.NasaCacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create nasa cache: %w", err)
+		}
+		cache = memoryCache
+	case config.NasaCachePostgres:
+		pgCache := pgrepo.NewNasaCacheRepo(pgDB)
+		cache = &pgCache
+	default:
+		return nil, fmt.Errorf("unknown nasa cache %q", cfg.NasaCache)
+	}
+	return clients.NewCachingNasaApiClient(nasaApiClient, cache, cfg.NasaListingTTL, cfg.NasaSizeTTL, logger), nil
+}
+
+// useRateLimits adds the rate limit middleware backed by the store selected by RATE_LIMIT_STORE.
+// Idle Postgres buckets are pruned in the background.
+func useRateLimits(cfg config.Config, pgDB *pkg.DB, router *mux.Router, logger zerolog.Logger, lifecycle *pkg.Lifecycle) error {
+	limits := httpserver.RateLimits{
+		Read:   domain.NewRateLimit(cfg.RateLimitReadPerMinute, cfg.RateLimitReadBurst),
+		Submit: domain.NewRateLimit(cfg.RateLimitSubmitPerMinute, cfg.RateLimitSubmitBurst),
+	}
+	var store httpserver.RateLimitStore
+	switch cfg.RateLimitStore {
+	case config.RateLimitStoreNone:
+		return nil
+	case config.RateLimitStoreMemory:
+		memoryStore, err := memrepo.NewRateLimitStore(memrepo.DefaultRateLimitStoreSize)
+		if err != nil {
+			return fmt.Errorf("failed to create rate limit store: %w", err)
+		}
+		store = memoryStore
+	case config.RateLimitStorePostgres:
+		pgStore := pgrepo.NewRateLimitStore(pgDB)
+		store = &pgStore
+		idleAfter := max(limits.Read.RefillTime(), limits.Submit.RefillTime())
+		addPruner(lifecycle, logger, "rate limit pruner", func(ctx context.Context) error {
+			_, err := pgStore.PruneIdle(ctx, time.Now().Add(-idleAfter))
+			return err
+		})
+	default:
+		return fmt.Errorf("unknown rate limit store %q", cfg.RateLimitStore)
+	}
+	router.Use(httpserver.NewRateLimitMiddleware(store, limits, httpserver.PublicPaths, cfg.TrustedProxyHeader, logger))
+	return nil
+}
+
+// addPruner runs prune every minute until shutdown, failures are logged and retried on the next tick
+func addPruner(lifecycle *pkg.Lifecycle, logger zerolog.Logger, name string, prune func(ctx context.Context) error) {
+	lifecycle.Add(pkg.Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			go func() {
+				ticker := time.NewTicker(time.Minute)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if err := prune(ctx); err != nil {
+							logger.Error().Err(err).Str("component", name).Msg("pruning failed")
+						}
+					}
+				}
+			}()
+			return nil
+		},
+	})
+}
+
+// commandQueue is a command queue backend that can report its readiness and be closed
+type commandQueue interface {
+	services.CommandQueue
+	Ready() error
+	Close()
+}
+
+// newCommandQueue creates the command queue backend selected by QUEUE_BACKEND
+func newCommandQueue(cfg config.Config, pgDB *pkg.DB, logger zerolog.Logger) (commandQueue, error) {
+	switch cfg.QueueBackend {
+	case config.QueueBackendRabbitMQ:
+		return pkg.ConnectRabbitMQ(cfg.RabbitMQURL, cfg.Workers, logger)
+	case config.QueueBackendMemory:
+		return pkg.NewMemoryQueue(pkg.DefaultMemoryQueueCapacity), nil
+	case config.QueueBackendPostgres:
+		return pkg.NewPostgresQueue(
+			pgDB,
+			pkg.DefaultPostgresQueuePollInterval,
+			// A command stays leased while its job may still run, otherwise another worker would run it again
+			cfg.JobTimeout+pkg.PostgresQueueLeaseMargin,
+			logger,
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", cfg.QueueBackend)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/config"
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = `Usage: app config <command> [arguments]
+
+Commands:
+  print [--redacted]    print the effective settings as YAML, usable as a config file.
+                        --redacted masks secrets and the passwords of DSN and RABBITMQ_URL
+`
+
+// runConfig prints the settings after every layer was applied. Invalid settings are printed too
+// and reported afterwards together with the problems of loading them, values that could not be
+// parsed are printed as their previous layer set them.
+func runConfig(cfg config.Config, loadErr error, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" {
+		fmt.Fprint(out, configUsage)
+		return nil
+	}
+	command, args := args[0], args[1:]
+	if command != "print" {
+		return fmt.Errorf("unknown config command %q", command)
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := flags.Bool("redacted", false, "mask secrets and passwords")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	printed := cfg
+	if *redacted {
+		printed = cfg.Redacted()
+	}
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := errors.Join(encoder.Encode(printed), encoder.Close()); err != nil {
+		return fmt.Errorf("failed to print config: %w", err)
+	}
+	return config.Merge(loadErr, cfg.Validate())
+}
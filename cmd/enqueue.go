@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/config"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/events"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/pgrepo"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services"
+)
+
+// runEnqueue submits a command through the outbox, it is published by a running serve or all process
+func runEnqueue(cfg config.Config, logger zerolog.Logger, args []string) error {
+	flags := flag.NewFlagSet("enqueue", flag.ContinueOnError)
+	sol := flags.Int("sol", -1, "sol to calculate the largest picture for")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	// Sol 0 is rejected like by every other way of submitting a command
+	if *sol <= 0 {
+		return errors.New("sol must be positive")
+	}
+
+	// The schema is migrated by serve, worker and all, enqueue only connects
+	pgDB, err := dialPostgres(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = pgDB.Close()
+	}()
+
+	jobRepo := pgrepo.NewJobRepo(pgDB)
+	jobEventRepo := pgrepo.NewJobEventRepo(pgDB)
+	largestPictureService := services.NewLargestPictureService(
+		nil,
+		nil,
+		nil,
+		&jobRepo,
+		events.NewStoreBroker(&jobEventRepo, events.DefaultPollInterval, logger),
+		nil,
+		logger,
+	).WithCommandCoalesceWindow(cfg.CommandCoalesceWindow)
+	job, err := largestPictureService.PublishCommand(context.Background(), *sol)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue sol %d: %w", *sol, err)
+	}
+	fmt.Printf("job_id: %s, sol: %d\n", job.GetID(), job.GetSol())
+	return nil
+}
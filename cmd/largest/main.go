@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/config"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/pgrepo"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/sqliterepo"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+const usage = `Usage: largest compute --sol <sol> [--rover curiosity] [--by bytes] [--top 5] [--format table|json] [--save [--sqlite <path>]]
+
+Computes the largest pictures for a sol straight from the NASA API, without Postgres or RabbitMQ.
+API_KEY is read from the environment and defaults to DEMO_KEY. --save stores the largest picture
+in the Postgres database from DSN, or in the SQLite database at --sqlite. Stored pictures are
+keyed by sol alone, so --save only works for the default rover.
+
+Exits with 2 on invalid arguments and with 1 when computing or saving fails.
+`
+
+// demoApiKey is the rate limited key NASA hands out for trying the API
+const demoApiKey = "DEMO_KEY"
+
+// defaultRover is the rover of the pictures stored by the API, the only one --save accepts
+const defaultRover = "curiosity"
+
+// errUsage marks errors caused by invalid arguments
+var errUsage = errors.New("invalid arguments")
+
+type pictureOutput struct {
+	Rank      int    `json:"rank"`
+	Sol       int    `json:"sol"`
+	Size      int    `json:"size"`
+	Rover     string `json:"rover,omitempty"`
+	Camera    string `json:"camera,omitempty"`
+	EarthDate string `json:"earth_date,omitempty"`
+	ImgSrc    string `json:"img_src"`
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(exitCode(err))
+}
+
+// exitCode is 0 on success and on --help, 2 for invalid arguments and 1 for any other failure
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "compute" {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("%w: expected compute command", errUsage)
+	}
+
+	flags := flag.NewFlagSet("compute", flag.ContinueOnError)
+	rover := flags.String("rover", defaultRover, "rover that took the photos")
+	sol := flags.Int("sol", -1, "sol to compute the largest pictures for")
+	by := flags.String("by", "bytes", "what makes a picture large, only bytes is supported")
+	top := flags.Int("top", 5, "how many pictures to print, 0 prints all of them")
+	format := flags.String("format", "table", "output format: table or json")
+	save := flags.Bool("save", false, "store the largest picture in the repository")
+	sqlitePath := flags.String("sqlite", "", "store pictures in this SQLite database instead of Postgres")
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if *sol < 0 {
+		return fmt.Errorf("%w: --sol must be a non-negative number", errUsage)
+	}
+	if *by != "bytes" {
+		return fmt.Errorf("%w: unsupported --by %q, only bytes is supported", errUsage, *by)
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("%w: unsupported --format %q, use table or json", errUsage, *format)
+	}
+	// The pictures table has one row per sol, a picture of another rover would replace the default rover's
+	if *save && !strings.EqualFold(*rover, defaultRover) {
+		return fmt.Errorf("%w: --save only supports --rover %s, pictures are stored by sol", errUsage, defaultRover)
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"), os.LookupEnv, nil)
+	if err != nil {
+		return err
+	}
+	// Results go to out, logs go to standard error
+	level, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", cfg.LogLevel, err)
+	}
+	logger := logging.New(zerolog.ConsoleWriter{Out: os.Stderr}, level)
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = demoApiKey
+	}
+
+	var pictureRepo services.PictureRepository
+	if *save {
+		repo, closeRepo, err := openPictureRepo(cfg, *sqlitePath)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+		pictureRepo = repo
+	}
+
+	// The same service the workers use, so the CLI and the workers rank pictures identically
+	largestPictureService := services.NewLargestPictureService(
+		nil,
+		pictureRepo,
+		clients.NewNasaApiClient(apiKey, clients.RoverPhotosUrl(*rover), logger),
+		nil,
+		nil,
+		nil,
+		logger,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	pictures, err := largestPictureService.ComputeLargestPictures(ctx, *sol, *top)
+	if err != nil {
+		return fmt.Errorf("failed to compute largest pictures for sol %d: %w", *sol, err)
+	}
+	if *save {
+		if err := largestPictureService.SavePicture(ctx, pictures[0]); err != nil {
+			return err
+		}
+	}
+
+	return writePictures(out, *format, pictures)
+}
+
+// writePictures prints pictures ranked from 1 as a table or as JSON
+func writePictures(out io.Writer, format string, pictures []domain.Picture) error {
+	outputs := make([]pictureOutput, 0, len(pictures))
+	for i, picture := range pictures {
+		outputs = append(outputs, toPictureOutput(i+1, picture))
+	}
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(outputs)
+	}
+	return printTable(out, outputs)
+}
+
+// openPictureRepo opens the SQLite repository at sqlitePath or the Postgres one from DSN
+func openPictureRepo(cfg config.Config, sqlitePath string) (services.PictureRepository, func(), error) {
+	if sqlitePath != "" {
+		db, err := pkg.DialSQLite(sqlitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := sqliterepo.Migrate(db); err != nil {
+			_ = db.Close()
+			return nil, nil, err
+		}
+		repo := sqliterepo.NewPictureRepo(db)
+		return &repo, func() { _ = db.Close() }, nil
+	}
+	pgDB, err := pkg.Dial(cfg.DSN)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	repo := pgrepo.NewPictureRepo(pgDB)
+	return &repo, func() { _ = pgDB.Close() }, nil
+}
+
+func printTable(out io.Writer, outputs []pictureOutput) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tSIZE\tCAMERA\tEARTH DATE\tIMG SRC")
+	for _, output := range outputs {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", output.Rank, output.Size, output.Camera, output.EarthDate, output.ImgSrc)
+	}
+	return w.Flush()
+}
+
+func toPictureOutput(rank int, picture domain.Picture) pictureOutput {
+	output := pictureOutput{
+		Rank:   rank,
+		Sol:    picture.GetSol(),
+		Size:   picture.GetSize(),
+		Rover:  picture.GetRover(),
+		Camera: picture.GetCamera(),
+		ImgSrc: picture.GetUrl(),
+	}
+	if !picture.GetEarthDate().IsZero() {
+		output.EarthDate = picture.GetEarthDate().Format(time.DateOnly)
+	}
+	return output
+}
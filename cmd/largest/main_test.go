@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+func TestRun_RejectsInvalidArguments(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedError string
+	}{
+		{
+			name:          "Should require compute command",
+			args:          []string{"rank", "--sol", "1000"},
+			expectedError: "expected compute command",
+		},
+		{
+			name:          "Should require sol",
+			args:          []string{"compute"},
+			expectedError: "--sol must be a non-negative number",
+		},
+		{
+			name:          "Should reject unknown flag",
+			args:          []string{"compute", "--sol", "1000", "--largest"},
+			expectedError: "flag provided but not defined: -largest",
+		},
+		{
+			name:          "Should reject unsupported ranking",
+			args:          []string{"compute", "--sol", "1000", "--by", "pixels"},
+			expectedError: `unsupported --by "pixels", only bytes is supported`,
+		},
+		{
+			name:          "Should reject unsupported format",
+			args:          []string{"compute", "--sol", "1000", "--format", "csv"},
+			expectedError: `unsupported --format "csv", use table or json`,
+		},
+		{
+			name:          "Should reject saving pictures of another rover",
+			args:          []string{"compute", "--sol", "1000", "--rover", "perseverance", "--save"},
+			expectedError: "--save only supports --rover curiosity",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			var out bytes.Buffer
+
+			// When
+			err := run(tc.args, &out)
+
+			// Then
+			require.ErrorIs(t, err, errUsage)
+			require.ErrorContains(t, err, tc.expectedError)
+			require.Equal(t, 2, exitCode(err))
+			require.Empty(t, out.String())
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "Should succeed without error", expected: 0},
+		{name: "Should succeed on help", err: flag.ErrHelp, expected: 0},
+		{name: "Should report invalid arguments", err: errUsage, expected: 2},
+		{name: "Should report failures", err: errors.New("nasa api is unreachable"), expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			actual := exitCode(tc.err)
+
+			// Then
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestWritePictures(t *testing.T) {
+	pictures := []domain.Picture{
+		domain.NewPicture(domain.NewPictureData{
+			Sol:       1000,
+			Size:      4096,
+			Url:       "http://mars.nasa.gov/large.jpg",
+			Rover:     "curiosity",
+			Camera:    "MAST",
+			EarthDate: time.Date(2015, 5, 30, 0, 0, 0, 0, time.UTC),
+		}),
+		domain.NewPicture(domain.NewPictureData{
+			Sol:  1000,
+			Size: 512,
+			Url:  "http://mars.nasa.gov/small.jpg",
+		}),
+	}
+
+	t.Run("Should print ranked table", func(t *testing.T) {
+		// Given
+		var out bytes.Buffer
+
+		// When
+		err := writePictures(&out, "table", pictures)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "RANK  SIZE  CAMERA  EARTH DATE  IMG SRC\n"+
+			"1     4096  MAST    2015-05-30  http://mars.nasa.gov/large.jpg\n"+
+			"2     512                       http://mars.nasa.gov/small.jpg\n", out.String())
+	})
+
+	t.Run("Should print ranked JSON without empty fields", func(t *testing.T) {
+		// Given
+		var out bytes.Buffer
+
+		// When
+		err := writePictures(&out, "json", pictures)
+
+		// Then
+		require.NoError(t, err)
+		var actual []map[string]any
+		require.NoError(t, json.Unmarshal(out.Bytes(), &actual))
+		require.Equal(t, []map[string]any{
+			{
+				"rank":       float64(1),
+				"sol":        float64(1000),
+				"size":       float64(4096),
+				"rover":      "curiosity",
+				"camera":     "MAST",
+				"earth_date": "2015-05-30",
+				"img_src":    "http://mars.nasa.gov/large.jpg",
+			},
+			{
+				"rank":    float64(2),
+				"sol":     float64(1000),
+				"size":    float64(512),
+				"img_src": "http://mars.nasa.gov/small.jpg",
+			},
+		}, actual)
+	})
+}
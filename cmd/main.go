@@ -1,124 +1,138 @@
 package main
 
 import (
-	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"io"
+	stdlog "log"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/gorilla/mux"
-	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients"
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/config"
-	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/pgrepo"
-	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services"
-	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver"
-	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
 )
 
+const usage = `Usage: app [settings] <command> [arguments]
+
+Settings are layered: defaults, then the YAML or TOML file given with --config or CONFIG_FILE,
+then environment variables, then --<setting> flags such as --workers 8. Every command except
+config and help refuses to start with invalid settings, all problems are reported at once.
+
+Commands:
+  serve                 serve the HTTP API and relay accepted commands to the queue
+  worker                consume commands from the queue, HTTP is bound for health checks only
+  all                   serve and worker in one process (default)
+  migrate up|down|status
+                        apply all migrations, roll back the last one or print the schema version
+  enqueue --sol <sol>   submit a command for sol without going through the HTTP API
+  api-key create|list|revoke
+                        issue, list or revoke API keys, see api-key help
+  config print [--redacted]
+                        print the effective settings, see config help
+
+Settings:
+`
+
 func main() {
-	if err := run(); err != nil {
-		log.Fatal(err)
+	cfg, args, loadErr := loadConfig(os.Args[1:])
+	if errors.Is(loadErr, flag.ErrHelp) {
+		os.Exit(0)
 	}
-	os.Exit(0)
-}
-
-func run() error {
-	cfg := config.Read()
-	mq, err := pkg.ConnectRabbitMQ(cfg.RabbitMQURL)
-	if err != nil {
-		return fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	var problems config.Problems
+	if loadErr != nil && !errors.As(loadErr, &problems) {
+		fmt.Fprintln(os.Stderr, loadErr)
+		os.Exit(2)
 	}
-
-	pgDB, err := pkg.Dial(cfg.DSN)
+	logger, err := newLogger(cfg, os.Stderr)
 	if err != nil {
-		return fmt.Errorf("failed to connect to postgres: %w", err)
-	}
-	if pgDB != nil {
-		log.Println("Running Postgres migrations")
-		if err := runPgMigrations(cfg.DSN, cfg.MigrationsPath); err != nil {
-			return fmt.Errorf("runPgMigrations failed: %w", err)
-		}
+		// The invalid setting is reported by Validate, config print has to work regardless
+		logger = logging.New(os.Stderr, zerolog.InfoLevel)
 	}
+	// Libraries logging with the standard library end up in the structured log too
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(logger)
 
-	pictureRepo := pgrepo.NewPictureRepo(pgDB)
-
-	nasaApiClient := clients.NewNasaApiClient(cfg.APIKey, cfg.APIUrl)
-	largestPictureService := services.NewLargestPictureService(
-		mq,
-		&pictureRepo,
-		nasaApiClient,
-	)
-	largestPictureService.StartListeningSolCommands(context.TODO())
-
-	largestPictureServer := httpserver.NewHttpServer(largestPictureService)
-
-	// create http router
-	router := mux.NewRouter()
-	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte("NASA Largest Picture API 1.0V"))
-	}).Methods("GET")
-
-	router.HandleFunc("/mars/pictures/largest/command", largestPictureServer.PostCommandHandler).Methods("POST")
-	router.HandleFunc("/mars/pictures/largest/command/{sol}", largestPictureServer.GetLargestPictureHandler).Methods("GET")
-
-	srv := &http.Server{
-		Addr:    cfg.HTTPAddr,
-		Handler: router,
+	if err := run(cfg, loadErr, logger, args); err != nil {
+		logger.Fatal().Err(err).Msg("exiting")
 	}
-	// listen to OS signals and gracefully shutdown HTTP server
-	stopped := make(chan struct{})
-	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-		<-sigint
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := srv.Shutdown(ctx); err != nil {
-			log.Printf("HTTP Server Shutdown Error: %v", err)
-		}
-		close(stopped)
-	}()
-
-	log.Printf("Starting HTTP server on %s", cfg.HTTPAddr)
+	os.Exit(0)
+}
 
-	// start HTTP server
-	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("HTTP server ListenAndServe Error: %v", err)
+// loadConfig parses the settings flags preceding the command and layers them over the config file
+// and the environment. It returns the command with its arguments, and config.Problems along with
+// the partially loaded settings when some of them could not be parsed.
+func loadConfig(args []string) (config.Config, []string, error) {
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
 	}
-
-	<-stopped
-
-	log.Printf("Have a nice day!")
-	return nil
+	configPath := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file, overrides CONFIG_FILE")
+	overrides := config.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return config.Config{}, nil, err
+	}
+	cfg, err := config.Load(*configPath, os.LookupEnv, overrides)
+	return cfg, flags.Args(), err
 }
 
-// runPgMigrations runs Postgres migrations
-func runPgMigrations(dsn, path string) error {
-	if path == "" {
-		return errors.New("no migrations path provided")
-	}
-	if dsn == "" {
-		return errors.New("no DSN provided")
+// run executes the command of args. loadErr holds the problems of loading cfg, they are
+// reported together with those of validating it.
+func run(cfg config.Config, loadErr error, logger zerolog.Logger, args []string) error {
+	command := "all"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	m, err := migrate.New(
-		path,
-		dsn,
-	)
-	if err != nil {
+	var runCommand func() error
+	// commandErr holds the problems of settings only the command needs
+	var commandErr error
+	switch command {
+	case "serve":
+		runCommand, commandErr = appCommand(cfg, logger, appMode{api: true})
+	case "worker":
+		runCommand, commandErr = appCommand(cfg, logger, appMode{worker: true})
+	case "all":
+		runCommand, commandErr = appCommand(cfg, logger, appMode{api: true, worker: true})
+	case "migrate":
+		runCommand = func() error { return runMigrate(cfg, args) }
+	case "enqueue":
+		runCommand = func() error { return runEnqueue(cfg, logger, args) }
+	case "api-key":
+		runCommand = func() error { return runAPIKey(cfg, logger, args) }
+	case "config":
+		return runConfig(cfg, loadErr, args, os.Stdout)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+	if err := config.Merge(loadErr, cfg.Validate(), commandErr); err != nil {
 		return err
 	}
+	return runCommand()
+}
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("failed to run migrations: %w", err)
+// appCommand returns the command running the app in mode along with the problems of the settings mode needs
+func appCommand(cfg config.Config, logger zerolog.Logger, mode appMode) (func() error, error) {
+	return func() error { return runApp(cfg, logger, mode) }, mode.validate(cfg)
+}
+
+// newLogger returns the logger of LOG_LEVEL and LOG_FORMAT writing to w
+func newLogger(cfg config.Config, w io.Writer) (zerolog.Logger, error) {
+	level, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return zerolog.Logger{}, fmt.Errorf("invalid log level %q: %w", cfg.LogLevel, err)
+	}
+	switch cfg.LogFormat {
+	case config.LogFormatJSON:
+	case config.LogFormatConsole:
+		w = zerolog.ConsoleWriter{Out: w}
+	default:
+		return zerolog.Logger{}, fmt.Errorf("unknown log format %q", cfg.LogFormat)
 	}
-	return nil
+	return logging.New(w, level), nil
 }
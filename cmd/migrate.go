@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/config"
+)
+
+// runMigrate applies all migrations, rolls back the last one or prints the schema version
+func runMigrate(cfg config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: migrate up|down|status")
+	}
+	m, err := newMigrate(cfg.DSN, cfg.MigrationsPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = m.Close()
+	}()
+
+	switch args[0] {
+	case "up":
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+	case "down":
+		if err := m.Steps(-1); err != nil {
+			return fmt.Errorf("failed to roll back migration: %w", err)
+		}
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q, use up, down or status", args[0])
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("version: none")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+	return nil
+}
+
+// runPgMigrations runs Postgres migrations
+func runPgMigrations(dsn, path string) error {
+	m, err := newMigrate(dsn, path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = m.Close()
+	}()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
+
+func newMigrate(dsn, path string) (*migrate.Migrate, error) {
+	if path == "" {
+		return nil, errors.New("no migrations path provided")
+	}
+	if dsn == "" {
+		return nil, errors.New("no DSN provided")
+	}
+	return migrate.New(
+		path,
+		dsn,
+	)
+}
@@ -0,0 +1,158 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+const (
+	// DefaultListingTTL is how long a photo listing is served without asking NASA
+	DefaultListingTTL = time.Hour
+	// DefaultSizeTTL is how long an image size is kept, images behind a URL never change
+	DefaultSizeTTL = 30 * 24 * time.Hour
+)
+
+// ResponseCache stores NASA API responses by key
+type ResponseCache interface {
+	Get(ctx context.Context, key string) (domain.CachedResponse, error)
+	Set(ctx context.Context, key string, response domain.CachedResponse) error
+}
+
+// PhotosFinder is the NASA API client wrapped by CachingNasaApiClient
+type PhotosFinder interface {
+	APIUrl() string
+	FindNasaPhotos(ctx context.Context, sol int) (models.NasaPhotos, error)
+	FindPhotoSize(ctx *context.Context, imgUrl string) (int, error)
+}
+
+// conditionalPhotosFinder is implemented by clients that can revalidate a listing with ETag/Last-Modified
+type conditionalPhotosFinder interface {
+	FindNasaPhotosIfModified(ctx context.Context, sol int, validators Validators) (models.NasaPhotos, Validators, bool, error)
+}
+
+// CachingNasaApiClient caches photo listings for listingTTL and image sizes for sizeTTL.
+// Expired listings are revalidated with a conditional request when the client supports it.
+// Cache failures are logged and never fail a call.
+type CachingNasaApiClient struct {
+	client     PhotosFinder
+	cache      ResponseCache
+	listingTTL time.Duration
+	sizeTTL    time.Duration
+	now        func() time.Time
+	logger     zerolog.Logger
+}
+
+func NewCachingNasaApiClient(client PhotosFinder, cache ResponseCache, listingTTL, sizeTTL time.Duration, logger zerolog.Logger) CachingNasaApiClient {
+	return CachingNasaApiClient{
+		client:     client,
+		cache:      cache,
+		listingTTL: listingTTL,
+		sizeTTL:    sizeTTL,
+		now:        time.Now,
+		logger:     logger,
+	}
+}
+
+func (c CachingNasaApiClient) FindNasaPhotos(ctx context.Context, sol int) (models.NasaPhotos, error) {
+	// Listings of another API url, e.g. another rover, must not be served from the cache
+	key := "photos:" + c.client.APIUrl() + ":" + strconv.Itoa(sol)
+	cached, found := c.get(ctx, key)
+	if found && cached.IsFresh(c.now()) {
+		var photos models.NasaPhotos
+		if err := json.Unmarshal(cached.Body, &photos); err == nil {
+			return photos, nil
+		}
+	}
+
+	conditionalClient, ok := c.client.(conditionalPhotosFinder)
+	if !ok {
+		photos, err := c.client.FindNasaPhotos(ctx, sol)
+		if err != nil {
+			return models.NasaPhotos{}, err
+		}
+		c.setPhotos(ctx, key, photos, Validators{})
+		return photos, nil
+	}
+
+	// Without a cached body there is nothing to revalidate, the request is unconditional
+	var validators Validators
+	if found && cached.CanRevalidate() {
+		validators = Validators{ETag: cached.ETag, LastModified: cached.LastModified}
+	}
+	photos, validators, modified, err := conditionalClient.FindNasaPhotosIfModified(ctx, sol, validators)
+	if err != nil {
+		return models.NasaPhotos{}, err
+	}
+	if modified {
+		c.setPhotos(ctx, key, photos, validators)
+		return photos, nil
+	}
+	if err := json.Unmarshal(cached.Body, &photos); err != nil {
+		return models.NasaPhotos{}, fmt.Errorf("failed to unmarshal cached photos: %w", err)
+	}
+	cached.ExpiresAt = c.now().Add(c.listingTTL)
+	c.set(ctx, key, cached)
+	return photos, nil
+}
+
+func (c CachingNasaApiClient) FindPhotoSize(ctx *context.Context, imgUrl string) (int, error) {
+	key := "size:" + imgUrl
+	if cached, found := c.get(*ctx, key); found && cached.IsFresh(c.now()) {
+		if size, err := strconv.Atoi(string(cached.Body)); err == nil {
+			return size, nil
+		}
+	}
+	size, err := c.client.FindPhotoSize(ctx, imgUrl)
+	if err != nil {
+		return 0, err
+	}
+	// A negative size means upstream did not report Content-Length, it is worth asking again
+	if size >= 0 {
+		c.set(*ctx, key, domain.CachedResponse{
+			Body:      []byte(strconv.Itoa(size)),
+			ExpiresAt: c.now().Add(c.sizeTTL),
+		})
+	}
+	return size, nil
+}
+
+func (c CachingNasaApiClient) setPhotos(ctx context.Context, key string, photos models.NasaPhotos, validators Validators) {
+	body, err := json.Marshal(photos)
+	if err != nil {
+		logging.From(ctx, c.logger).Error().Err(err).Msg("failed to marshal photos for cache")
+		return
+	}
+	c.set(ctx, key, domain.CachedResponse{
+		Body:         body,
+		ETag:         validators.ETag,
+		LastModified: validators.LastModified,
+		ExpiresAt:    c.now().Add(c.listingTTL),
+	})
+}
+
+func (c CachingNasaApiClient) get(ctx context.Context, key string) (domain.CachedResponse, bool) {
+	cached, err := c.cache.Get(ctx, key)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.CachedResponse{}, false
+	}
+	if err != nil {
+		logging.From(ctx, c.logger).Error().Err(err).Str("key", key).Msg("failed to read from nasa cache")
+		return domain.CachedResponse{}, false
+	}
+	return cached, true
+}
+
+func (c CachingNasaApiClient) set(ctx context.Context, key string, response domain.CachedResponse) {
+	if err := c.cache.Set(ctx, key, response); err != nil {
+		logging.From(ctx, c.logger).Error().Err(err).Str("key", key).Msg("failed to write to nasa cache")
+	}
+}
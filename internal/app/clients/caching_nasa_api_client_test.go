@@ -0,0 +1,158 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/memrepo"
+)
+
+const photosBody = `{"photos":[{"img_src":"http://mars.nasa.gov/1.jpg","earth_date":"2015-05-30","camera":{"name":"MAST"},"rover":{"name":"Curiosity"}}]}`
+
+// nasaStub serves a photo listing with an ETag and image sizes, counting what reached it
+type nasaStub struct {
+	listings    atomic.Int32
+	notModified atomic.Int32
+	heads       atomic.Int32
+	headStatus  int
+}
+
+func (s *nasaStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead {
+		s.heads.Add(1)
+		w.Header().Set("Content-Length", "2048")
+		if s.headStatus != 0 {
+			w.WriteHeader(s.headStatus)
+		}
+		return
+	}
+	s.listings.Add(1)
+	if r.Header.Get("If-None-Match") == `"v1"` {
+		s.notModified.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", `"v1"`)
+	_, _ = w.Write([]byte(photosBody))
+}
+
+func TestCachingNasaApiClient(t *testing.T) {
+	t.Run("Should serve fresh listing from cache", func(t *testing.T) {
+		// Given
+		stub := &nasaStub{}
+		server := httptest.NewServer(stub)
+		defer server.Close()
+		cache, err := memrepo.NewNasaCacheRepo(10)
+		require.NoError(t, err)
+		client := NewCachingNasaApiClient(NewNasaApiClient("key", server.URL, zerolog.Nop()), cache, time.Hour, time.Hour, zerolog.Nop())
+
+		// When
+		first, err := client.FindNasaPhotos(context.Background(), 1000)
+		require.NoError(t, err)
+		second, err := client.FindNasaPhotos(context.Background(), 1000)
+		require.NoError(t, err)
+
+		// Then
+		require.Equal(t, first, second)
+		require.Equal(t, "MAST", second.Photos[0].Camera.Name)
+		require.Equal(t, int32(1), stub.listings.Load())
+	})
+
+	t.Run("Should revalidate expired listing with ETag", func(t *testing.T) {
+		// Given
+		stub := &nasaStub{}
+		server := httptest.NewServer(stub)
+		defer server.Close()
+		cache, err := memrepo.NewNasaCacheRepo(10)
+		require.NoError(t, err)
+		client := NewCachingNasaApiClient(NewNasaApiClient("key", server.URL, zerolog.Nop()), cache, time.Hour, time.Hour, zerolog.Nop())
+		now := time.Now()
+		client.now = func() time.Time { return now }
+		_, err = client.FindNasaPhotos(context.Background(), 1000)
+		require.NoError(t, err)
+
+		// When
+		now = now.Add(2 * time.Hour)
+		photos, err := client.FindNasaPhotos(context.Background(), 1000)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, photos.Photos, 1)
+		require.Equal(t, int32(2), stub.listings.Load())
+		require.Equal(t, int32(1), stub.notModified.Load())
+	})
+
+	t.Run("Should not serve listing cached for another api url", func(t *testing.T) {
+		// Given
+		stub := &nasaStub{}
+		server := httptest.NewServer(stub)
+		defer server.Close()
+		cache, err := memrepo.NewNasaCacheRepo(10)
+		require.NoError(t, err)
+		curiosity := NewCachingNasaApiClient(NewNasaApiClient("key", server.URL+"/rovers/curiosity/photos", zerolog.Nop()), cache, time.Hour, time.Hour, zerolog.Nop())
+		perseverance := NewCachingNasaApiClient(NewNasaApiClient("key", server.URL+"/rovers/perseverance/photos", zerolog.Nop()), cache, time.Hour, time.Hour, zerolog.Nop())
+		_, err = curiosity.FindNasaPhotos(context.Background(), 1000)
+		require.NoError(t, err)
+
+		// When
+		_, err = perseverance.FindNasaPhotos(context.Background(), 1000)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, int32(2), stub.listings.Load())
+	})
+
+	t.Run("Should cache image size by url", func(t *testing.T) {
+		// Given
+		stub := &nasaStub{}
+		server := httptest.NewServer(stub)
+		defer server.Close()
+		cache, err := memrepo.NewNasaCacheRepo(10)
+		require.NoError(t, err)
+		client := NewCachingNasaApiClient(NewNasaApiClient("key", server.URL, zerolog.Nop()), cache, time.Hour, time.Hour, zerolog.Nop())
+		ctx := context.Background()
+
+		// When
+		first, err := client.FindPhotoSize(&ctx, server.URL+"/1.jpg")
+		require.NoError(t, err)
+		second, err := client.FindPhotoSize(&ctx, server.URL+"/1.jpg")
+		require.NoError(t, err)
+
+		// Then
+		require.Equal(t, 2048, first)
+		require.Equal(t, 2048, second)
+		require.Equal(t, int32(1), stub.heads.Load())
+	})
+
+	t.Run("Should not cache size of failed response", func(t *testing.T) {
+		// Given
+		backoff := nasaRetryBackoff
+		nasaRetryBackoff = time.Millisecond
+		t.Cleanup(func() {
+			nasaRetryBackoff = backoff
+		})
+		stub := &nasaStub{headStatus: http.StatusNotFound}
+		server := httptest.NewServer(stub)
+		defer server.Close()
+		cache, err := memrepo.NewNasaCacheRepo(10)
+		require.NoError(t, err)
+		client := NewCachingNasaApiClient(NewNasaApiClient("key", server.URL, zerolog.Nop()), cache, time.Hour, time.Hour, zerolog.Nop())
+		ctx := context.Background()
+		_, err = client.FindPhotoSize(&ctx, server.URL+"/1.jpg")
+		require.ErrorContains(t, err, "unexpected status code 404")
+
+		// When
+		stub.headStatus = http.StatusServiceUnavailable
+		_, err = client.FindPhotoSize(&ctx, server.URL+"/1.jpg")
+
+		// Then
+		require.ErrorContains(t, err, "unexpected status code 503")
+		require.Equal(t, int32(1+maxNasaRetries+1), stub.heads.Load())
+	})
+}
@@ -4,5 +4,16 @@ type NasaPhotos struct {
 	Photos []NasaPhoto `json:"photos"`
 }
 type NasaPhoto struct {
-	ImageSrc string `json:"img_src"`
+	ImageSrc  string     `json:"img_src"`
+	EarthDate string     `json:"earth_date"`
+	Camera    NasaCamera `json:"camera"`
+	Rover     NasaRover  `json:"rover"`
+}
+
+type NasaCamera struct {
+	Name string `json:"name"`
+}
+
+type NasaRover struct {
+	Name string `json:"name"`
 }
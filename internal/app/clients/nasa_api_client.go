@@ -3,73 +3,135 @@ package clients
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/sheepla/go-urlbuilder"
+	"github.com/rs/zerolog"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// NasaRoversUrl is the base of the Mars Rover Photos API, photos of a rover are served at <NasaRoversUrl>/<rover>/photos
+const NasaRoversUrl = "https://api.nasa.gov/mars-photos/api/v1/rovers"
+
+// RoverPhotosUrl returns the photos endpoint of rover
+func RoverPhotosUrl(rover string) string {
+	return NasaRoversUrl + "/" + url.PathEscape(strings.ToLower(rover)) + "/photos"
+}
+
 var HTTPClient = http.Client{
 	Timeout: time.Second * 5,
 }
 
+// maxNasaRetries is how many times a NASA call is repeated after a transient failure
+const maxNasaRetries = 2
+
+// nasaRetryBackoff is the wait before the first retry, it doubles for every further one
+var nasaRetryBackoff = 200 * time.Millisecond
+
 type NasaApiClient struct {
 	apiKey string
-	apiUrl *urlbuilder.URL
+	apiUrl url.URL
+	logger zerolog.Logger
 }
 
-func NewNasaApiClient(apiKey string, apiUrl string) NasaApiClient {
-	parsedUrl := urlbuilder.MustParse(apiUrl)
+func NewNasaApiClient(apiKey string, apiUrl string, logger zerolog.Logger) NasaApiClient {
+	parsedUrl, err := url.Parse(apiUrl)
+	if err != nil {
+		panic(fmt.Sprintf("invalid NASA API url %q: %v", apiUrl, err))
+	}
 	return NasaApiClient{
 		apiKey: apiKey,
-		apiUrl: parsedUrl,
+		apiUrl: *parsedUrl,
+		logger: logger,
 	}
 }
 
+// Validators are the HTTP cache validators of a photo listing response
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// APIUrl returns the photo listing URL the client queries, without an API key
+func (c NasaApiClient) APIUrl() string {
+	listingUrl := c.apiUrl
+	query := listingUrl.Query()
+	query.Del("api_key")
+	listingUrl.RawQuery = query.Encode()
+	return listingUrl.String()
+}
+
 func (c NasaApiClient) FindNasaPhotos(ctx context.Context, sol int) (models.NasaPhotos, error) {
+	photos, _, _, err := c.FindNasaPhotosIfModified(ctx, sol, Validators{})
+	return photos, err
+}
+
+// FindNasaPhotosIfModified sends a conditional request with validators. It reports
+// modified=false, and no photos, when upstream answered 304 Not Modified.
+func (c NasaApiClient) FindNasaPhotosIfModified(ctx context.Context, sol int, validators Validators) (models.NasaPhotos, Validators, bool, error) {
 	solStr := strconv.Itoa(sol)
 	var currentUrl = c.buildUrl(c.apiKey, solStr)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, currentUrl, nil)
 	if err != nil {
-		return models.NasaPhotos{}, fmt.Errorf("failed to build request: %w", err)
+		return models.NasaPhotos{}, Validators{}, false, fmt.Errorf("failed to build request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
 
-	resp, err := HTTPClient.Do(req)
+	resp, err := send(req, metrics.NasaOperationListing)
 	if err != nil {
-		return models.NasaPhotos{}, fmt.Errorf("failed to send request: %w", err)
+		return models.NasaPhotos{}, Validators{}, false, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer func() {
 		err := resp.Body.Close()
 		if err != nil {
-			log.Printf("failed to close response body: %v", err)
+			logging.From(ctx, c.logger).Error().Err(err).Msg("failed to close response body")
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return models.NasaPhotos{}, validators, false, nil
+	}
+
 	responseBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return models.NasaPhotos{}, fmt.Errorf("failed to read response body: %w", err)
+		return models.NasaPhotos{}, Validators{}, false, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return models.NasaPhotos{}, fmt.Errorf("unexpected status code: %d, response body: %s\n", resp.StatusCode, responseBytes)
+		return models.NasaPhotos{}, Validators{}, false, fmt.Errorf("unexpected status code: %d, response body: %s\n", resp.StatusCode, responseBytes)
 	}
 
 	var photos models.NasaPhotos
 	if err := json.Unmarshal(responseBytes, &photos); err != nil {
-		return models.NasaPhotos{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+		return models.NasaPhotos{}, Validators{}, false, fmt.Errorf("failed to unmarshal response body: %w", err)
 	}
 	if len(photos.Photos) == 0 {
-		return models.NasaPhotos{}, fmt.Errorf("no photo found")
+		return models.NasaPhotos{}, Validators{}, false, fmt.Errorf("no photo found")
 	}
-	return photos, nil
+	responseValidators := Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	return photos, responseValidators, true, nil
 }
 
 func (c NasaApiClient) FindPhotoSize(ctx *context.Context, imgUrl string) (int, error) {
@@ -78,18 +140,96 @@ func (c NasaApiClient) FindPhotoSize(ctx *context.Context, imgUrl string) (int,
 		return 0, fmt.Errorf("failed to build request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := HTTPClient.Do(req)
+	resp, err := send(req, metrics.NasaOperationPhotoSize)
 	if err != nil {
 		return 0, fmt.Errorf("could not send request: %w", err)
 	}
+	_ = resp.Body.Close()
+	// Error pages carry a Content-Length too, it must not be taken for the size of the image
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return 0, fmt.Errorf("unexpected status code %d for photo %s", resp.StatusCode, imgUrl)
+	}
 	return int(resp.ContentLength), nil
 }
 
+// send sends req and retries network errors, 429 and 5xx responses with a growing backoff.
+// Requests of this client have no body, so they can be sent again as they are.
+// Every attempt gets a client span, the URL is left out of it because it carries the API key.
+func send(req *http.Request, operation string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		_, span := tracing.Tracer().Start(req.Context(), "nasa "+req.Method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("server.address", req.URL.Hostname()),
+				attribute.Int("http.request.resend_count", attempt),
+			))
+		started := time.Now()
+		resp, err := HTTPClient.Do(req)
+		redactURLError(err)
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusBadRequest {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		}
+		tracing.End(span, err)
+		metrics.ObserveNasaRequest(operation, status, time.Since(started))
+		retryable := err != nil || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+		if !retryable || attempt == maxNasaRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		metrics.NasaRetried(operation)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(nasaRetryBackoff << attempt):
+		}
+	}
+}
+
+// Ping checks that the NASA API is reachable and accepts the API key. It is not retried,
+// callers are expected to cache the outcome because every call counts against the key's rate limit.
+func (c NasaApiClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.buildUrl(c.apiKey, "0"), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := HTTPClient.Do(req)
+	redactURLError(err)
+	if err != nil {
+		return fmt.Errorf("nasa api is unreachable: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("nasa api responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// redactURLError removes the API key from the URL quoted by transport errors,
+// so it leaks neither into logs nor into job failures
+func redactURLError(err error) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = logging.Redact(urlErr.URL)
+	}
+}
+
+// buildUrl returns the listing URL of sol. It works on a copy of apiUrl since workers call it concurrently.
 func (c NasaApiClient) buildUrl(apiKey, sol string) string {
-	c.apiUrl.EditQuery(func(q url.Values) url.Values {
-		q.Set("sol", sol)
-		q.Set("api_key", apiKey)
-		return q
-	})
-	return c.apiUrl.MustString()
+	listingUrl := c.apiUrl
+	query := listingUrl.Query()
+	query.Set("sol", sol)
+	query.Set("api_key", apiKey)
+	listingUrl.RawQuery = query.Encode()
+	return listingUrl.String()
 }
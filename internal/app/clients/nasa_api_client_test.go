@@ -0,0 +1,170 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNasaApiClient_Retries(t *testing.T) {
+	backoff := nasaRetryBackoff
+	nasaRetryBackoff = time.Millisecond
+	t.Cleanup(func() {
+		nasaRetryBackoff = backoff
+	})
+	testCases := []struct {
+		name             string
+		statuses         []int
+		expectedAttempts int32
+		expectedError    bool
+	}{
+		{
+			name:             "Should retry transient failures until listing is served",
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Should give up after max retries",
+			statuses:         []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			expectedAttempts: maxNasaRetries + 1,
+			expectedError:    true,
+		},
+		{
+			name:             "Should not retry client errors",
+			statuses:         []int{http.StatusForbidden, http.StatusOK},
+			expectedAttempts: 1,
+			expectedError:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			var attempts atomic.Int32
+			nasa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tc.statuses[attempts.Add(1)-1]
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					_, _ = w.Write([]byte(photosBody))
+				}
+			}))
+			defer nasa.Close()
+			client := NewNasaApiClient("key", nasa.URL, zerolog.Nop())
+
+			// When
+			photos, err := client.FindNasaPhotos(context.Background(), 1000)
+
+			// Then
+			require.Equal(t, tc.expectedAttempts, attempts.Load())
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, photos.Photos, 1)
+			}
+		})
+	}
+}
+
+func TestNasaApiClient_RedactsApiKeyFromErrors(t *testing.T) {
+	// Given
+	backoff := nasaRetryBackoff
+	nasaRetryBackoff = time.Millisecond
+	t.Cleanup(func() {
+		nasaRetryBackoff = backoff
+	})
+	nasa := httptest.NewServer(http.NotFoundHandler())
+	nasa.Close()
+	client := NewNasaApiClient("secret-key", nasa.URL, zerolog.Nop())
+
+	// When
+	_, err := client.FindNasaPhotos(context.Background(), 1000)
+
+	// Then
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secret-key")
+	require.Contains(t, err.Error(), "api_key=REDACTED")
+}
+
+func TestNasaApiClient_Ping(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		expectedError string
+	}{
+		{
+			name:   "Should succeed when NASA accepts the key",
+			status: http.StatusOK,
+		},
+		{
+			name:          "Should fail when NASA rejects the key",
+			status:        http.StatusForbidden,
+			expectedError: "nasa api responded with status code 403",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			var attempts atomic.Int32
+			nasa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tc.status)
+			}))
+			defer nasa.Close()
+			client := NewNasaApiClient("key", nasa.URL, zerolog.Nop())
+
+			// When
+			err := client.Ping(context.Background())
+
+			// Then
+			require.Equal(t, int32(1), attempts.Load())
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNasaApiClient_ConcurrentCallers(t *testing.T) {
+	// Given
+	const callers = 20
+	var mu sync.Mutex
+	requestedSols := map[string]int{}
+	nasa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestedSols[r.URL.Query().Get("sol")]++
+		mu.Unlock()
+		_, _ = w.Write([]byte(photosBody))
+	}))
+	defer nasa.Close()
+	client := NewNasaApiClient("key", nasa.URL, zerolog.Nop())
+
+	// When
+	var wg sync.WaitGroup
+	for sol := 0; sol < callers; sol++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.FindNasaPhotos(context.Background(), sol)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Then
+	require.Len(t, requestedSols, callers)
+	for sol := 0; sol < callers; sol++ {
+		require.Equal(t, 1, requestedSols[strconv.Itoa(sol)], "sol %d", sol)
+	}
+}
@@ -0,0 +1,90 @@
+// Package logging builds the structured logger of the application and carries the
+// request and job IDs that correlate log lines in context.
+package logging
+
+import (
+	"context"
+	"io"
+	"regexp"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Fields correlating log lines with requests, jobs and traces
+const (
+	RequestIDField = "request_id"
+	JobIDField     = "job_id"
+	TraceIDField   = "trace_id"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	jobIDKey
+)
+
+// apiKeyParam matches the value of an api_key query parameter, also when it is URL encoded in another URL
+var apiKeyParam = regexp.MustCompile(`(api_key(?:=|%3D))[^&\s"\\]+`)
+
+// New returns a logger writing JSON events of at least level to w with api_key values redacted.
+// Wrap w in a zerolog.ConsoleWriter for human readable output.
+func New(w io.Writer, level zerolog.Level) zerolog.Logger {
+	return zerolog.New(redactingWriter{w: w}).Level(level).With().Timestamp().Logger()
+}
+
+// Redact replaces the value of every api_key query parameter in s
+func Redact(s string) string {
+	return apiKeyParam.ReplaceAllString(s, "${1}REDACTED")
+}
+
+// redactingWriter redacts api_key values of every event, zerolog writes one event per call
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (rw redactingWriter) Write(p []byte) (int, error) {
+	if _, err := rw.w.Write(apiKeyParam.ReplaceAll(p, []byte("${1}REDACTED"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WithRequestID returns ctx carrying the ID of the HTTP request being served
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, it is empty outside of requests
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithJobID returns ctx carrying the ID of the job being processed
+func WithJobID(ctx context.Context, jobID string) context.Context {
+	return context.WithValue(ctx, jobIDKey, jobID)
+}
+
+// JobID returns the job ID carried by ctx, it is empty outside of jobs
+func JobID(ctx context.Context) string {
+	jobID, _ := ctx.Value(jobIDKey).(string)
+	return jobID
+}
+
+// From returns logger with the request, job and trace IDs carried by ctx
+func From(ctx context.Context, logger zerolog.Logger) *zerolog.Logger {
+	fields := logger.With()
+	if requestID := RequestID(ctx); requestID != "" {
+		fields = fields.Str(RequestIDField, requestID)
+	}
+	if jobID := JobID(ctx); jobID != "" {
+		fields = fields.Str(JobIDField, jobID)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		fields = fields.Str(TraceIDField, spanContext.TraceID().String())
+	}
+	correlated := fields.Logger()
+	return &correlated
+}
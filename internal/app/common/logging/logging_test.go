@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedact(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Should redact api_key query parameter",
+			input:    `Get "https://api.nasa.gov/photos?api_key=secret&sol=1000": EOF`,
+			expected: `Get "https://api.nasa.gov/photos?api_key=REDACTED&sol=1000": EOF`,
+		},
+		{
+			name:     "Should redact api_key at the end of URL",
+			input:    "https://api.nasa.gov/photos?sol=1000&api_key=secret",
+			expected: "https://api.nasa.gov/photos?sol=1000&api_key=REDACTED",
+		},
+		{
+			name:     "Should redact URL encoded api_key",
+			input:    "next=%2Fphotos%3Fapi_key%3Dsecret",
+			expected: "next=%2Fphotos%3Fapi_key%3DREDACTED",
+		},
+		{
+			name:     "Should leave text without api_key alone",
+			input:    "failed to send request: context canceled",
+			expected: "failed to send request: context canceled",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			redacted := Redact(tc.input)
+
+			// Then
+			require.Equal(t, tc.expected, redacted)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Should redact api_key from every event", func(t *testing.T) {
+		// Given
+		var out bytes.Buffer
+		logger := New(&out, zerolog.InfoLevel)
+
+		// When
+		logger.Error().Str("url", "https://api.nasa.gov/photos?api_key=secret").Msg("request failed")
+
+		// Then
+		require.NotContains(t, out.String(), "secret")
+		require.Contains(t, out.String(), `"url":"https://api.nasa.gov/photos?api_key=REDACTED"`)
+	})
+
+	t.Run("Should drop events below level", func(t *testing.T) {
+		// Given
+		var out bytes.Buffer
+		logger := New(&out, zerolog.WarnLevel)
+
+		// When
+		logger.Info().Msg("ignored")
+
+		// Then
+		require.Empty(t, out.String())
+	})
+}
+
+func TestFrom(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	testCases := []struct {
+		name           string
+		ctx            context.Context
+		expectedFields map[string]any
+	}{
+		{
+			name:           "Should add nothing outside of requests and jobs",
+			ctx:            context.Background(),
+			expectedFields: map[string]any{"level": "info", "message": "hello"},
+		},
+		{
+			name: "Should add request and job IDs",
+			ctx:  WithJobID(WithRequestID(context.Background(), "request-1"), "job-1"),
+			expectedFields: map[string]any{
+				"level":        "info",
+				"message":      "hello",
+				RequestIDField: "request-1",
+				JobIDField:     "job-1",
+			},
+		},
+		{
+			name: "Should add trace ID",
+			ctx:  traced,
+			expectedFields: map[string]any{
+				"level":      "info",
+				"message":    "hello",
+				TraceIDField: "4bf92f3577b34da6a3ce929d0e0e4736",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			var out bytes.Buffer
+			logger := zerolog.New(&out)
+
+			// When
+			From(tc.ctx, logger).Info().Msg("hello")
+
+			// Then
+			var fields map[string]any
+			require.NoError(t, json.Unmarshal(out.Bytes(), &fields))
+			require.Equal(t, tc.expectedFields, fields)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package metrics defines every Prometheus metric exposed on /metrics. Metric and label names
+// are part of the public interface of the service, dashboards and alerts rely on them,
+// so they are only ever added, never renamed.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "largest_picture"
+
+// Label values shared by several metrics
+const (
+	ResultOK       = "ok"
+	ResultError    = "error"
+	ResultRequeued = "requeued"
+	ResultRejected = "rejected"
+
+	OutcomeCompleted   = "completed"
+	OutcomeFailed      = "failed"
+	OutcomeInterrupted = "interrupted"
+
+	NasaOperationListing   = "listing"
+	NasaOperationPhotoSize = "photo_size"
+
+	// RouteUnmatched labels requests that did not match any route
+	RouteUnmatched = "unmatched"
+	// StatusCodeError labels NASA calls that failed before a response arrived
+	StatusCodeError = "error"
+)
+
+// Registry holds the metrics of this package together with the Go runtime and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	// httpRequests counts served requests by method, route template and status code
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	// httpRequestDuration measures how long requests took until the handler returned
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// queuePublished counts commands published to the queue by the outbox relay
+	queuePublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_published_total",
+		Help:      "Commands published to the command queue, by result: ok or error.",
+	}, []string{"result"})
+
+	// queueConsumed counts commands taken off the queue by the workers
+	queueConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_consumed_total",
+		Help:      "Commands consumed from the command queue, by result: ok, requeued or rejected.",
+	}, []string{"result"})
+
+	// jobsFinished counts settled jobs by outcome and the error slug of failed ones
+	jobsFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_finished_total",
+		Help:      "Jobs that finished, by outcome: completed, failed or interrupted, and reason: the error slug or none.",
+	}, []string{"outcome", "reason"})
+
+	// jobPhotosSized records how many photos one job sized
+	jobPhotosSized = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_photos_sized",
+		Help:      "Photos sized by a single job.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 13),
+	})
+
+	// nasaRequests counts every attempt of a NASA API call by its status code
+	nasaRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nasa_requests_total",
+		Help:      "NASA API requests, by operation: listing or photo_size, and status code or error.",
+	}, []string{"operation", "status_code"})
+
+	// nasaRequestDuration measures every attempt of a NASA API call
+	nasaRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "nasa_request_duration_seconds",
+		Help:      "NASA API request latency, by operation: listing or photo_size.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	// nasaRetries counts NASA API calls repeated after a transient failure
+	nasaRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nasa_retries_total",
+		Help:      "NASA API requests retried after a transient failure, by operation: listing or photo_size.",
+	}, []string{"operation"})
+
+	// dbQueryDuration measures queries run through bun
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency, by operation such as SELECT or INSERT and result: ok or error.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "result"})
+
+	// workersInFlight is how many commands the workers are processing right now
+	workersInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workers_in_flight",
+		Help:      "Commands being processed by the command workers right now.",
+	})
+
+	// workers is how many commands the workers may process at once
+	workers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workers",
+		Help:      "Command workers started, the most commands processed at once.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		queuePublished,
+		queueConsumed,
+		jobsFinished,
+		jobPhotosSized,
+		nasaRequests,
+		nasaRequestDuration,
+		nasaRetries,
+		dbQueryDuration,
+		workersInFlight,
+		workers,
+	)
+}
+
+// Handler serves Registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest records a served request, route is the route template, not the raw path
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+	httpRequestDuration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
+
+// CommandPublished records a publish to the command queue
+func CommandPublished(err error) {
+	queuePublished.WithLabelValues(result(err)).Inc()
+}
+
+// CommandConsumed records a command taken off the queue, result is ResultOK, ResultRequeued or ResultRejected
+func CommandConsumed(result string) {
+	queueConsumed.WithLabelValues(result).Inc()
+}
+
+// JobFinished records the outcome of a job, reason is the error slug of a failed job
+func JobFinished(outcome, reason string) {
+	if reason == "" {
+		reason = "none"
+	}
+	jobsFinished.WithLabelValues(outcome, reason).Inc()
+}
+
+// PhotosSized records how many photos a job sized
+func PhotosSized(count int) {
+	jobPhotosSized.Observe(float64(count))
+}
+
+// ObserveNasaRequest records one attempt of a NASA API call, status is 0 when no response arrived
+func ObserveNasaRequest(operation string, status int, duration time.Duration) {
+	statusLabel := StatusCodeError
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	nasaRequests.WithLabelValues(operation, statusLabel).Inc()
+	nasaRequestDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// NasaRetried records a NASA API call about to be repeated
+func NasaRetried(operation string) {
+	nasaRetries.WithLabelValues(operation).Inc()
+}
+
+// SetWorkers records how many command workers were started
+func SetWorkers(count int) {
+	workers.Set(float64(count))
+}
+
+// WorkerStarted records a command picked up by a worker
+func WorkerStarted() {
+	workersInFlight.Inc()
+}
+
+// WorkerFinished records a command a worker is done with
+func WorkerFinished() {
+	workersInFlight.Dec()
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultOK
+}
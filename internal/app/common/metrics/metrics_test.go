@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	// Given
+	ObserveHTTPRequest(http.MethodGet, "/mars/pictures/largest/command/{sol}", http.StatusOK, time.Millisecond)
+	CommandPublished(errors.New("broker is down"))
+	CommandConsumed(ResultOK)
+	JobFinished(OutcomeFailed, "could-not-find-nasa-photos")
+	PhotosSized(12)
+	ObserveNasaRequest(NasaOperationListing, 0, time.Millisecond)
+	NasaRetried(NasaOperationListing)
+	SetWorkers(4)
+	WorkerStarted()
+	w := httptest.NewRecorder()
+
+	// When
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Then
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	for _, series := range []string{
+		`largest_picture_http_requests_total{method="GET",route="/mars/pictures/largest/command/{sol}",status="200"} 1`,
+		`largest_picture_http_request_duration_seconds_count{method="GET",route="/mars/pictures/largest/command/{sol}",status="200"} 1`,
+		`largest_picture_queue_published_total{result="error"} 1`,
+		`largest_picture_queue_consumed_total{result="ok"} 1`,
+		`largest_picture_jobs_finished_total{outcome="failed",reason="could-not-find-nasa-photos"} 1`,
+		`largest_picture_job_photos_sized_count 1`,
+		`largest_picture_nasa_requests_total{operation="listing",status_code="error"} 1`,
+		`largest_picture_nasa_retries_total{operation="listing"} 1`,
+		`largest_picture_workers 4`,
+		`largest_picture_workers_in_flight 1`,
+		`go_goroutines`,
+	} {
+		require.Contains(t, body, series)
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// QueryHook records the latency of every bun query in db_query_duration_seconds
+type QueryHook struct{}
+
+var _ bun.QueryHook = QueryHook{}
+
+func (QueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (QueryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	queryResult := ResultOK
+	// A missing row is an answer, not a failed query
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		queryResult = ResultError
+	}
+	dbQueryDuration.WithLabelValues(event.Operation(), queryResult).Observe(time.Since(event.StartTime).Seconds())
+}
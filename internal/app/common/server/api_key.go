@@ -0,0 +1,20 @@
+package server
+
+import (
+	"context"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+type apiKeyContextKey struct{}
+
+// WithAPIKey returns ctx carrying the key that authenticated the request
+func WithAPIKey(ctx context.Context, key domain.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFromContext returns the key that authenticated the request, it is missing when authentication is disabled
+func APIKeyFromContext(ctx context.Context) (domain.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(domain.APIKey)
+	return key, ok
+}
@@ -2,9 +2,13 @@ package server
 
 import (
 	"encoding/json"
-	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
 )
 
 func InternalError(slug string, err error, w http.ResponseWriter, r *http.Request) {
@@ -19,8 +23,37 @@ func NotFound(slug string, err error, w http.ResponseWriter, r *http.Request) {
 	httpRespondWithError(err, slug, w, r, "Not found", http.StatusNotFound)
 }
 
+func Unauthorized(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `ApiKey realm="api"`)
+	httpRespondWithError(err, slug, w, r, "Unauthorized", http.StatusUnauthorized)
+}
+
+func Forbidden(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Forbidden", http.StatusForbidden)
+}
+
+func Conflict(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Conflict", http.StatusConflict)
+}
+
+func UnprocessableEntity(slug string, err error, w http.ResponseWriter, r *http.Request) {
+	httpRespondWithError(err, slug, w, r, "Unprocessable entity", http.StatusUnprocessableEntity)
+}
+
+// TooManyRequests tells the client to come back after retryAfter, rounded up to whole seconds
+func TooManyRequests(slug string, err error, retryAfter time.Duration, w http.ResponseWriter, r *http.Request) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(max(1, seconds), 10))
+	httpRespondWithError(err, slug, w, r, "Too many requests", http.StatusTooManyRequests)
+}
+
 func httpRespondWithError(err error, slug string, w http.ResponseWriter, r *http.Request, msg string, status int) {
-	log.Printf("error: %s, slug: %s, msg: %s", err, slug, msg)
+	// The logging middleware puts a logger carrying the request ID in the context
+	event := zerolog.Ctx(r.Context()).Warn()
+	if status >= http.StatusInternalServerError {
+		event = zerolog.Ctx(r.Context()).Error()
+	}
+	event.Err(err).Str("slug", slug).Int("status", status).Msg(msg)
 
 	resp := ErrorResponse{Slug: slug, httpStatus: status}
 	if os.Getenv("DEBUG_ERRORS") != "" && err != nil {
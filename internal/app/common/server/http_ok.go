@@ -9,11 +9,31 @@ type SuccessResponse struct {
 	Sol     int    `json:"sol,omitempty"`
 	ImgSrc  string `json:"img_src,omitempty"`
 	Size    int    `json:"size,omitempty"`
+	JobID   string `json:"job_id,omitempty"`
 	Message string `json:"message,omitempty"`
 }
 
 func RespondOK(data SuccessResponse, w http.ResponseWriter) {
+	RespondJSON(data, w)
+}
+
+// RespondJSON writes any JSON serializable payload with 200 status
+func RespondJSON(data interface{}, w http.ResponseWriter) {
+	RespondWithStatus(data, http.StatusOK, w)
+}
+
+// RespondCreated writes any JSON serializable payload with 201 status
+func RespondCreated(data interface{}, w http.ResponseWriter) {
+	RespondWithStatus(data, http.StatusCreated, w)
+}
+
+func RespondNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RespondWithStatus writes any JSON serializable payload with the given status
+func RespondWithStatus(data interface{}, status int, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(data)
 }
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a field of Config with the names it is set by
+type setting struct {
+	index  int
+	env    string
+	key    string
+	flag   string
+	redact string
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// settings lists every field of Config in declaration order
+var settings = func() []setting {
+	configType := reflect.TypeOf(Config{})
+	all := make([]setting, 0, configType.NumField())
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		env := field.Tag.Get("env")
+		if env == "" {
+			panic(fmt.Sprintf("config field %s has no env tag", field.Name))
+		}
+		key := strings.ToLower(env)
+		all = append(all, setting{
+			index:  i,
+			env:    env,
+			key:    key,
+			flag:   strings.ReplaceAll(key, "_", "-"),
+			redact: field.Tag.Get("redact"),
+		})
+	}
+	return all
+}()
+
+// Overrides are setting values given on the command line by config file key
+type Overrides map[string]string
+
+// RegisterFlags adds a flag for every setting to fs, the flags given end up in the returned Overrides once fs is parsed
+func RegisterFlags(fs *flag.FlagSet) Overrides {
+	overrides := Overrides{}
+	defaults := reflect.ValueOf(Default())
+	for _, s := range settings {
+		value := overrideValue{key: s.key, overrides: overrides, isBool: defaults.Field(s.index).Kind() == reflect.Bool}
+		fs.Var(value, s.flag, fmt.Sprintf("overrides %s (default %s)", s.env, formatValue(defaults.Field(s.index))))
+	}
+	return overrides
+}
+
+// overrideValue collects a flag value as it was given, it is parsed by Load like any other layer
+type overrideValue struct {
+	key       string
+	overrides Overrides
+	isBool    bool
+}
+
+func (v overrideValue) String() string {
+	return ""
+}
+
+func (v overrideValue) Set(raw string) error {
+	v.overrides[v.key] = raw
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given as --api-auth without a value
+func (v overrideValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// Load layers the settings: defaults, then the YAML or TOML file at path unless it is empty,
+// then environment variables read with lookupEnv, then overrides. A file that cannot be read,
+// every value that cannot be parsed and every unknown setting of the file are reported together
+// as Problems, the returned Config then holds every other value so it can still be printed.
+// The result is not validated, see Validate.
+func Load(path string, lookupEnv func(string) (string, bool), overrides Overrides) (Config, error) {
+	config := Default()
+	target := reflect.ValueOf(&config).Elem()
+	var problems Problems
+
+	if path != "" {
+		fileValues, err := readFile(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, s := range settings {
+			value, ok := fileValues[s.key]
+			if !ok {
+				continue
+			}
+			delete(fileValues, s.key)
+			raw, ok := scalar(value)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: %s must be a single value", path, s.key))
+				continue
+			}
+			if err := setValue(target.Field(s.index), raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s: %v", path, s.key, err))
+			}
+		}
+		for key := range fileValues {
+			problems = append(problems, fmt.Sprintf("%s: unknown setting %q", path, key))
+		}
+	}
+
+	for _, s := range settings {
+		raw, ok := lookupEnv(s.env)
+		if !ok {
+			continue
+		}
+		if err := setValue(target.Field(s.index), raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.env, err))
+		}
+	}
+
+	for _, s := range settings {
+		raw, ok := overrides[s.key]
+		if !ok {
+			continue
+		}
+		if err := setValue(target.Field(s.index), raw); err != nil {
+			problems = append(problems, fmt.Sprintf("--%s: %v", s.flag, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return config, problems
+	}
+	return config, nil
+}
+
+// readFile decodes a YAML or TOML file, selected by its extension
+func readFile(path string) (map[string]any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var decoded map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &decoded)
+	case ".toml":
+		err = toml.Unmarshal(content, &decoded)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return decoded, nil
+}
+
+// scalar returns a decoded file value in the syntax setValue parses, lists and tables are not settings
+func scalar(value any) (string, bool) {
+	switch value.(type) {
+	case nil:
+		return "", true
+	case string, bool, int, int64, uint64, float64:
+		return fmt.Sprint(value), true
+	default:
+		return "", false
+	}
+}
+
+// setValue parses raw into field according to its type
+func setValue(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, use a number with a unit such as 30s or 5m", raw)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(f)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q, use true or false", raw)
+		}
+		field.SetBool(b)
+	default:
+		panic(fmt.Sprintf("unsupported config field type %s", field.Type()))
+	}
+	return nil
+}
+
+// formatValue returns field in the syntax setValue parses
+func formatValue(field reflect.Value) string {
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String()
+	}
+	return fmt.Sprint(field.Interface())
+}
+
+// MarshalYAML writes the settings in declaration order under their config file keys,
+// so the output of config print can be used as a config file
+func (c Config) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	value := reflect.ValueOf(c)
+	for _, s := range settings {
+		field := value.Field(s.index)
+		tag := "!!str"
+		switch {
+		case field.Type() == durationType:
+		case field.Kind() == reflect.Int:
+			tag = "!!int"
+		case field.Kind() == reflect.Float64:
+			tag = "!!float"
+		case field.Kind() == reflect.Bool:
+			tag = "!!bool"
+		}
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: formatValue(field)},
+		)
+	}
+	return node, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LogLevels are the values accepted by LOG_LEVEL, least severe first
+var LogLevels = []string{"trace", "debug", "info", "warn", "error"}
+
+const redactedValue = "REDACTED"
+
+// dsnPassword matches the password of a key=value Postgres connection string
+var dsnPassword = regexp.MustCompile(`(password=)(?:'(?:[^'\\]|\\.)*'|\S+)`)
+
+// Problems lists every invalid setting, Load and Validate report them all at once
+type Problems []string
+
+func (p Problems) Error() string {
+	return "invalid configuration:\n" + strings.Join(p, "\n")
+}
+
+// Merge combines the problems reported by Load and Validate into one report, nil when there are none
+func Merge(errs ...error) error {
+	var all Problems
+	for _, err := range errs {
+		var problems Problems
+		switch {
+		case errors.As(err, &problems):
+			all = append(all, problems...)
+		case err != nil:
+			all = append(all, err.Error())
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return all
+}
+
+// Validate reports every missing or out of range setting at once
+func (c Config) Validate() error {
+	var problems Problems
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		check(slices.Contains(allowed, value), "%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+	}
+	positive := func(key string, value int) {
+		check(value > 0, "%s must be positive, got %d", key, value)
+	}
+	positiveDuration := func(key string, value time.Duration) {
+		check(value > 0, "%s must be a positive duration, got %s", key, value)
+	}
+	address := func(key, value string) {
+		_, _, err := net.SplitHostPort(value)
+		check(err == nil, "%s must be a host:port address, got %q", key, value)
+	}
+
+	address("http_addr", c.HTTPAddr)
+	address("grpc_addr", c.GRPCAddr)
+	check(c.DSN != "", "dsn is required, set DSN, --dsn or dsn in the config file")
+	check(c.MigrationsPath != "", "migrations_path is required")
+	apiURL, err := url.Parse(c.APIUrl)
+	check(err == nil && (apiURL.Scheme == "http" || apiURL.Scheme == "https") && apiURL.Host != "",
+		"api_url must be an http or https URL, got %q", c.APIUrl)
+	oneOf("queue_backend", c.QueueBackend, QueueBackendRabbitMQ, QueueBackendPostgres, QueueBackendMemory)
+	if c.QueueBackend == QueueBackendRabbitMQ {
+		check(c.RabbitMQURL != "", "rabbitmq_url is required with the rabbitmq queue backend")
+	}
+
+	positive("workers", c.Workers)
+	positiveDuration("job_timeout", c.JobTimeout)
+	positiveDuration("worker_shutdown_timeout", c.WorkerShutdownTimeout)
+	positiveDuration("command_coalesce_window", c.CommandCoalesceWindow)
+	check(c.ShutdownDrainDelay >= 0, "shutdown_drain_delay must not be negative, got %s", c.ShutdownDrainDelay)
+
+	oneOf("nasa_cache", c.NasaCache, NasaCacheMemory, NasaCachePostgres, NasaCacheNone)
+	positive("nasa_cache_size", c.NasaCacheSize)
+	positiveDuration("nasa_listing_ttl", c.NasaListingTTL)
+	positiveDuration("nasa_size_ttl", c.NasaSizeTTL)
+	positiveDuration("nasa_health_check_ttl", c.NasaHealthCheckTTL)
+
+	positive("picture_cache_size", c.PictureCacheSize)
+	positiveDuration("picture_cache_ttl", c.PictureCacheTTL)
+
+	oneOf("rate_limit_store", c.RateLimitStore, RateLimitStoreMemory, RateLimitStorePostgres, RateLimitStoreNone)
+	positive("rate_limit_read_per_minute", c.RateLimitReadPerMinute)
+	positive("rate_limit_read_burst", c.RateLimitReadBurst)
+	positive("rate_limit_submit_per_minute", c.RateLimitSubmitPerMinute)
+	positive("rate_limit_submit_burst", c.RateLimitSubmitBurst)
+
+	positiveDuration("idempotency_ttl", c.IdempotencyTTL)
+
+	oneOf("tracing_exporter", c.TracingExporter, TracingExporterNone, TracingExporterOTLP, TracingExporterStdout)
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1,
+		"tracing_sample_ratio must be between 0 and 1, got %g", c.TracingSampleRatio)
+
+	oneOf("log_level", c.LogLevel, LogLevels...)
+	oneOf("log_format", c.LogFormat, LogFormatJSON, LogFormatConsole)
+
+	positive("graphql_max_depth", c.GraphqlMaxDepth)
+	positive("graphql_max_complexity", c.GraphqlMaxComplexity)
+
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// Redacted returns c with the settings tagged redact masked: secrets entirely,
+// URLs and connection strings only in their password
+func (c Config) Redacted() Config {
+	value := reflect.ValueOf(&c).Elem()
+	for _, s := range settings {
+		field := value.Field(s.index)
+		if field.String() == "" {
+			continue
+		}
+		switch s.redact {
+		case "secret":
+			field.SetString(redactedValue)
+		case "url":
+			field.SetString(redactPassword(field.String()))
+		}
+	}
+	return c
+}
+
+// redactPassword masks the password of a URL or of a key=value connection string
+func redactPassword(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Scheme != "" {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redactedValue)
+		}
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", redactedValue)
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(raw, "${1}"+redactedValue)
+}
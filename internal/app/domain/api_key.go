@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrUnauthorized  = errors.New("missing, unknown or revoked api key")
+	ErrForbidden     = errors.New("api key lacks the required scope")
+)
+
+type APIKeyScope string
+
+const (
+	// APIKeyScopeRead allows reading pictures, jobs, events and webhooks
+	APIKeyScopeRead APIKeyScope = "read"
+	// APIKeyScopeSubmit allows submitting commands and managing webhooks
+	APIKeyScopeSubmit APIKeyScope = "submit"
+)
+
+// APIKeyScopes are every scope a key can be granted
+var APIKeyScopes = []APIKeyScope{APIKeyScopeRead, APIKeyScopeSubmit}
+
+// QuotaKind names a per key quota and the window it is counted in
+type QuotaKind string
+
+const (
+	// QuotaRequests counts authenticated requests per minute
+	QuotaRequests QuotaKind = "requests"
+	// QuotaEnqueues counts submitted commands per hour
+	QuotaEnqueues QuotaKind = "enqueues"
+)
+
+// Window is how long a quota of kind is counted before it resets
+func (k QuotaKind) Window() time.Duration {
+	if k == QuotaEnqueues {
+		return time.Hour
+	}
+	return time.Minute
+}
+
+// QuotaExceededError is returned once a key spent its quota in the current window
+type QuotaExceededError struct {
+	Kind       QuotaKind
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded, retry after %s", e.Kind, e.RetryAfter)
+}
+
+// APIKey identifies a client of the API. Only the SHA-256 hash of the secret is kept,
+// the secret itself is shown once when the key is created.
+type APIKey struct {
+	id           string
+	name         string
+	hash         string
+	prefix       string
+	scopes       []APIKeyScope
+	requestQuota int
+	enqueueQuota int
+	createdAt    time.Time
+	revokedAt    time.Time
+}
+
+type NewAPIKeyData struct {
+	ID     string
+	Name   string
+	Hash   string
+	Prefix string
+	Scopes []APIKeyScope
+	// RequestQuota is how many requests the key may send per minute, zero means unlimited
+	RequestQuota int
+	// EnqueueQuota is how many commands the key may submit per hour, zero means unlimited
+	EnqueueQuota int
+	CreatedAt    time.Time
+	RevokedAt    time.Time
+}
+
+// NewAPIKey Constructor for APIKey struct
+func NewAPIKey(key NewAPIKeyData) APIKey {
+	return APIKey{
+		id:           key.ID,
+		name:         key.Name,
+		hash:         key.Hash,
+		prefix:       key.Prefix,
+		scopes:       key.Scopes,
+		requestQuota: key.RequestQuota,
+		enqueueQuota: key.EnqueueQuota,
+		createdAt:    key.CreatedAt,
+		revokedAt:    key.RevokedAt,
+	}
+}
+
+// NewAPIKeySecret returns a random secret and its hash, the prefix helps to recognise a key in listings
+func NewAPIKeySecret() (secret string, hash string, prefix string) {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	secret = "mpk_" + hex.EncodeToString(b)
+	return secret, HashAPIKeySecret(secret), secret[:12]
+}
+
+// HashAPIKeySecret returns the hex encoded SHA-256 of secret. Secrets are random,
+// so a fast unsalted hash is enough to make a leaked table useless.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (k APIKey) GetID() string {
+	return k.id
+}
+
+func (k APIKey) GetName() string {
+	return k.name
+}
+
+func (k APIKey) GetHash() string {
+	return k.hash
+}
+
+func (k APIKey) GetPrefix() string {
+	return k.prefix
+}
+
+func (k APIKey) GetScopes() []APIKeyScope {
+	return k.scopes
+}
+
+func (k APIKey) GetRequestQuota() int {
+	return k.requestQuota
+}
+
+func (k APIKey) GetEnqueueQuota() int {
+	return k.enqueueQuota
+}
+
+func (k APIKey) GetCreatedAt() time.Time {
+	return k.createdAt
+}
+
+func (k APIKey) GetRevokedAt() time.Time {
+	return k.revokedAt
+}
+
+func (k APIKey) IsRevoked() bool {
+	return !k.revokedAt.IsZero()
+}
+
+// HasScope reports whether key was granted scope
+func (k APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Quota returns the limit of kind, zero means unlimited
+func (k APIKey) Quota(kind QuotaKind) int {
+	if kind == QuotaEnqueues {
+		return k.enqueueQuota
+	}
+	return k.requestQuota
+}
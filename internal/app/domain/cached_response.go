@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// CachedResponse is an upstream response body kept together with its HTTP validators,
+// so it can be revalidated with a conditional request once it expires
+type CachedResponse struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	ExpiresAt    time.Time
+}
+
+// IsFresh reports whether the response can be served without asking upstream
+func (r CachedResponse) IsFresh(now time.Time) bool {
+	return now.Before(r.ExpiresAt)
+}
+
+// CanRevalidate reports whether upstream can answer 304 Not Modified for the response
+func (r CachedResponse) CanRevalidate() bool {
+	return r.ETag != "" || r.LastModified != ""
+}
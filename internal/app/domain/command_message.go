@@ -0,0 +1,49 @@
+package domain
+
+// CommandAcknowledger settles a message with the queue backend it was received from
+type CommandAcknowledger interface {
+	Ack() error
+	Nack(requeue bool) error
+}
+
+// CommandMessage is a raw command received from a command queue.
+// Exactly one of Ack or Nack must be called once the command is handled.
+type CommandMessage struct {
+	body         []byte
+	headers      map[string]string
+	acknowledger CommandAcknowledger
+}
+
+func NewCommandMessage(body []byte, acknowledger CommandAcknowledger) CommandMessage {
+	return CommandMessage{body: body, acknowledger: acknowledger}
+}
+
+// WithHeaders returns the message carrying headers, such as the trace context of its publisher
+func (m CommandMessage) WithHeaders(headers map[string]string) CommandMessage {
+	m.headers = headers
+	return m
+}
+
+func (m CommandMessage) GetBody() []byte {
+	return m.body
+}
+
+func (m CommandMessage) GetHeaders() map[string]string {
+	return m.headers
+}
+
+// Ack removes the message from the queue
+func (m CommandMessage) Ack() error {
+	if m.acknowledger == nil {
+		return nil
+	}
+	return m.acknowledger.Ack()
+}
+
+// Nack rejects the message, putting it back to the queue when requeue is true
+func (m CommandMessage) Nack(requeue bool) error {
+	if m.acknowledger == nil {
+		return nil
+	}
+	return m.acknowledger.Nack(requeue)
+}
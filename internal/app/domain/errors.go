@@ -5,4 +5,6 @@ import "errors"
 var (
 	ErrNotFound                  = errors.New("not found")
 	ErrCalculationLargestPicture = errors.New("error calculating largest picture")
+	ErrPictureAlreadyExists      = errors.New("picture already exists")
+	ErrJobAlreadyActive          = errors.New("sol already has a queued or running job")
 )
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// MaxIdempotencyKeyLength bounds the Idempotency-Key chosen by a client
+const MaxIdempotencyKeyLength = 255
+
+var (
+	ErrInvalidIdempotencyKey    = errors.New("idempotency key must be 1 to 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still in progress")
+)
+
+// IdempotencyRecord remembers the request a client sent with an Idempotency-Key and,
+// once it finished, the response replayed to every repeat of the request
+type IdempotencyRecord struct {
+	key         string
+	requestHash string
+	statusCode  int
+	body        []byte
+	createdAt   time.Time
+	expiresAt   time.Time
+}
+
+type NewIdempotencyRecordData struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// NewIdempotencyRecord Constructor for IdempotencyRecord struct
+func NewIdempotencyRecord(data NewIdempotencyRecordData) IdempotencyRecord {
+	return IdempotencyRecord{
+		key:         data.Key,
+		requestHash: data.RequestHash,
+		statusCode:  data.StatusCode,
+		body:        data.Body,
+		createdAt:   data.CreatedAt,
+		expiresAt:   data.ExpiresAt,
+	}
+}
+
+// HashIdempotentRequest fingerprints a request, repeats must match it to be replayed
+func HashIdempotentRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (r IdempotencyRecord) GetKey() string {
+	return r.key
+}
+
+func (r IdempotencyRecord) GetRequestHash() string {
+	return r.requestHash
+}
+
+func (r IdempotencyRecord) GetStatusCode() int {
+	return r.statusCode
+}
+
+func (r IdempotencyRecord) GetBody() []byte {
+	return r.body
+}
+
+func (r IdempotencyRecord) GetCreatedAt() time.Time {
+	return r.createdAt
+}
+
+func (r IdempotencyRecord) GetExpiresAt() time.Time {
+	return r.expiresAt
+}
+
+// IsCompleted tells whether the response of the request has been stored
+func (r IdempotencyRecord) IsCompleted() bool {
+	return r.statusCode != 0
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// ActiveJobStatuses are the statuses of a job that has not finished yet
+var ActiveJobStatuses = []JobStatus{JobStatusQueued, JobStatusRunning}
+
+// Job tracks a single largest picture calculation requested for a sol
+type Job struct {
+	id          string
+	sol         int
+	status      JobStatus
+	photosSized int
+	photosTotal int
+	errorSlug   string
+	createdAt   time.Time
+	updatedAt   time.Time
+}
+
+type NewJobData struct {
+	ID          string
+	Sol         int
+	Status      JobStatus
+	PhotosSized int
+	PhotosTotal int
+	ErrorSlug   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewJob Constructor for Job struct
+func NewJob(job NewJobData) Job {
+	return Job{
+		id:          job.ID,
+		sol:         job.Sol,
+		status:      job.Status,
+		photosSized: job.PhotosSized,
+		photosTotal: job.PhotosTotal,
+		errorSlug:   job.ErrorSlug,
+		createdAt:   job.CreatedAt,
+		updatedAt:   job.UpdatedAt,
+	}
+}
+
+// NewQueuedJob creates a job for sol with a fresh random identifier
+func NewQueuedJob(sol int, now time.Time) Job {
+	return NewJob(NewJobData{
+		ID:        NewJobID(),
+		Sol:       sol,
+		Status:    JobStatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+// NewJobID returns a random 128-bit hex encoded identifier
+func NewJobID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (j Job) GetID() string {
+	return j.id
+}
+
+func (j Job) GetSol() int {
+	return j.sol
+}
+
+func (j Job) GetStatus() JobStatus {
+	return j.status
+}
+
+func (j Job) GetPhotosSized() int {
+	return j.photosSized
+}
+
+func (j Job) GetPhotosTotal() int {
+	return j.photosTotal
+}
+
+func (j Job) GetErrorSlug() string {
+	return j.errorSlug
+}
+
+func (j Job) GetCreatedAt() time.Time {
+	return j.createdAt
+}
+
+func (j Job) GetUpdatedAt() time.Time {
+	return j.updatedAt
+}
+
+// Start marks job as picked up by a worker
+func (j *Job) Start(now time.Time) {
+	j.status = JobStatusRunning
+	j.updatedAt = now
+}
+
+// Progress records how many photos were sized so far
+func (j *Job) Progress(sized, total int, now time.Time) {
+	j.photosSized = sized
+	j.photosTotal = total
+	j.updatedAt = now
+}
+
+// Complete marks job as successfully finished
+func (j *Job) Complete(now time.Time) {
+	j.status = JobStatusCompleted
+	j.errorSlug = ""
+	j.updatedAt = now
+}
+
+// Fail marks job as finished with an error identified by slug
+func (j *Job) Fail(slug string, now time.Time) {
+	j.status = JobStatusFailed
+	j.errorSlug = slug
+	j.updatedAt = now
+}
+
+// SolCommand is the message put on the queue to calculate largest picture for a sol
+type SolCommand struct {
+	JobID string `json:"job_id"`
+	Sol   int    `json:"sol"`
+}
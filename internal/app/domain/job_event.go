@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+type JobEventType string
+
+const (
+	JobEventQueued    JobEventType = "queued"
+	JobEventProgress  JobEventType = "progress"
+	JobEventCompleted JobEventType = "completed"
+	JobEventFailed    JobEventType = "failed"
+)
+
+// JobEvent describes a single step of a job lifecycle.
+// ID is assigned by the event broker and grows monotonically.
+type JobEvent struct {
+	ID          int64
+	Type        JobEventType
+	JobID       string
+	Sol         int
+	PhotosSized int
+	PhotosTotal int
+	Picture     *Picture
+	Slug        string
+	CreatedAt   time.Time
+}
+
+// JobEventFilter selects events of a single job or sol. Zero values match everything.
+type JobEventFilter struct {
+	JobID string
+	Sol   int
+}
+
+// Matches reports whether event passes the filter
+func (f JobEventFilter) Matches(event JobEvent) bool {
+	if f.JobID != "" && f.JobID != event.JobID {
+		return false
+	}
+	if f.Sol != 0 && f.Sol != event.Sol {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+const (
+	DefaultLeaderboardLimit = 10
+	MaxLeaderboardLimit     = 100
+)
+
+// LeaderboardFilter narrows down the global leaderboard of largest pictures.
+// Zero values mean "no filter" for Rover, Camera, From and To.
+type LeaderboardFilter struct {
+	Limit  int
+	Rover  string
+	Camera string
+	From   time.Time
+	To     time.Time
+}
+
+// CameraCount is how many computed sols have their largest picture taken by Camera
+type CameraCount struct {
+	Camera   string
+	Pictures int
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// OutboxMessage is a command durably recorded together with its job and waiting to be published
+type OutboxMessage struct {
+	ID        int64
+	Command   SolCommand
+	Attempts  int
+	CreatedAt time.Time
+	// TraceContext continues the trace of the request that accepted the command
+	TraceContext map[string]string
+}
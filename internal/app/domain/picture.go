@@ -1,23 +1,37 @@
 package domain
 
+import "time"
+
 type Picture struct {
-	sol  int
-	size int
-	url  string
+	sol       int
+	size      int
+	url       string
+	rover     string
+	camera    string
+	earthDate time.Time
+	updatedAt time.Time
 }
 
 type NewPictureData struct {
-	Size int
-	Sol  int
-	Url  string
+	Size      int
+	Sol       int
+	Url       string
+	Rover     string
+	Camera    string
+	EarthDate time.Time
+	UpdatedAt time.Time
 }
 
 // NewPicture Constructor for Picture struct
 func NewPicture(pic NewPictureData) Picture {
 	return Picture{
-		sol:  pic.Sol,
-		size: pic.Size,
-		url:  pic.Url,
+		sol:       pic.Sol,
+		size:      pic.Size,
+		url:       pic.Url,
+		rover:     pic.Rover,
+		camera:    pic.Camera,
+		earthDate: pic.EarthDate,
+		updatedAt: pic.UpdatedAt,
 	}
 }
 
@@ -34,3 +48,28 @@ func (p Picture) GetUrl() string {
 func (p Picture) GetSize() int {
 	return p.size
 }
+
+// GetRover Getter for Rover field
+func (p Picture) GetRover() string {
+	return p.rover
+}
+
+// GetCamera Getter for Camera field
+func (p Picture) GetCamera() string {
+	return p.camera
+}
+
+// GetEarthDate Getter for EarthDate field
+func (p Picture) GetEarthDate() time.Time {
+	return p.earthDate
+}
+
+// GetUpdatedAt Getter for UpdatedAt field, it is zero until the picture is saved
+func (p Picture) GetUpdatedAt() time.Time {
+	return p.updatedAt
+}
+
+// Touch records now as the time the picture was last saved
+func (p *Picture) Touch(now time.Time) {
+	p.updatedAt = now
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit is a token bucket holding up to Burst tokens that refills at Rate tokens per second
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// NewRateLimit returns a limit allowing perMinute requests a minute on average and burst at once
+func NewRateLimit(perMinute int, burst int) RateLimit {
+	return RateLimit{Rate: float64(perMinute) / 60, Burst: burst}
+}
+
+// RefillTime is how long an empty bucket takes to fill up
+func (l RateLimit) RefillTime() time.Duration {
+	return seconds(float64(l.Burst) / l.Rate)
+}
+
+// TokenBucket is the state of one client's bucket, the zero value is a full bucket
+type TokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// RateLimitDecision tells whether a request was let through and how the bucket looks afterwards
+type RateLimitDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, zero when this one was
+	RetryAfter time.Duration
+}
+
+// Take refills the bucket for the time passed since its last update and takes one token when there is one
+func (b TokenBucket) Take(limit RateLimit, now time.Time) (TokenBucket, RateLimitDecision) {
+	burst := float64(limit.Burst)
+	tokens := burst
+	if !b.UpdatedAt.IsZero() {
+		tokens = b.Tokens
+		if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+			tokens = math.Min(burst, tokens+elapsed*limit.Rate)
+		}
+	}
+
+	decision := RateLimitDecision{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	decision.Remaining = int(math.Floor(tokens))
+	decision.Reset = seconds((burst - tokens) / limit.Rate)
+	return TokenBucket{Tokens: tokens, UpdatedAt: now}, decision
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultWebhookDeliveriesLimit = 50
+	MaxWebhookDeliveriesLimit     = 100
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook subscription")
+
+// WebhookEventTypes are job events that can be delivered to subscribers
+var WebhookEventTypes = []JobEventType{JobEventCompleted, JobEventFailed}
+
+// WebhookSubscription is a downstream endpoint notified about job events
+type WebhookSubscription struct {
+	id         string
+	url        string
+	secret     string
+	eventTypes []JobEventType
+	createdAt  time.Time
+}
+
+type NewWebhookSubscriptionData struct {
+	ID         string
+	Url        string
+	Secret     string
+	EventTypes []JobEventType
+	CreatedAt  time.Time
+}
+
+// NewWebhookSubscription Constructor for WebhookSubscription struct
+func NewWebhookSubscription(sub NewWebhookSubscriptionData) WebhookSubscription {
+	return WebhookSubscription{
+		id:         sub.ID,
+		url:        sub.Url,
+		secret:     sub.Secret,
+		eventTypes: sub.EventTypes,
+		createdAt:  sub.CreatedAt,
+	}
+}
+
+func (s WebhookSubscription) GetID() string {
+	return s.id
+}
+
+func (s WebhookSubscription) GetUrl() string {
+	return s.url
+}
+
+func (s WebhookSubscription) GetSecret() string {
+	return s.secret
+}
+
+func (s WebhookSubscription) GetEventTypes() []JobEventType {
+	return s.eventTypes
+}
+
+func (s WebhookSubscription) GetCreatedAt() time.Time {
+	return s.createdAt
+}
+
+// Accepts reports whether subscription wants events of eventType
+func (s WebhookSubscription) Accepts(eventType JobEventType) bool {
+	for _, t := range s.eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a single delivery attempt recorded in the delivery log
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID string
+	EventType      JobEventType
+	JobID          string
+	Attempt        int
+	StatusCode     int
+	Error          string
+	Succeeded      bool
+	Duration       time.Duration
+	CreatedAt      time.Time
+}
+
+// PendingWebhookDelivery is an event waiting to be delivered to a subscription. It is kept
+// until it was delivered or its retries are exhausted.
+type PendingWebhookDelivery struct {
+	ID            int64
+	Subscription  WebhookSubscription
+	EventType     JobEventType
+	JobID         string
+	Body          []byte
+	Attempts      int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
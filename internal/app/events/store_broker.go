@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+const (
+	// DefaultPollInterval is how often StoreBroker subscribers look for new events
+	DefaultPollInterval = 500 * time.Millisecond
+	// DefaultRetention is how long stored events stay available for Last-Event-ID resumption
+	DefaultRetention = 24 * time.Hour
+
+	pollBatchSize    = 256
+	publishTimeout   = 5 * time.Second
+	subscriberBuffer = 64
+)
+
+// Store persists job events. IDs are assigned by the store, grow monotonically and become
+// visible in order.
+type Store interface {
+	Append(ctx context.Context, event domain.JobEvent) (domain.JobEvent, error)
+	ListAfter(ctx context.Context, afterID int64, filter domain.JobEventFilter, limit int) ([]domain.JobEvent, error)
+	LastID(ctx context.Context) (int64, error)
+}
+
+// StoreBroker shares job events through a Store, so subscribers see the events of every process,
+// e.g. an API streaming the progress of jobs run by separate workers, and event IDs survive restarts.
+type StoreBroker struct {
+	store        Store
+	pollInterval time.Duration
+	logger       zerolog.Logger
+}
+
+func NewStoreBroker(store Store, pollInterval time.Duration, logger zerolog.Logger) *StoreBroker {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &StoreBroker{store: store, pollInterval: pollInterval, logger: logger}
+}
+
+// Publish stores event and returns it with its ID. Events are best effort, a failure is logged
+// and the event is returned without an ID.
+func (b *StoreBroker) Publish(event domain.JobEvent) domain.JobEvent {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	stored, err := b.store.Append(ctx, event)
+	if err != nil {
+		b.logger.Error().Err(err).Str("job_id", event.JobID).Str("type", string(event.Type)).Msg("failed to publish job event")
+		return event
+	}
+	return stored
+}
+
+// Subscribe streams events matching filter with an ID greater than lastEventID, or only new ones
+// without Last-Event-ID. Subscribers that fall behind are not dropped, they catch up from the store.
+// The channel is closed when ctx is done or the current position can't be read.
+func (b *StoreBroker) Subscribe(ctx context.Context, filter domain.JobEventFilter, lastEventID int64) <-chan domain.JobEvent {
+	ch := make(chan domain.JobEvent, subscriberBuffer)
+	afterID := lastEventID
+	if afterID <= 0 {
+		// The position is taken before returning, so events published right after Subscribe are delivered
+		lastID, err := b.store.LastID(ctx)
+		if err != nil {
+			b.logger.Error().Err(err).Msg("failed to subscribe to job events")
+			close(ch)
+			return ch
+		}
+		afterID = lastID
+	}
+	go b.poll(ctx, filter, afterID, ch)
+	return ch
+}
+
+// poll delivers stored events after afterID until ctx is done
+func (b *StoreBroker) poll(ctx context.Context, filter domain.JobEventFilter, afterID int64, ch chan<- domain.JobEvent) {
+	defer close(ch)
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+	for {
+		events, err := b.store.ListAfter(ctx, afterID, filter, pollBatchSize)
+		if err != nil && ctx.Err() == nil {
+			b.logger.Warn().Err(err).Msg("failed to poll job events")
+		}
+		for _, event := range events {
+			select {
+			case ch <- event:
+				afterID = event.ID
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(events) == pollBatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// memoryStore is a Store shared by brokers standing in for separate processes
+type memoryStore struct {
+	mu        sync.Mutex
+	events    []domain.JobEvent
+	appendErr error
+}
+
+func (s *memoryStore) Append(ctx context.Context, event domain.JobEvent) (domain.JobEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.appendErr != nil {
+		return domain.JobEvent{}, s.appendErr
+	}
+	event.ID = int64(len(s.events) + 1)
+	s.events = append(s.events, event)
+	return event, nil
+}
+
+func (s *memoryStore) ListAfter(ctx context.Context, afterID int64, filter domain.JobEventFilter, limit int) ([]domain.JobEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []domain.JobEvent
+	for _, event := range s.events {
+		if event.ID > afterID && filter.Matches(event) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *memoryStore) LastID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.events)), nil
+}
+
+func receiveIDs(t *testing.T, events <-chan domain.JobEvent, count int) []int64 {
+	var ids []int64
+	for range count {
+		select {
+		case event := <-events:
+			ids = append(ids, event.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("received only %v", ids)
+		}
+	}
+	return ids
+}
+
+func TestStoreBroker_Subscribe(t *testing.T) {
+	testCases := []struct {
+		name        string
+		filter      domain.JobEventFilter
+		lastEventID int64
+		expectedIDs []int64
+	}{
+		{
+			name:        "Should replay events after Last-Event-ID and deliver live ones",
+			lastEventID: 1,
+			expectedIDs: []int64{2, 3, 4},
+		},
+		{
+			name:        "Should deliver only events matching filter",
+			filter:      domain.JobEventFilter{Sol: 2},
+			lastEventID: 1,
+			expectedIDs: []int64{2, 4},
+		},
+		{
+			name:        "Should deliver only new events without Last-Event-ID",
+			expectedIDs: []int64{4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			store := &memoryStore{}
+			worker := NewStoreBroker(store, time.Millisecond, zerolog.Nop())
+			api := NewStoreBroker(store, time.Millisecond, zerolog.Nop())
+			worker.Publish(domain.JobEvent{JobID: "a", Sol: 1})
+			worker.Publish(domain.JobEvent{JobID: "b", Sol: 2})
+			worker.Publish(domain.JobEvent{JobID: "c", Sol: 3})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// When
+			events := api.Subscribe(ctx, tc.filter, tc.lastEventID)
+			worker.Publish(domain.JobEvent{JobID: "b", Sol: 2})
+
+			// Then
+			require.Equal(t, tc.expectedIDs, receiveIDs(t, events, len(tc.expectedIDs)))
+		})
+	}
+}
+
+func TestStoreBroker_KeepsLaggingSubscriber(t *testing.T) {
+	// Given
+	store := &memoryStore{}
+	broker := NewStoreBroker(store, time.Millisecond, zerolog.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := broker.Subscribe(ctx, domain.JobEventFilter{}, 0)
+
+	// When
+	total := subscriberBuffer + pollBatchSize + 1
+	for range total {
+		broker.Publish(domain.JobEvent{JobID: "a", Sol: 1})
+	}
+
+	// Then
+	ids := receiveIDs(t, events, total)
+	require.Equal(t, int64(total), ids[len(ids)-1])
+}
+
+func TestStoreBroker_Publish(t *testing.T) {
+	t.Run("Should return event with the ID assigned by store", func(t *testing.T) {
+		// Given
+		broker := NewStoreBroker(&memoryStore{}, 0, zerolog.Nop())
+
+		// When
+		broker.Publish(domain.JobEvent{JobID: "a", Sol: 1})
+		event := broker.Publish(domain.JobEvent{JobID: "b", Sol: 2})
+
+		// Then
+		require.Equal(t, int64(2), event.ID)
+		require.Equal(t, "b", event.JobID)
+	})
+
+	t.Run("Should return event without ID when store fails", func(t *testing.T) {
+		// Given
+		broker := NewStoreBroker(&memoryStore{appendErr: errors.New("connection refused")}, 0, zerolog.Nop())
+
+		// When
+		event := broker.Publish(domain.JobEvent{JobID: "a", Sol: 1})
+
+		// Then
+		require.Zero(t, event.ID)
+		require.Equal(t, "a", event.JobID)
+	})
+}
@@ -0,0 +1,9 @@
+-- Token buckets shared by every API instance, idle buckets are pruned once they are full again
+CREATE TABLE rate_limit_buckets
+(
+    key        VARCHAR(256)     NOT NULL PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
@@ -0,0 +1,2 @@
+DROP INDEX jobs_active_sol_idx;
+DROP TABLE idempotency_keys;
@@ -0,0 +1,15 @@
+-- Responses of requests sent with an Idempotency-Key, status_code stays 0 while the request runs
+CREATE TABLE idempotency_keys
+(
+    key          VARCHAR(320) NOT NULL PRIMARY KEY,
+    request_hash CHAR(64)     NOT NULL,
+    status_code  INTEGER      NOT NULL DEFAULT 0,
+    body         BYTEA,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- New commands look up the unfinished job of their sol to coalesce into it
+CREATE INDEX jobs_active_sol_idx ON jobs (sol) WHERE status IN ('queued', 'running');
@@ -0,0 +1,2 @@
+ALTER TABLE command_queue DROP COLUMN headers;
+ALTER TABLE outbox DROP COLUMN trace_context;
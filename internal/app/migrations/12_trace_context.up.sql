@@ -0,0 +1,3 @@
+-- W3C trace context of the request that accepted a command, carried to the worker consuming it
+ALTER TABLE outbox ADD COLUMN trace_context JSONB;
+ALTER TABLE command_queue ADD COLUMN headers JSONB;
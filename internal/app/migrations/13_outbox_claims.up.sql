@@ -0,0 +1,2 @@
+-- Relays claim a batch for a while and publish it outside of any transaction
+ALTER TABLE outbox ADD COLUMN locked_until TIMESTAMPTZ;
@@ -0,0 +1,16 @@
+-- Job events are shared by every process, so streams see events of workers in other processes
+-- and keep their IDs across restarts for Last-Event-ID
+CREATE TABLE job_events
+(
+    id           BIGSERIAL   NOT NULL PRIMARY KEY,
+    type         VARCHAR(16) NOT NULL,
+    job_id       VARCHAR(32) NOT NULL,
+    sol          INTEGER     NOT NULL,
+    photos_sized INTEGER     NOT NULL DEFAULT 0,
+    photos_total INTEGER     NOT NULL DEFAULT 0,
+    picture      JSONB,
+    slug         TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX job_events_created_at_idx ON job_events (created_at);
@@ -0,0 +1 @@
+DROP TABLE pending_webhook_deliveries;
@@ -0,0 +1,16 @@
+-- Deliveries wait here until they succeed or give up, so they survive restarts and retries
+-- are scheduled instead of slept through
+CREATE TABLE pending_webhook_deliveries
+(
+    id              BIGSERIAL   NOT NULL PRIMARY KEY,
+    subscription_id VARCHAR(32) NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_type      VARCHAR(16) NOT NULL,
+    job_id          VARCHAR(32) NOT NULL DEFAULT '',
+    body            TEXT        NOT NULL,
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX pending_webhook_deliveries_due_idx ON pending_webhook_deliveries (next_attempt_at);
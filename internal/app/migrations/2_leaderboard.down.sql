@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS pictures_size_sol_idx;
+
+ALTER TABLE pictures
+    DROP COLUMN earth_date,
+    DROP COLUMN camera,
+    DROP COLUMN rover;
@@ -0,0 +1,7 @@
+ALTER TABLE pictures
+    ADD COLUMN rover      VARCHAR(64) NOT NULL DEFAULT 'curiosity',
+    ADD COLUMN camera     VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN earth_date DATE;
+
+-- Leaderboard is ordered by size with sol as deterministic tie breaker
+CREATE INDEX pictures_size_sol_idx ON pictures (size DESC, sol ASC);
@@ -0,0 +1,13 @@
+CREATE TABLE jobs
+(
+    id           VARCHAR(32) NOT NULL PRIMARY KEY,
+    sol          INTEGER     NOT NULL,
+    status       VARCHAR(16) NOT NULL,
+    photos_sized INTEGER     NOT NULL DEFAULT 0,
+    photos_total INTEGER     NOT NULL DEFAULT 0,
+    error_slug   VARCHAR(64) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX jobs_sol_idx ON jobs (sol);
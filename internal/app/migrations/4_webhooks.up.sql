@@ -0,0 +1,24 @@
+CREATE TABLE webhook_subscriptions
+(
+    id          VARCHAR(32)   NOT NULL PRIMARY KEY,
+    url         VARCHAR(2048) NOT NULL,
+    secret      VARCHAR(256)  NOT NULL,
+    event_types TEXT[]        NOT NULL,
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id              BIGSERIAL   NOT NULL PRIMARY KEY,
+    subscription_id VARCHAR(32) NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_type      VARCHAR(16) NOT NULL,
+    job_id          VARCHAR(32) NOT NULL DEFAULT '',
+    attempt         INTEGER     NOT NULL,
+    status_code     INTEGER     NOT NULL DEFAULT 0,
+    error           TEXT        NOT NULL DEFAULT '',
+    succeeded       BOOLEAN     NOT NULL,
+    duration_ms     INTEGER     NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id DESC);
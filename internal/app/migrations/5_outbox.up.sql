@@ -0,0 +1,13 @@
+CREATE TABLE outbox
+(
+    id         BIGSERIAL   NOT NULL PRIMARY KEY,
+    job_id     VARCHAR(32) NOT NULL,
+    payload    JSONB       NOT NULL,
+    attempts   INTEGER     NOT NULL DEFAULT 0,
+    last_error TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at    TIMESTAMPTZ
+);
+
+-- Relay only ever scans rows that are still waiting to be published
+CREATE INDEX outbox_pending_idx ON outbox (id) WHERE sent_at IS NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE command_queue
+(
+    id           BIGSERIAL   NOT NULL PRIMARY KEY,
+    job_id       VARCHAR(32) NOT NULL DEFAULT '',
+    payload      JSONB       NOT NULL,
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+DROP TABLE nasa_cache;
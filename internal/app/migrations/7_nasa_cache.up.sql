@@ -0,0 +1,9 @@
+CREATE TABLE nasa_cache
+(
+    key           TEXT        NOT NULL PRIMARY KEY,
+    body          BYTEA       NOT NULL,
+    etag          TEXT        NOT NULL DEFAULT '',
+    last_modified TEXT        NOT NULL DEFAULT '',
+    expires_at    TIMESTAMPTZ NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
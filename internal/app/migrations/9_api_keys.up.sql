@@ -0,0 +1,23 @@
+CREATE TABLE api_keys
+(
+    id            VARCHAR(32)  NOT NULL PRIMARY KEY,
+    name          VARCHAR(128) NOT NULL,
+    -- hex encoded SHA-256 of the secret, the secret itself is never stored
+    key_hash      CHAR(64)     NOT NULL UNIQUE,
+    key_prefix    VARCHAR(16)  NOT NULL,
+    scopes        TEXT[]       NOT NULL,
+    request_quota INTEGER      NOT NULL DEFAULT 0,
+    enqueue_quota INTEGER      NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    revoked_at    TIMESTAMPTZ
+);
+
+-- Fixed window counters of per key quotas, only the current window of each quota is kept
+CREATE TABLE api_key_usage
+(
+    key_id       VARCHAR(32) NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    kind         VARCHAR(16) NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    count        INTEGER     NOT NULL,
+    PRIMARY KEY (key_id, kind, window_start)
+);
@@ -0,0 +1,4 @@
+DROP INDEX pictures_size_sol_idx;
+ALTER TABLE pictures DROP COLUMN earth_date;
+ALTER TABLE pictures DROP COLUMN camera;
+ALTER TABLE pictures DROP COLUMN rover;
@@ -0,0 +1,6 @@
+ALTER TABLE pictures ADD COLUMN rover VARCHAR(64) NOT NULL DEFAULT 'curiosity';
+ALTER TABLE pictures ADD COLUMN camera VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE pictures ADD COLUMN earth_date TIMESTAMP;
+
+-- Leaderboard is ordered by size with sol as deterministic tie breaker
+CREATE INDEX pictures_size_sol_idx ON pictures (size DESC, sol ASC);
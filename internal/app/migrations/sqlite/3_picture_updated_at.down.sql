@@ -0,0 +1 @@
+ALTER TABLE pictures DROP COLUMN updated_at;
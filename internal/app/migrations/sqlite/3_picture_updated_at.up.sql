@@ -0,0 +1,2 @@
+-- Last-Modified of GET results comes from the time the picture was saved
+ALTER TABLE pictures ADD COLUMN updated_at TIMESTAMP;
@@ -0,0 +1,8 @@
+// Package sqlite holds the SQLite equivalents of the Postgres migrations for the tables
+// that have a SQLite repository. They are embedded so a SQLite database needs no files on disk.
+package sqlite
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
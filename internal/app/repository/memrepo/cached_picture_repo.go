@@ -0,0 +1,68 @@
+package memrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services"
+)
+
+// DefaultPictureCacheSize is how many sols CachedPictureRepo keeps by default
+const DefaultPictureCacheSize = 1000
+
+// DefaultPictureCacheTTL bounds how stale a picture saved by another process can be
+const DefaultPictureCacheTTL = time.Minute
+
+// CachedPictureRepo is a read-through cache of pictures found by sol in front of another repository.
+// Saving a picture through it invalidates the sol, pictures saved by other processes are picked up after ttl.
+type CachedPictureRepo struct {
+	repo     services.PictureRepository
+	pictures *expirable.LRU[int, domain.Picture]
+}
+
+func NewCachedPictureRepo(repo services.PictureRepository, size int, ttl time.Duration) *CachedPictureRepo {
+	return &CachedPictureRepo{
+		repo:     repo,
+		pictures: expirable.NewLRU[int, domain.Picture](size, nil, ttl),
+	}
+}
+
+// FindLargestPictureBySol returns the cached picture of sol or reads it from the underlying repository.
+// Missing sols are not cached, they are usually being computed right now.
+func (r *CachedPictureRepo) FindLargestPictureBySol(ctx context.Context, sol int) (domain.Picture, error) {
+	if picture, ok := r.pictures.Get(sol); ok {
+		return picture, nil
+	}
+	picture, err := r.repo.FindLargestPictureBySol(ctx, sol)
+	if err != nil {
+		return domain.Picture{}, err
+	}
+	r.pictures.Add(sol, picture)
+	return picture, nil
+}
+
+// Save saves the picture in the underlying repository and drops the cached one
+func (r *CachedPictureRepo) Save(ctx context.Context, picture domain.Picture) error {
+	defer r.pictures.Remove(picture.GetSol())
+	return r.repo.Save(ctx, picture)
+}
+
+// FindLargestPictures is not cached, every page is read from the underlying repository
+func (r *CachedPictureRepo) FindLargestPictures(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error) {
+	return r.repo.FindLargestPictures(ctx, filter)
+}
+
+// CountByCamera is not cached, counts are read from the underlying repository
+func (r *CachedPictureRepo) CountByCamera(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.CameraCount, error) {
+	return r.repo.CountByCamera(ctx, filter)
+}
+
+// Exists answers from the cache when sol is cached
+func (r *CachedPictureRepo) Exists(ctx context.Context, sol int) (bool, error) {
+	if r.pictures.Contains(sol) {
+		return true, nil
+	}
+	return r.repo.Exists(ctx, sol)
+}
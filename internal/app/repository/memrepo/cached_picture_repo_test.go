@@ -0,0 +1,94 @@
+package memrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/repotest"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services/mocks"
+)
+
+func TestCachedPictureRepo(t *testing.T) {
+	repotest.TestPictureRepository(t, func(t *testing.T) repotest.PictureRepository {
+		return NewCachedPictureRepo(NewPictureRepo(), DefaultPictureCacheSize, DefaultPictureCacheTTL)
+	})
+}
+
+func TestCachedPictureRepo_FindLargestPictureBySol(t *testing.T) {
+	ctx := context.Background()
+	picture := domain.NewPicture(domain.NewPictureData{Sol: 1000, Size: 2048, Url: "http://example.com/1.jpg"})
+
+	t.Run("Should read picture from underlying repository once", func(t *testing.T) {
+		// Given
+		repoMock := mocks.NewPictureRepository(t)
+		repoMock.On("FindLargestPictureBySol", mock.Anything, 1000).Return(picture, nil).Once()
+		repo := NewCachedPictureRepo(repoMock, DefaultPictureCacheSize, DefaultPictureCacheTTL)
+
+		// When
+		first, err := repo.FindLargestPictureBySol(ctx, 1000)
+		require.NoError(t, err)
+		second, err := repo.FindLargestPictureBySol(ctx, 1000)
+		require.NoError(t, err)
+
+		// Then
+		require.Equal(t, picture, first)
+		require.Equal(t, picture, second)
+	})
+
+	t.Run("Should read picture again after it is saved", func(t *testing.T) {
+		// Given
+		replacement := domain.NewPicture(domain.NewPictureData{Sol: 1000, Size: 4096, Url: "http://example.com/2.jpg"})
+		repoMock := mocks.NewPictureRepository(t)
+		repoMock.On("FindLargestPictureBySol", mock.Anything, 1000).Return(picture, nil).Once()
+		repoMock.On("Save", mock.Anything, replacement).Return(nil).Once()
+		repoMock.On("FindLargestPictureBySol", mock.Anything, 1000).Return(replacement, nil).Once()
+		repo := NewCachedPictureRepo(repoMock, DefaultPictureCacheSize, DefaultPictureCacheTTL)
+		_, err := repo.FindLargestPictureBySol(ctx, 1000)
+		require.NoError(t, err)
+
+		// When
+		require.NoError(t, repo.Save(ctx, replacement))
+		found, err := repo.FindLargestPictureBySol(ctx, 1000)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, replacement, found)
+	})
+
+	t.Run("Should not cache missing sol", func(t *testing.T) {
+		// Given
+		repoMock := mocks.NewPictureRepository(t)
+		repoMock.On("FindLargestPictureBySol", mock.Anything, 1000).Return(domain.Picture{}, domain.ErrNotFound).Once()
+		repoMock.On("FindLargestPictureBySol", mock.Anything, 1000).Return(picture, nil).Once()
+		repo := NewCachedPictureRepo(repoMock, DefaultPictureCacheSize, DefaultPictureCacheTTL)
+
+		// When
+		_, err := repo.FindLargestPictureBySol(ctx, 1000)
+		found, foundErr := repo.FindLargestPictureBySol(ctx, 1000)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrNotFound)
+		require.NoError(t, foundErr)
+		require.Equal(t, picture, found)
+	})
+
+	t.Run("Should read picture again after ttl", func(t *testing.T) {
+		// Given
+		repoMock := mocks.NewPictureRepository(t)
+		repoMock.On("FindLargestPictureBySol", mock.Anything, 1000).Return(picture, nil).Twice()
+		repo := NewCachedPictureRepo(repoMock, DefaultPictureCacheSize, 10*time.Millisecond)
+		_, err := repo.FindLargestPictureBySol(ctx, 1000)
+		require.NoError(t, err)
+
+		// When
+		time.Sleep(50 * time.Millisecond)
+		_, err = repo.FindLargestPictureBySol(ctx, 1000)
+
+		// Then
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,39 @@
+package memrepo
+
+import (
+	"context"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// DefaultNasaCacheSize is how many NASA API responses NasaCacheRepo keeps by default
+const DefaultNasaCacheSize = 10000
+
+// NasaCacheRepo keeps the most recently used NASA API responses in memory
+type NasaCacheRepo struct {
+	responses *lru.Cache[string, domain.CachedResponse]
+}
+
+func NewNasaCacheRepo(size int) (*NasaCacheRepo, error) {
+	responses, err := lru.New[string, domain.CachedResponse](size)
+	if err != nil {
+		return nil, err
+	}
+	return &NasaCacheRepo{responses: responses}, nil
+}
+
+// Get returns the cached response for key, expired ones included
+func (r *NasaCacheRepo) Get(ctx context.Context, key string) (domain.CachedResponse, error) {
+	response, ok := r.responses.Get(key)
+	if !ok {
+		return domain.CachedResponse{}, domain.ErrNotFound
+	}
+	return response, nil
+}
+
+// Set inserts or replaces the cached response for key, evicting the least recently used one when full
+func (r *NasaCacheRepo) Set(ctx context.Context, key string, response domain.CachedResponse) error {
+	r.responses.Add(key, response)
+	return nil
+}
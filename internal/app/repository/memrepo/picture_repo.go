@@ -0,0 +1,113 @@
+package memrepo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// PictureRepo keeps pictures in memory, it is meant for tests and local demos
+type PictureRepo struct {
+	mu       sync.RWMutex
+	pictures map[int]domain.Picture
+}
+
+func NewPictureRepo() *PictureRepo {
+	return &PictureRepo{pictures: make(map[int]domain.Picture)}
+}
+
+// FindLargestPictureBySol retrieves the largest picture (based on size) for the given sol
+func (r *PictureRepo) FindLargestPictureBySol(ctx context.Context, sol int) (domain.Picture, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	picture, ok := r.pictures[sol]
+	if !ok {
+		return domain.Picture{}, domain.ErrNotFound
+	}
+	return picture, nil
+}
+
+// Save inserts or replaces the picture of its sol
+func (r *PictureRepo) Save(ctx context.Context, picture domain.Picture) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	picture.Touch(time.Now().UTC())
+	r.pictures[picture.GetSol()] = picture
+	return nil
+}
+
+// FindLargestPictures returns the largest pictures across all computed sols, ties are broken by sol
+func (r *PictureRepo) FindLargestPictures(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]domain.Picture, 0, len(r.pictures))
+	for _, picture := range r.pictures {
+		if matchesLeaderboardFilter(picture, filter) {
+			result = append(result, picture)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].GetSize() != result[j].GetSize() {
+			return result[i].GetSize() > result[j].GetSize()
+		}
+		return result[i].GetSol() < result[j].GetSol()
+	})
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nil
+}
+
+// CountByCamera counts saved sols per camera of their largest picture, the filter limit is ignored
+func (r *PictureRepo) CountByCamera(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.CameraCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pictures := make(map[string]int)
+	for _, picture := range r.pictures {
+		if matchesLeaderboardFilter(picture, filter) {
+			pictures[picture.GetCamera()]++
+		}
+	}
+	result := make([]domain.CameraCount, 0, len(pictures))
+	for camera, count := range pictures {
+		result = append(result, domain.CameraCount{Camera: camera, Pictures: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pictures != result[j].Pictures {
+			return result[i].Pictures > result[j].Pictures
+		}
+		return result[i].Camera < result[j].Camera
+	})
+	return result, nil
+}
+
+// Exists checks if a picture was saved for the given sol
+func (r *PictureRepo) Exists(ctx context.Context, sol int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.pictures[sol]
+	return ok, nil
+}
+
+// matchesLeaderboardFilter mirrors the SQL filters: a picture without earth date never matches a date range
+func matchesLeaderboardFilter(picture domain.Picture, filter domain.LeaderboardFilter) bool {
+	if filter.Rover != "" && picture.GetRover() != filter.Rover {
+		return false
+	}
+	if filter.Camera != "" && picture.GetCamera() != filter.Camera {
+		return false
+	}
+	earthDate := picture.GetEarthDate()
+	if !filter.From.IsZero() && (earthDate.IsZero() || earthDate.Before(filter.From)) {
+		return false
+	}
+	if !filter.To.IsZero() && (earthDate.IsZero() || earthDate.After(filter.To)) {
+		return false
+	}
+	return true
+}
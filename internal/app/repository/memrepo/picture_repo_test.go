@@ -0,0 +1,13 @@
+package memrepo
+
+import (
+	"testing"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/repotest"
+)
+
+func TestPictureRepo(t *testing.T) {
+	repotest.TestPictureRepository(t, func(t *testing.T) repotest.PictureRepository {
+		return NewPictureRepo()
+	})
+}
@@ -0,0 +1,38 @@
+package memrepo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// DefaultRateLimitStoreSize is how many clients RateLimitStore tracks by default
+const DefaultRateLimitStoreSize = 100000
+
+// RateLimitStore keeps token buckets of the most recently seen clients in memory, limits are per instance.
+// Evicting an idle client is harmless because its bucket has most likely refilled.
+type RateLimitStore struct {
+	mu      sync.Mutex
+	buckets *lru.Cache[string, domain.TokenBucket]
+}
+
+func NewRateLimitStore(size int) (*RateLimitStore, error) {
+	buckets, err := lru.New[string, domain.TokenBucket](size)
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimitStore{buckets: buckets}, nil
+}
+
+// Take takes a token from the bucket of key
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, _ := s.buckets.Get(key)
+	bucket, decision := bucket.Take(limit, now)
+	s.buckets.Add(key, bucket)
+	return decision, nil
+}
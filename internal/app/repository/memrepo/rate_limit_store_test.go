@@ -0,0 +1,16 @@
+package memrepo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/repotest"
+)
+
+func TestRateLimitStore(t *testing.T) {
+	repotest.TestRateLimitStore(t, func(t *testing.T) repotest.RateLimitStore {
+		store, err := NewRateLimitStore(DefaultRateLimitStoreSize)
+		require.NoError(t, err)
+		return store
+	})
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type APIKey struct {
+	bun.BaseModel `bun:"table:api_keys"`
+
+	ID           string    `bun:",pk"`
+	Name         string    `bun:"name,notnull"`
+	KeyHash      string    `bun:"key_hash,notnull"`
+	KeyPrefix    string    `bun:"key_prefix,notnull"`
+	Scopes       []string  `bun:"scopes,array,notnull"`
+	RequestQuota int       `bun:"request_quota,notnull"`
+	EnqueueQuota int       `bun:"enqueue_quota,notnull"`
+	CreatedAt    time.Time `bun:"created_at,notnull"`
+	RevokedAt    time.Time `bun:"revoked_at,nullzero"`
+}
+
+type APIKeyUsage struct {
+	bun.BaseModel `bun:"table:api_key_usage"`
+
+	KeyID       string    `bun:"key_id,pk"`
+	Kind        string    `bun:"kind,pk"`
+	WindowStart time.Time `bun:"window_start,pk"`
+	Count       int       `bun:"count,notnull"`
+}
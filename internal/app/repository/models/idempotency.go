@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type IdempotencyKey struct {
+	bun.BaseModel `bun:"table:idempotency_keys"`
+
+	Key         string    `bun:",pk"`
+	RequestHash string    `bun:"request_hash,notnull"`
+	StatusCode  int       `bun:"status_code,notnull"`
+	Body        []byte    `bun:"body"`
+	CreatedAt   time.Time `bun:"created_at,notnull"`
+	ExpiresAt   time.Time `bun:"expires_at,notnull"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Job struct {
+	ID          string    `bun:",pk"`
+	Sol         int       `bun:"sol,notnull"`
+	Status      string    `bun:"status,notnull"`
+	PhotosSized int       `bun:"photos_sized,notnull"`
+	PhotosTotal int       `bun:"photos_total,notnull"`
+	ErrorSlug   string    `bun:"error_slug,notnull"`
+	CreatedAt   time.Time `bun:"created_at,notnull"`
+	UpdatedAt   time.Time `bun:"updated_at,notnull"`
+}
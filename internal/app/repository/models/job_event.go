@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type JobEvent struct {
+	bun.BaseModel `bun:"table:job_events"`
+
+	ID          int64     `bun:",pk,autoincrement"`
+	Type        string    `bun:"type,notnull"`
+	JobID       string    `bun:"job_id,notnull"`
+	Sol         int       `bun:"sol,notnull"`
+	PhotosSized int       `bun:"photos_sized,notnull"`
+	PhotosTotal int       `bun:"photos_total,notnull"`
+	Picture     *Picture  `bun:"picture,type:jsonb"`
+	Slug        string    `bun:"slug,notnull"`
+	CreatedAt   time.Time `bun:"created_at,notnull"`
+}
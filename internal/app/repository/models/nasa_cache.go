@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type NasaCacheEntry struct {
+	bun.BaseModel `bun:"table:nasa_cache"`
+
+	Key          string    `bun:",pk"`
+	Body         []byte    `bun:"body,notnull"`
+	ETag         string    `bun:"etag,notnull"`
+	LastModified string    `bun:"last_modified,notnull"`
+	ExpiresAt    time.Time `bun:"expires_at,notnull"`
+	UpdatedAt    time.Time `bun:"updated_at,notnull"`
+}
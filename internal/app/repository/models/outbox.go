@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+type OutboxMessage struct {
+	bun.BaseModel `bun:"table:outbox"`
+
+	ID        int64             `bun:",pk,autoincrement"`
+	JobID     string            `bun:"job_id,notnull"`
+	Payload   domain.SolCommand `bun:"payload,type:jsonb,notnull"`
+	Attempts  int               `bun:"attempts,notnull"`
+	LastError string            `bun:"last_error,notnull"`
+	CreatedAt time.Time         `bun:"created_at,notnull"`
+	SentAt    time.Time         `bun:"sent_at,nullzero"`
+	// LockedUntil hides a message claimed by a relay from the others
+	LockedUntil time.Time `bun:"locked_until,nullzero"`
+
+	TraceContext map[string]string `bun:"trace_context,type:jsonb"`
+}
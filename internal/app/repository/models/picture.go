@@ -1,8 +1,14 @@
 package models
 
+import "time"
+
 // Picture is domain
 type Picture struct {
-	Sol    int    `bun:",pk"`
-	ImgSrc string `bun:"img_src,notnull"`
-	Size   int    `bun:"size,notnull"`
+	Sol       int       `bun:",pk"`
+	ImgSrc    string    `bun:"img_src,notnull"`
+	Size      int       `bun:"size,notnull"`
+	Rover     string    `bun:"rover,notnull"`
+	Camera    string    `bun:"camera,notnull"`
+	EarthDate time.Time `bun:"earth_date,nullzero"`
+	UpdatedAt time.Time `bun:"updated_at,nullzero"`
 }
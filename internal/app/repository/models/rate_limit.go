@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type RateLimitBucket struct {
+	bun.BaseModel `bun:"table:rate_limit_buckets"`
+
+	Key       string    `bun:",pk"`
+	Tokens    float64   `bun:"tokens,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull"`
+}
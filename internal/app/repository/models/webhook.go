@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type WebhookSubscription struct {
+	ID         string    `bun:",pk"`
+	Url        string    `bun:"url,notnull"`
+	Secret     string    `bun:"secret,notnull"`
+	EventTypes []string  `bun:"event_types,array,notnull"`
+	CreatedAt  time.Time `bun:"created_at,notnull"`
+}
+
+type WebhookDelivery struct {
+	ID             int64     `bun:",pk,autoincrement"`
+	SubscriptionID string    `bun:"subscription_id,notnull"`
+	EventType      string    `bun:"event_type,notnull"`
+	JobID          string    `bun:"job_id,notnull"`
+	Attempt        int       `bun:"attempt,notnull"`
+	StatusCode     int       `bun:"status_code,notnull"`
+	Error          string    `bun:"error,notnull"`
+	Succeeded      bool      `bun:"succeeded,notnull"`
+	DurationMs     int64     `bun:"duration_ms,notnull"`
+	CreatedAt      time.Time `bun:"created_at,notnull"`
+}
+
+type PendingWebhookDelivery struct {
+	ID             int64     `bun:",pk,autoincrement"`
+	SubscriptionID string    `bun:"subscription_id,notnull"`
+	EventType      string    `bun:"event_type,notnull"`
+	JobID          string    `bun:"job_id,notnull"`
+	Body           string    `bun:"body,notnull"`
+	Attempts       int       `bun:"attempts,notnull"`
+	NextAttemptAt  time.Time `bun:"next_attempt_at,notnull"`
+	// LockedUntil hides a delivery claimed by a worker from the others
+	LockedUntil time.Time `bun:"locked_until,nullzero"`
+	CreatedAt   time.Time `bun:"created_at,notnull"`
+}
@@ -0,0 +1,104 @@
+package pgrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// APIKeyRepo stores hashed API keys and counts their quota usage, so every API instance sees the same counters
+type APIKeyRepo struct {
+	db *pkg.DB
+}
+
+func NewAPIKeyRepo(db *pkg.DB) APIKeyRepo {
+	return APIKeyRepo{db: db}
+}
+
+// CreateAPIKey inserts a new API key
+func (r *APIKeyRepo) CreateAPIKey(ctx context.Context, key domain.APIKey) error {
+	modelKey := domainToAPIKey(key)
+	_, err := r.db.NewInsert().Model(&modelKey).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not create api key: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys returns all API keys including revoked ones, oldest first
+func (r *APIKeyRepo) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	var keys []models.APIKey
+
+	err := r.db.NewSelect().Model(&keys).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list api keys: %w", err)
+	}
+
+	result := make([]domain.APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, toDomainAPIKey(key))
+	}
+	return result, nil
+}
+
+// FindAPIKeyByHash returns the key whose secret hashes to hash
+func (r *APIKeyRepo) FindAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	var key models.APIKey
+	err := r.db.NewSelect().Model(&key).Where("key_hash = ?", hash).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("could not find api key: %w", err)
+	}
+	return toDomainAPIKey(key), nil
+}
+
+// RevokeAPIKey marks key as revoked, revoking a revoked key keeps the original time
+func (r *APIKeyRepo) RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) error {
+	res, err := r.db.NewUpdate().
+		Model((*models.APIKey)(nil)).
+		Set("revoked_at = COALESCE(revoked_at, ?)", revokedAt).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not revoke api key: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// IncrementUsage counts one use of quota kind in the window starting at windowStart and returns the new count.
+// The first use of a window drops the counters of previous windows.
+func (r *APIKeyRepo) IncrementUsage(ctx context.Context, keyID string, kind domain.QuotaKind, windowStart time.Time) (int, error) {
+	usage := models.APIKeyUsage{KeyID: keyID, Kind: string(kind), WindowStart: windowStart, Count: 1}
+	err := r.db.NewInsert().
+		Model(&usage).
+		On("CONFLICT (key_id, kind, window_start) DO UPDATE").
+		Set("count = api_key_usage.count + 1").
+		Returning("count").
+		Scan(ctx, &usage.Count)
+	if err != nil {
+		return 0, fmt.Errorf("could not count api key usage: %w", err)
+	}
+	if usage.Count == 1 {
+		_, err = r.db.NewDelete().
+			Model((*models.APIKeyUsage)(nil)).
+			Where("key_id = ? AND kind = ? AND window_start < ?", keyID, string(kind), windowStart).
+			Exec(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("could not drop old api key usage: %w", err)
+		}
+	}
+	return usage.Count, nil
+}
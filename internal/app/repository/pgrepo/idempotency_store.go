@@ -0,0 +1,90 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// IdempotencyStore keeps Idempotency-Key records in Postgres, so a repeat is replayed by any API instance
+type IdempotencyStore struct {
+	db *pkg.DB
+}
+
+func NewIdempotencyStore(db *pkg.DB) IdempotencyStore {
+	return IdempotencyStore{db: db}
+}
+
+// Reserve claims the key of record for a new request. An expired record of the key is replaced, and so is
+// one still in progress that was reserved before staleBefore, its request crashed without releasing it.
+// When the key is held by another record that record is returned and reserved is false.
+func (s *IdempotencyStore) Reserve(ctx context.Context, record domain.IdempotencyRecord, staleBefore time.Time) (domain.IdempotencyRecord, bool, error) {
+	model := domainToIdempotencyKey(record)
+	res, err := s.db.NewInsert().
+		Model(&model).
+		On("CONFLICT (key) DO UPDATE").
+		Set("request_hash = EXCLUDED.request_hash").
+		Set("status_code = 0").
+		Set("body = NULL").
+		Set("created_at = EXCLUDED.created_at").
+		Set("expires_at = EXCLUDED.expires_at").
+		Where("idempotency_key.expires_at <= EXCLUDED.created_at OR (idempotency_key.status_code = 0 AND idempotency_key.created_at <= ?)", staleBefore).
+		Exec(ctx)
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("could not reserve idempotency key: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected > 0 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	err = s.db.NewSelect().Model(&existing).Where("key = ?", record.GetKey()).Scan(ctx)
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("could not find idempotency key: %w", err)
+	}
+	return toDomainIdempotencyRecord(existing), false, nil
+}
+
+// Complete stores the response of the request holding key
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	_, err := s.db.NewUpdate().
+		Model((*models.IdempotencyKey)(nil)).
+		Set("status_code = ?", statusCode).
+		Set("body = ?", body).
+		Where("key = ?", key).
+		Where("status_code = 0").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release frees key of a request that did not complete, so the client can retry it
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.NewDelete().
+		Model((*models.IdempotencyKey)(nil)).
+		Where("key = ?", key).
+		Where("status_code = 0").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PruneExpired deletes records that expired before before
+func (s *IdempotencyStore) PruneExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.NewDelete().
+		Model((*models.IdempotencyKey)(nil)).
+		Where("expires_at <= ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not prune idempotency keys: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,125 @@
+package pgrepo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// TestIdempotencyStore runs against a migrated Postgres database from TEST_DSN, the table is truncated before each test
+func TestIdempotencyStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+	db, err := pkg.Dial(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	staleBefore := now.Add(-time.Minute)
+	record := func(hash string, createdAt time.Time) domain.IdempotencyRecord {
+		return domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+			Key:         "key-1",
+			RequestHash: hash,
+			CreatedAt:   createdAt,
+			ExpiresAt:   createdAt.Add(time.Hour),
+		})
+	}
+
+	testCases := []struct {
+		name string
+		run  func(t *testing.T, store *IdempotencyStore)
+	}{
+		{
+			name: "Should return stored response for a completed key",
+			run: func(t *testing.T, store *IdempotencyStore) {
+				_, reserved, err := store.Reserve(context.Background(), record("hash-1", now), staleBefore)
+				require.NoError(t, err)
+				require.True(t, reserved)
+				require.NoError(t, store.Complete(context.Background(), "key-1", 200, []byte(`{"sol":1}`)))
+
+				existing, reserved, err := store.Reserve(context.Background(), record("hash-2", now), staleBefore)
+
+				require.NoError(t, err)
+				require.False(t, reserved)
+				require.Equal(t, "hash-1", existing.GetRequestHash())
+				require.Equal(t, 200, existing.GetStatusCode())
+				require.JSONEq(t, `{"sol":1}`, string(existing.GetBody()))
+			},
+		},
+		{
+			name: "Should reserve a released key again",
+			run: func(t *testing.T, store *IdempotencyStore) {
+				_, _, err := store.Reserve(context.Background(), record("hash-1", now), staleBefore)
+				require.NoError(t, err)
+				require.NoError(t, store.Release(context.Background(), "key-1"))
+
+				_, reserved, err := store.Reserve(context.Background(), record("hash-1", now), staleBefore)
+
+				require.NoError(t, err)
+				require.True(t, reserved)
+			},
+		},
+		{
+			name: "Should reclaim a key whose request never finished",
+			run: func(t *testing.T, store *IdempotencyStore) {
+				_, _, err := store.Reserve(context.Background(), record("hash-1", now.Add(-2*time.Minute)), staleBefore)
+				require.NoError(t, err)
+
+				_, reserved, err := store.Reserve(context.Background(), record("hash-1", now), staleBefore)
+
+				require.NoError(t, err)
+				require.True(t, reserved)
+			},
+		},
+		{
+			name: "Should keep a completed key past the lease",
+			run: func(t *testing.T, store *IdempotencyStore) {
+				_, _, err := store.Reserve(context.Background(), record("hash-1", now.Add(-2*time.Minute)), staleBefore)
+				require.NoError(t, err)
+				require.NoError(t, store.Complete(context.Background(), "key-1", 200, []byte(`{"sol":1}`)))
+
+				existing, reserved, err := store.Reserve(context.Background(), record("hash-1", now), staleBefore)
+
+				require.NoError(t, err)
+				require.False(t, reserved)
+				require.Equal(t, 200, existing.GetStatusCode())
+			},
+		},
+		{
+			name: "Should replace an expired key and prune it",
+			run: func(t *testing.T, store *IdempotencyStore) {
+				_, _, err := store.Reserve(context.Background(), record("hash-1", now.Add(-2*time.Hour)), staleBefore)
+				require.NoError(t, err)
+
+				_, reserved, err := store.Reserve(context.Background(), record("hash-2", now), staleBefore)
+				require.NoError(t, err)
+				require.True(t, reserved)
+
+				pruned, err := store.PruneExpired(context.Background(), now.Add(2*time.Hour))
+				require.NoError(t, err)
+				require.Equal(t, int64(1), pruned)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			_, err := db.NewTruncateTable().Table("idempotency_keys").Exec(context.Background())
+			require.NoError(t, err)
+			store := NewIdempotencyStore(db)
+
+			// When / Then
+			tc.run(t, &store)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+type JobEventRepo struct {
+	db *pkg.DB
+}
+
+func NewJobEventRepo(db *pkg.DB) JobEventRepo {
+	return JobEventRepo{db: db}
+}
+
+// jobEventLockNamespace keeps the advisory lock serializing event appends apart from any other advisory lock
+const jobEventLockNamespace = 1002
+
+// Append stores event and returns it with its ID. Appends are serialized, so IDs become visible
+// in the order they were assigned and readers listing after the last ID they have seen never skip
+// an event committed late with a smaller ID.
+func (r *JobEventRepo) Append(ctx context.Context, event domain.JobEvent) (domain.JobEvent, error) {
+	modelEvent := domainToJobEvent(event)
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?, 0)", jobEventLockNamespace); err != nil {
+			return fmt.Errorf("could not lock job events: %w", err)
+		}
+		_, err := tx.NewInsert().Model(&modelEvent).Returning("id").Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return domain.JobEvent{}, fmt.Errorf("could not append job event: %w", err)
+	}
+	event.ID = modelEvent.ID
+	return event, nil
+}
+
+// ListAfter returns up to limit events matching filter with an ID greater than afterID, oldest first
+func (r *JobEventRepo) ListAfter(ctx context.Context, afterID int64, filter domain.JobEventFilter, limit int) ([]domain.JobEvent, error) {
+	var modelEvents []models.JobEvent
+	query := r.db.NewSelect().
+		Model(&modelEvents).
+		Where("id > ?", afterID).
+		OrderExpr("id ASC").
+		Limit(limit)
+	if filter.JobID != "" {
+		query = query.Where("job_id = ?", filter.JobID)
+	}
+	if filter.Sol != 0 {
+		query = query.Where("sol = ?", filter.Sol)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("could not list job events: %w", err)
+	}
+	events := make([]domain.JobEvent, 0, len(modelEvents))
+	for _, modelEvent := range modelEvents {
+		events = append(events, toDomainJobEvent(modelEvent))
+	}
+	return events, nil
+}
+
+// LastID returns the ID of the newest event, 0 when there is none
+func (r *JobEventRepo) LastID(ctx context.Context) (int64, error) {
+	var lastID int64
+	err := r.db.NewSelect().
+		Model((*models.JobEvent)(nil)).
+		ColumnExpr("COALESCE(MAX(id), 0)").
+		Scan(ctx, &lastID)
+	if err != nil {
+		return 0, fmt.Errorf("could not find last job event: %w", err)
+	}
+	return lastID, nil
+}
+
+// PruneBefore deletes events created before before
+func (r *JobEventRepo) PruneBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.NewDelete().
+		Model((*models.JobEvent)(nil)).
+		Where("created_at < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not prune job events: %w", err)
+	}
+	return res.RowsAffected()
+}
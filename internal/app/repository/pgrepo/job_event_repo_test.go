@@ -0,0 +1,86 @@
+package pgrepo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// TestJobEventRepo runs against a migrated Postgres database from TEST_DSN, the table is truncated before each test
+func TestJobEventRepo(t *testing.T) {
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+	db, err := pkg.Dial(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	picture := domain.NewPicture(domain.NewPictureData{Sol: 2, Size: 100, Url: "https://mars/2.jpg", Rover: "curiosity", Camera: "FHAZ"})
+
+	testCases := []struct {
+		name string
+		run  func(t *testing.T, repo *JobEventRepo)
+	}{
+		{
+			name: "Should list appended events after an ID matching filter",
+			run: func(t *testing.T, repo *JobEventRepo) {
+				first, err := repo.Append(context.Background(), domain.JobEvent{Type: domain.JobEventQueued, JobID: "a", Sol: 1, CreatedAt: now})
+				require.NoError(t, err)
+				_, err = repo.Append(context.Background(), domain.JobEvent{Type: domain.JobEventQueued, JobID: "b", Sol: 2, CreatedAt: now})
+				require.NoError(t, err)
+				completed, err := repo.Append(context.Background(), domain.JobEvent{Type: domain.JobEventCompleted, JobID: "b", Sol: 2, Picture: &picture, CreatedAt: now})
+				require.NoError(t, err)
+
+				events, err := repo.ListAfter(context.Background(), first.ID, domain.JobEventFilter{JobID: "b"}, 1)
+
+				require.NoError(t, err)
+				require.Len(t, events, 1)
+				require.Equal(t, domain.JobEventQueued, events[0].Type)
+				events, err = repo.ListAfter(context.Background(), events[0].ID, domain.JobEventFilter{Sol: 2}, 10)
+				require.NoError(t, err)
+				require.Equal(t, []domain.JobEvent{completed}, events)
+				lastID, err := repo.LastID(context.Background())
+				require.NoError(t, err)
+				require.Equal(t, completed.ID, lastID)
+			},
+		},
+		{
+			name: "Should prune events created before cutoff",
+			run: func(t *testing.T, repo *JobEventRepo) {
+				_, err := repo.Append(context.Background(), domain.JobEvent{Type: domain.JobEventQueued, JobID: "a", Sol: 1, CreatedAt: now.Add(-2 * time.Hour)})
+				require.NoError(t, err)
+				recent, err := repo.Append(context.Background(), domain.JobEvent{Type: domain.JobEventQueued, JobID: "b", Sol: 2, CreatedAt: now})
+				require.NoError(t, err)
+
+				pruned, err := repo.PruneBefore(context.Background(), now.Add(-time.Hour))
+
+				require.NoError(t, err)
+				require.Equal(t, int64(1), pruned)
+				events, err := repo.ListAfter(context.Background(), 0, domain.JobEventFilter{}, 10)
+				require.NoError(t, err)
+				require.Equal(t, []domain.JobEvent{recent}, events)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			_, err := db.NewTruncateTable().Table("job_events").Exec(context.Background())
+			require.NoError(t, err)
+			repo := NewJobEventRepo(db)
+
+			// When / Then
+			tc.run(t, &repo)
+		})
+	}
+}
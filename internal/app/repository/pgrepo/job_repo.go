@@ -0,0 +1,130 @@
+package pgrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+type JobRepo struct {
+	db *pkg.DB
+}
+
+func NewJobRepo(db *pkg.DB) JobRepo {
+	return JobRepo{db: db}
+}
+
+// Create inserts a new job record
+func (r *JobRepo) Create(ctx context.Context, job domain.Job) error {
+	modelJob := domainToJob(job)
+	_, err := r.db.NewInsert().Model(&modelJob).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not create job: %w", err)
+	}
+	return nil
+}
+
+// jobSolLockNamespace keeps the advisory locks taken per sol apart from any other advisory lock
+const jobSolLockNamespace = 1001
+
+// CreateWithCommand inserts a new job and its queue command into the outbox in one transaction,
+// so an accepted job is never left without a command that will eventually be published.
+// Creation is serialized per sol and domain.ErrJobAlreadyActive is returned when sol
+// already has a queued or running job updated since activeSince.
+func (r *JobRepo) CreateWithCommand(ctx context.Context, job domain.Job, command domain.SolCommand, activeSince time.Time) error {
+	modelJob := domainToJob(job)
+	outboxMessage := models.OutboxMessage{
+		JobID:     job.GetID(),
+		Payload:   command,
+		CreatedAt: job.GetCreatedAt(),
+
+		TraceContext: tracing.Inject(ctx),
+	}
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(?, ?)", jobSolLockNamespace, job.GetSol()); err != nil {
+			return fmt.Errorf("could not lock sol: %w", err)
+		}
+		active, err := tx.NewSelect().
+			Model((*models.Job)(nil)).
+			Where("sol = ?", job.GetSol()).
+			Where("status IN (?)", bun.In(domain.ActiveJobStatuses)).
+			Where("updated_at >= ?", activeSince).
+			Exists(ctx)
+		if err != nil {
+			return fmt.Errorf("could not check active jobs: %w", err)
+		}
+		if active {
+			return domain.ErrJobAlreadyActive
+		}
+		if _, err := tx.NewInsert().Model(&modelJob).Exec(ctx); err != nil {
+			return fmt.Errorf("could not create job: %w", err)
+		}
+		if _, err := tx.NewInsert().Model(&outboxMessage).Exec(ctx); err != nil {
+			return fmt.Errorf("could not create outbox message: %w", err)
+		}
+		return nil
+	})
+}
+
+// Update persists status and progress of an existing job
+func (r *JobRepo) Update(ctx context.Context, job domain.Job) error {
+	modelJob := domainToJob(job)
+	res, err := r.db.NewUpdate().
+		Model(&modelJob).
+		Column("status", "photos_sized", "photos_total", "error_slug", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not update job: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// FindActiveBySol retrieves the most recent queued or running job for sol updated since activeSince
+func (r *JobRepo) FindActiveBySol(ctx context.Context, sol int, activeSince time.Time) (domain.Job, error) {
+	var job models.Job
+
+	err := r.db.NewSelect().Model(&job).
+		Where("sol = ?", sol).
+		Where("status IN (?)", bun.In(domain.ActiveJobStatuses)).
+		Where("updated_at >= ?", activeSince).
+		Order("created_at DESC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Job{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Job{}, fmt.Errorf("could not find active job: %w", err)
+	}
+
+	return toDomainJob(job), nil
+}
+
+// FindByID retrieves job by its identifier
+func (r *JobRepo) FindByID(ctx context.Context, id string) (domain.Job, error) {
+	var job models.Job
+
+	err := r.db.NewSelect().Model(&job).
+		Where("id = ?", id).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Job{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Job{}, fmt.Errorf("could not find job: %w", err)
+	}
+
+	return toDomainJob(job), nil
+}
@@ -0,0 +1,49 @@
+package pgrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// NasaCacheRepo stores NASA API responses in Postgres, so the cache is shared by all workers
+type NasaCacheRepo struct {
+	db *pkg.DB
+}
+
+func NewNasaCacheRepo(db *pkg.DB) NasaCacheRepo {
+	return NasaCacheRepo{db: db}
+}
+
+// Get returns the cached response for key, expired ones included
+func (r *NasaCacheRepo) Get(ctx context.Context, key string) (domain.CachedResponse, error) {
+	var entry models.NasaCacheEntry
+	err := r.db.NewSelect().Model(&entry).Where("key = ?", key).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.CachedResponse{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.CachedResponse{}, fmt.Errorf("could not find cached response: %w", err)
+	}
+	return toDomainCachedResponse(entry), nil
+}
+
+// Set inserts or replaces the cached response for key
+func (r *NasaCacheRepo) Set(ctx context.Context, key string, response domain.CachedResponse) error {
+	entry := domainToNasaCacheEntry(key, response, time.Now().UTC())
+	_, err := r.db.NewInsert().
+		Model(&entry).
+		On("CONFLICT (key) DO UPDATE").
+		Set("body = EXCLUDED.body, etag = EXCLUDED.etag, last_modified = EXCLUDED.last_modified, expires_at = EXCLUDED.expires_at, updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not save cached response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package pgrepo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+type OutboxRepo struct {
+	db *pkg.DB
+}
+
+func NewOutboxRepo(db *pkg.DB) OutboxRepo {
+	return OutboxRepo{db: db}
+}
+
+// outboxClaimTimeout is how long a claimed batch is hidden from other relays. Publishing a batch takes
+// far less, messages of a relay that died while publishing are picked up again once it expired.
+const outboxClaimTimeout = 10 * time.Minute
+
+// PublishPending claims up to limit unsent messages and hands them to publish in order. Rows are claimed
+// in a short transaction with SKIP LOCKED, so several relays never publish the same message concurrently,
+// and published outside of it, so a slow broker holds neither a connection nor row locks. Published
+// messages are then marked sent in a second short transaction. The batch stops at the first publish
+// failure to keep ordering, the rest of it is handed back right away.
+func (r *OutboxRepo) PublishPending(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, message domain.OutboxMessage) error,
+) (int, error) {
+	messages, err := r.claim(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	claimed := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		claimed = append(claimed, message.ID)
+	}
+	sent := make([]int64, 0, len(messages))
+	var failed int64
+	var publishErr error
+	for _, message := range messages {
+		if publishErr = publish(ctx, toDomainOutboxMessage(message)); publishErr != nil {
+			failed = message.ID
+			break
+		}
+		sent = append(sent, message.ID)
+	}
+
+	// Sent messages are recorded even when ctx was canceled meanwhile, so they are not published again
+	settleCtx := context.WithoutCancel(ctx)
+	err = r.db.RunInTx(settleCtx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if len(sent) > 0 {
+			_, err := tx.NewUpdate().Model((*models.OutboxMessage)(nil)).
+				Set("attempts = attempts + 1").
+				Set("last_error = ''").
+				Set("sent_at = now()").
+				Set("locked_until = NULL").
+				Where("id IN (?)", bun.In(sent)).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("could not mark outbox messages as sent: %w", err)
+			}
+		}
+		if publishErr != nil {
+			_, err := tx.NewUpdate().Model((*models.OutboxMessage)(nil)).
+				Set("attempts = attempts + 1").
+				Set("last_error = ?", publishErr.Error()).
+				Where("id = ?", failed).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("could not record outbox failure: %w", err)
+			}
+		}
+		_, err := tx.NewUpdate().Model((*models.OutboxMessage)(nil)).
+			Set("locked_until = NULL").
+			Where("id IN (?)", bun.In(claimed)).
+			Where("sent_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("could not release outbox messages: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return len(sent), err
+	}
+	if publishErr != nil {
+		return len(sent), fmt.Errorf("could not publish outbox message: %w", publishErr)
+	}
+	return len(sent), nil
+}
+
+// claim leases the oldest unsent messages that no other relay holds, ordered by id
+func (r *OutboxRepo) claim(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := r.db.NewRaw(`
+		UPDATE outbox
+		SET locked_until = now() + ?::interval
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at IS NULL AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		fmt.Sprintf("%d milliseconds", outboxClaimTimeout.Milliseconds()), limit,
+	).Scan(ctx, &messages)
+	if err != nil {
+		return nil, fmt.Errorf("could not claim pending outbox messages: %w", err)
+	}
+	// RETURNING does not keep the order of the subquery
+	slices.SortFunc(messages, func(a, b models.OutboxMessage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return messages, nil
+}
@@ -0,0 +1,130 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// TestOutboxRepo runs against a migrated Postgres database from TEST_DSN, the table is truncated before each test
+func TestOutboxRepo(t *testing.T) {
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+	db, err := pkg.Dial(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	insert := func(t *testing.T, sols ...int) {
+		for _, sol := range sols {
+			message := models.OutboxMessage{
+				JobID:     domain.NewJobID(),
+				Payload:   domain.SolCommand{Sol: sol},
+				CreatedAt: time.Now().UTC(),
+			}
+			_, err := db.NewInsert().Model(&message).Exec(context.Background())
+			require.NoError(t, err)
+		}
+	}
+	collect := func(sols *[]int) func(ctx context.Context, message domain.OutboxMessage) error {
+		return func(ctx context.Context, message domain.OutboxMessage) error {
+			*sols = append(*sols, message.Command.Sol)
+			return nil
+		}
+	}
+
+	testCases := []struct {
+		name string
+		run  func(t *testing.T, repo *OutboxRepo)
+	}{
+		{
+			name: "Should publish pending messages in order once",
+			run: func(t *testing.T, repo *OutboxRepo) {
+				insert(t, 1, 2, 3)
+				var sols []int
+
+				published, err := repo.PublishPending(context.Background(), 10, collect(&sols))
+				require.NoError(t, err)
+				again, err := repo.PublishPending(context.Background(), 10, collect(&sols))
+
+				require.NoError(t, err)
+				require.Equal(t, 3, published)
+				require.Zero(t, again)
+				require.Equal(t, []int{1, 2, 3}, sols)
+			},
+		},
+		{
+			name: "Should stop at failure and hand the rest of the batch back",
+			run: func(t *testing.T, repo *OutboxRepo) {
+				insert(t, 1, 2, 3)
+				failing := func(ctx context.Context, message domain.OutboxMessage) error {
+					if message.Command.Sol == 2 {
+						return errors.New("broker is down")
+					}
+					return nil
+				}
+				published, err := repo.PublishPending(context.Background(), 10, failing)
+				require.ErrorContains(t, err, "broker is down")
+				require.Equal(t, 1, published)
+
+				var sols []int
+				published, err = repo.PublishPending(context.Background(), 10, collect(&sols))
+
+				require.NoError(t, err)
+				require.Equal(t, 2, published)
+				require.Equal(t, []int{2, 3}, sols)
+				var failed models.OutboxMessage
+				require.NoError(t, db.NewSelect().Model(&failed).Where("payload->>'sol' = '2'").Scan(context.Background()))
+				require.Equal(t, 2, failed.Attempts)
+				require.Empty(t, failed.LastError)
+			},
+		},
+		{
+			name: "Should publish outside of the claiming transaction",
+			run: func(t *testing.T, repo *OutboxRepo) {
+				insert(t, 1)
+				publish := func(ctx context.Context, message domain.OutboxMessage) error {
+					// Another relay finds nothing to claim and the row can still be written meanwhile
+					var others []int
+					published, err := repo.PublishPending(ctx, 10, collect(&others))
+					require.NoError(t, err)
+					require.Zero(t, published)
+					lockCtx, cancel := context.WithTimeout(ctx, time.Second)
+					defer cancel()
+					_, err = db.NewUpdate().Model((*models.OutboxMessage)(nil)).
+						Set("last_error = 'checked'").
+						Where("id = ?", message.ID).
+						Exec(lockCtx)
+					return err
+				}
+
+				published, err := repo.PublishPending(context.Background(), 10, publish)
+
+				require.NoError(t, err)
+				require.Equal(t, 1, published)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			_, err := db.NewTruncateTable().Table("outbox").Exec(context.Background())
+			require.NoError(t, err)
+			repo := NewOutboxRepo(db)
+
+			// When / Then
+			tc.run(t, &repo)
+		})
+	}
+}
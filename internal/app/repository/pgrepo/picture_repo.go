@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/uptrace/bun"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
@@ -38,11 +40,12 @@ func (r *PictureRepo) FindLargestPictureBySol(ctx context.Context, sol int) (dom
 
 // Save inserts or updates a picture record
 func (r *PictureRepo) Save(ctx context.Context, picture domain.Picture) error {
+	picture.Touch(time.Now().UTC())
 	modelPicture := domainToPicture(picture)
 	_, err := r.db.NewInsert().
 		Model(&modelPicture).
 		On("CONFLICT (sol) DO UPDATE").
-		Set("img_src = EXCLUDED.img_src, size = EXCLUDED.size"). // Update specific fields in case of conflict
+		Set("img_src = EXCLUDED.img_src, size = EXCLUDED.size, rover = EXCLUDED.rover, camera = EXCLUDED.camera, earth_date = EXCLUDED.earth_date, updated_at = EXCLUDED.updated_at"). // Update specific fields in case of conflict
 		Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("could not save picture: %w", err)
@@ -50,6 +53,51 @@ func (r *PictureRepo) Save(ctx context.Context, picture domain.Picture) error {
 	return nil
 }
 
+// FindLargestPictures returns the largest pictures across all computed sols.
+// Ties on size are broken by sol so that pages are stable between requests.
+func (r *PictureRepo) FindLargestPictures(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error) {
+	var pictures []models.Picture
+
+	query := applyLeaderboardFilter(r.db.NewSelect().Model(&pictures), filter)
+	err := query.
+		OrderExpr("size DESC, sol ASC"). // Served by pictures_size_sol_idx
+		Limit(filter.Limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not find largest pictures: %w", err)
+	}
+
+	result := make([]domain.Picture, 0, len(pictures))
+	for _, picture := range pictures {
+		result = append(result, toDomainPicture(picture))
+	}
+	return result, nil
+}
+
+// CountByCamera counts computed sols per camera of their largest picture, the filter limit is ignored
+func (r *PictureRepo) CountByCamera(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.CameraCount, error) {
+	var counts []struct {
+		Camera   string `bun:"camera"`
+		Pictures int    `bun:"pictures"`
+	}
+
+	err := applyLeaderboardFilter(r.db.NewSelect().Model((*models.Picture)(nil)), filter).
+		ColumnExpr("camera").
+		ColumnExpr("COUNT(*) AS pictures").
+		Group("camera").
+		OrderExpr("pictures DESC, camera ASC").
+		Scan(ctx, &counts)
+	if err != nil {
+		return nil, fmt.Errorf("could not count pictures by camera: %w", err)
+	}
+
+	result := make([]domain.CameraCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, domain.CameraCount{Camera: count.Camera, Pictures: count.Pictures})
+	}
+	return result, nil
+}
+
 // Exists checks if a picture exists in the database for the given sol
 func (r *PictureRepo) Exists(ctx context.Context, sol int) (bool, error) {
 	var exists bool
@@ -69,3 +117,20 @@ func (r *PictureRepo) Exists(ctx context.Context, sol int) (bool, error) {
 
 	return exists, nil
 }
+
+// applyLeaderboardFilter narrows query down to pictures matching filter, except for its limit
+func applyLeaderboardFilter(query *bun.SelectQuery, filter domain.LeaderboardFilter) *bun.SelectQuery {
+	if filter.Rover != "" {
+		query = query.Where("rover = ?", filter.Rover)
+	}
+	if filter.Camera != "" {
+		query = query.Where("camera = ?", filter.Camera)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("earth_date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("earth_date <= ?", filter.To)
+	}
+	return query
+}
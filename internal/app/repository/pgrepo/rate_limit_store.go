@@ -0,0 +1,64 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// RateLimitStore keeps token buckets in Postgres, so limits are shared by every API instance
+type RateLimitStore struct {
+	db *pkg.DB
+}
+
+func NewRateLimitStore(db *pkg.DB) RateLimitStore {
+	return RateLimitStore{db: db}
+}
+
+// Take takes a token from the bucket of key. The bucket row is locked while it is updated,
+// a missing one is created full first so concurrent first requests queue up on the same row.
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitDecision, error) {
+	var decision domain.RateLimitDecision
+	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		bucket := models.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		_, err := tx.NewInsert().Model(&bucket).On("CONFLICT (key) DO NOTHING").Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("could not create rate limit bucket: %w", err)
+		}
+		err = tx.NewSelect().Model(&bucket).WherePK().For("UPDATE").Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("could not lock rate limit bucket: %w", err)
+		}
+
+		var updated domain.TokenBucket
+		updated, decision = domain.TokenBucket{Tokens: bucket.Tokens, UpdatedAt: bucket.UpdatedAt}.Take(limit, now)
+		bucket.Tokens = updated.Tokens
+		bucket.UpdatedAt = updated.UpdatedAt
+		_, err = tx.NewUpdate().Model(&bucket).Column("tokens", "updated_at").WherePK().Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("could not update rate limit bucket: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.RateLimitDecision{}, err
+	}
+	return decision, nil
+}
+
+// PruneIdle deletes buckets not used since before, they are full again and would be recreated as such
+func (s *RateLimitStore) PruneIdle(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.NewDelete().
+		Model((*models.RateLimitBucket)(nil)).
+		Where("updated_at < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not prune rate limit buckets: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,31 @@
+package pgrepo
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/repotest"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// TestRateLimitStore runs against a migrated Postgres database from TEST_DSN, the table is truncated before each test
+func TestRateLimitStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+	db, err := pkg.Dial(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	repotest.TestRateLimitStore(t, func(t *testing.T) repotest.RateLimitStore {
+		_, err := db.NewTruncateTable().Table("rate_limit_buckets").Exec(context.Background())
+		require.NoError(t, err)
+		store := NewRateLimitStore(db)
+		return &store
+	})
+}
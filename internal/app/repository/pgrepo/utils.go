@@ -1,22 +1,266 @@
 package pgrepo
 
 import (
+	"time"
+
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
 )
 
 func toDomainPicture(picture models.Picture) domain.Picture {
 	return domain.NewPicture(domain.NewPictureData{
-		Sol:  picture.Sol,
-		Size: picture.Size,
-		Url:  picture.ImgSrc,
+		Sol:       picture.Sol,
+		Size:      picture.Size,
+		Url:       picture.ImgSrc,
+		Rover:     picture.Rover,
+		Camera:    picture.Camera,
+		EarthDate: picture.EarthDate,
+		UpdatedAt: picture.UpdatedAt,
 	})
 }
 
 func domainToPicture(domainPicture domain.Picture) models.Picture {
 	return models.Picture{
-		ImgSrc: domainPicture.GetUrl(),
-		Size:   domainPicture.GetSize(),
-		Sol:    domainPicture.GetSol(),
+		ImgSrc:    domainPicture.GetUrl(),
+		Size:      domainPicture.GetSize(),
+		Sol:       domainPicture.GetSol(),
+		Rover:     domainPicture.GetRover(),
+		Camera:    domainPicture.GetCamera(),
+		EarthDate: domainPicture.GetEarthDate(),
+		UpdatedAt: domainPicture.GetUpdatedAt(),
+	}
+}
+
+func toDomainJob(job models.Job) domain.Job {
+	return domain.NewJob(domain.NewJobData{
+		ID:          job.ID,
+		Sol:         job.Sol,
+		Status:      domain.JobStatus(job.Status),
+		PhotosSized: job.PhotosSized,
+		PhotosTotal: job.PhotosTotal,
+		ErrorSlug:   job.ErrorSlug,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+	})
+}
+
+func domainToJob(job domain.Job) models.Job {
+	return models.Job{
+		ID:          job.GetID(),
+		Sol:         job.GetSol(),
+		Status:      string(job.GetStatus()),
+		PhotosSized: job.GetPhotosSized(),
+		PhotosTotal: job.GetPhotosTotal(),
+		ErrorSlug:   job.GetErrorSlug(),
+		CreatedAt:   job.GetCreatedAt(),
+		UpdatedAt:   job.GetUpdatedAt(),
+	}
+}
+
+func toDomainWebhookSubscription(subscription models.WebhookSubscription) domain.WebhookSubscription {
+	eventTypes := make([]domain.JobEventType, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		eventTypes = append(eventTypes, domain.JobEventType(eventType))
+	}
+	return domain.NewWebhookSubscription(domain.NewWebhookSubscriptionData{
+		ID:         subscription.ID,
+		Url:        subscription.Url,
+		Secret:     subscription.Secret,
+		EventTypes: eventTypes,
+		CreatedAt:  subscription.CreatedAt,
+	})
+}
+
+func domainToWebhookSubscription(subscription domain.WebhookSubscription) models.WebhookSubscription {
+	eventTypes := make([]string, 0, len(subscription.GetEventTypes()))
+	for _, eventType := range subscription.GetEventTypes() {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	return models.WebhookSubscription{
+		ID:         subscription.GetID(),
+		Url:        subscription.GetUrl(),
+		Secret:     subscription.GetSecret(),
+		EventTypes: eventTypes,
+		CreatedAt:  subscription.GetCreatedAt(),
+	}
+}
+
+func toDomainWebhookDelivery(delivery models.WebhookDelivery) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventType:      domain.JobEventType(delivery.EventType),
+		JobID:          delivery.JobID,
+		Attempt:        delivery.Attempt,
+		StatusCode:     delivery.StatusCode,
+		Error:          delivery.Error,
+		Succeeded:      delivery.Succeeded,
+		Duration:       time.Duration(delivery.DurationMs) * time.Millisecond,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func domainToWebhookDelivery(delivery domain.WebhookDelivery) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventType:      string(delivery.EventType),
+		JobID:          delivery.JobID,
+		Attempt:        delivery.Attempt,
+		StatusCode:     delivery.StatusCode,
+		Error:          delivery.Error,
+		Succeeded:      delivery.Succeeded,
+		DurationMs:     delivery.Duration.Milliseconds(),
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func toDomainPendingWebhookDelivery(delivery models.PendingWebhookDelivery, subscription models.WebhookSubscription) domain.PendingWebhookDelivery {
+	return domain.PendingWebhookDelivery{
+		ID:            delivery.ID,
+		Subscription:  toDomainWebhookSubscription(subscription),
+		EventType:     domain.JobEventType(delivery.EventType),
+		JobID:         delivery.JobID,
+		Body:          []byte(delivery.Body),
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		CreatedAt:     delivery.CreatedAt,
+	}
+}
+
+func domainToPendingWebhookDelivery(delivery domain.PendingWebhookDelivery) models.PendingWebhookDelivery {
+	return models.PendingWebhookDelivery{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.Subscription.GetID(),
+		EventType:      string(delivery.EventType),
+		JobID:          delivery.JobID,
+		Body:           string(delivery.Body),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func toDomainOutboxMessage(message models.OutboxMessage) domain.OutboxMessage {
+	return domain.OutboxMessage{
+		ID:        message.ID,
+		Command:   message.Payload,
+		Attempts:  message.Attempts,
+		CreatedAt: message.CreatedAt,
+
+		TraceContext: message.TraceContext,
+	}
+}
+
+func toDomainCachedResponse(entry models.NasaCacheEntry) domain.CachedResponse {
+	return domain.CachedResponse{
+		Body:         entry.Body,
+		ETag:         entry.ETag,
+		LastModified: entry.LastModified,
+		ExpiresAt:    entry.ExpiresAt,
+	}
+}
+
+func domainToNasaCacheEntry(key string, response domain.CachedResponse, now time.Time) models.NasaCacheEntry {
+	return models.NasaCacheEntry{
+		Key:          key,
+		Body:         response.Body,
+		ETag:         response.ETag,
+		LastModified: response.LastModified,
+		ExpiresAt:    response.ExpiresAt,
+		UpdatedAt:    now,
+	}
+}
+
+func toDomainAPIKey(key models.APIKey) domain.APIKey {
+	scopes := make([]domain.APIKeyScope, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, domain.APIKeyScope(scope))
+	}
+	return domain.NewAPIKey(domain.NewAPIKeyData{
+		ID:           key.ID,
+		Name:         key.Name,
+		Hash:         key.KeyHash,
+		Prefix:       key.KeyPrefix,
+		Scopes:       scopes,
+		RequestQuota: key.RequestQuota,
+		EnqueueQuota: key.EnqueueQuota,
+		CreatedAt:    key.CreatedAt,
+		RevokedAt:    key.RevokedAt,
+	})
+}
+
+func domainToAPIKey(key domain.APIKey) models.APIKey {
+	scopes := make([]string, 0, len(key.GetScopes()))
+	for _, scope := range key.GetScopes() {
+		scopes = append(scopes, string(scope))
+	}
+	return models.APIKey{
+		ID:           key.GetID(),
+		Name:         key.GetName(),
+		KeyHash:      key.GetHash(),
+		KeyPrefix:    key.GetPrefix(),
+		Scopes:       scopes,
+		RequestQuota: key.GetRequestQuota(),
+		EnqueueQuota: key.GetEnqueueQuota(),
+		CreatedAt:    key.GetCreatedAt(),
+		RevokedAt:    key.GetRevokedAt(),
+	}
+}
+
+func toDomainIdempotencyRecord(key models.IdempotencyKey) domain.IdempotencyRecord {
+	return domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+		Key:         key.Key,
+		RequestHash: key.RequestHash,
+		StatusCode:  key.StatusCode,
+		Body:        key.Body,
+		CreatedAt:   key.CreatedAt,
+		ExpiresAt:   key.ExpiresAt,
+	})
+}
+
+func domainToIdempotencyKey(record domain.IdempotencyRecord) models.IdempotencyKey {
+	return models.IdempotencyKey{
+		Key:         record.GetKey(),
+		RequestHash: record.GetRequestHash(),
+		StatusCode:  record.GetStatusCode(),
+		Body:        record.GetBody(),
+		CreatedAt:   record.GetCreatedAt(),
+		ExpiresAt:   record.GetExpiresAt(),
+	}
+}
+
+func toDomainJobEvent(event models.JobEvent) domain.JobEvent {
+	domainEvent := domain.JobEvent{
+		ID:          event.ID,
+		Type:        domain.JobEventType(event.Type),
+		JobID:       event.JobID,
+		Sol:         event.Sol,
+		PhotosSized: event.PhotosSized,
+		PhotosTotal: event.PhotosTotal,
+		Slug:        event.Slug,
+		CreatedAt:   event.CreatedAt,
+	}
+	if event.Picture != nil {
+		picture := toDomainPicture(*event.Picture)
+		domainEvent.Picture = &picture
+	}
+	return domainEvent
+}
+
+func domainToJobEvent(event domain.JobEvent) models.JobEvent {
+	modelEvent := models.JobEvent{
+		Type:        string(event.Type),
+		JobID:       event.JobID,
+		Sol:         event.Sol,
+		PhotosSized: event.PhotosSized,
+		PhotosTotal: event.PhotosTotal,
+		Slug:        event.Slug,
+		CreatedAt:   event.CreatedAt,
+	}
+	if event.Picture != nil {
+		picture := domainToPicture(*event.Picture)
+		modelEvent.Picture = &picture
 	}
+	return modelEvent
 }
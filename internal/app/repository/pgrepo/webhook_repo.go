@@ -0,0 +1,188 @@
+package pgrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+type WebhookRepo struct {
+	db *pkg.DB
+}
+
+func NewWebhookRepo(db *pkg.DB) WebhookRepo {
+	return WebhookRepo{db: db}
+}
+
+// CreateSubscription inserts a new webhook subscription
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error {
+	modelSubscription := domainToWebhookSubscription(subscription)
+	_, err := r.db.NewInsert().Model(&modelSubscription).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// ListSubscriptions returns all webhook subscriptions, oldest first
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+
+	err := r.db.NewSelect().Model(&subscriptions).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list webhook subscriptions: %w", err)
+	}
+
+	result := make([]domain.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		result = append(result, toDomainWebhookSubscription(subscription))
+	}
+	return result, nil
+}
+
+// FindSubscriptionsByEventType returns subscriptions interested in eventType
+func (r *WebhookRepo) FindSubscriptionsByEventType(ctx context.Context, eventType domain.JobEventType) ([]domain.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+
+	err := r.db.NewSelect().Model(&subscriptions).
+		Where("? = ANY(event_types)", string(eventType)).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not find webhook subscriptions: %w", err)
+	}
+
+	result := make([]domain.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		result = append(result, toDomainWebhookSubscription(subscription))
+	}
+	return result, nil
+}
+
+// DeleteSubscription removes subscription together with its delivery log
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, id string) error {
+	res, err := r.db.NewDelete().
+		Model((*models.WebhookSubscription)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not delete webhook subscription: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// SaveDelivery appends a delivery attempt to the delivery log
+func (r *WebhookRepo) SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	modelDelivery := domainToWebhookDelivery(delivery)
+	_, err := r.db.NewInsert().Model(&modelDelivery).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the most recent delivery attempts of a subscription
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := r.db.NewSelect().Model(&deliveries).
+		Where("subscription_id = ?", subscriptionID).
+		Order("id DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list webhook deliveries: %w", err)
+	}
+
+	result := make([]domain.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, toDomainWebhookDelivery(delivery))
+	}
+	return result, nil
+}
+
+// EnqueueDeliveries stores deliveries until they are delivered or given up
+func (r *WebhookRepo) EnqueueDeliveries(ctx context.Context, deliveries []domain.PendingWebhookDelivery) error {
+	modelDeliveries := make([]models.PendingWebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		modelDeliveries = append(modelDeliveries, domainToPendingWebhookDelivery(delivery))
+	}
+	_, err := r.db.NewInsert().Model(&modelDeliveries).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDelivery leases the delivery due the longest at now that no other worker holds until lockedUntil.
+// domain.ErrNotFound is returned when nothing is due.
+func (r *WebhookRepo) ClaimDueDelivery(ctx context.Context, now, lockedUntil time.Time) (domain.PendingWebhookDelivery, error) {
+	var delivery models.PendingWebhookDelivery
+	err := r.db.NewRaw(`
+		UPDATE pending_webhook_deliveries
+		SET locked_until = ?
+		WHERE id = (
+			SELECT id FROM pending_webhook_deliveries
+			WHERE next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		lockedUntil, now, now,
+	).Scan(ctx, &delivery)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.PendingWebhookDelivery{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.PendingWebhookDelivery{}, fmt.Errorf("could not claim webhook delivery: %w", err)
+	}
+
+	// The delivery is deleted together with its subscription, so the subscription is found unless it was just deleted
+	var subscription models.WebhookSubscription
+	err = r.db.NewSelect().Model(&subscription).Where("id = ?", delivery.SubscriptionID).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.PendingWebhookDelivery{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.PendingWebhookDelivery{}, fmt.Errorf("could not find webhook subscription: %w", err)
+	}
+	return toDomainPendingWebhookDelivery(delivery, subscription), nil
+}
+
+// RescheduleDelivery records attempts made so far and releases the delivery until nextAttemptAt
+func (r *WebhookRepo) RescheduleDelivery(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.PendingWebhookDelivery)(nil)).
+		Set("attempts = ?", attempts).
+		Set("next_attempt_at = ?", nextAttemptAt).
+		Set("locked_until = NULL").
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not reschedule webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// FinishDelivery removes a delivery that succeeded or was given up
+func (r *WebhookRepo) FinishDelivery(ctx context.Context, id int64) error {
+	_, err := r.db.NewDelete().
+		Model((*models.PendingWebhookDelivery)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not finish webhook delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package pgrepo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// TestWebhookRepo_PendingDeliveries runs against a migrated Postgres database from TEST_DSN, the tables are truncated before each test
+func TestWebhookRepo_PendingDeliveries(t *testing.T) {
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+	db, err := pkg.Dial(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	subscription := domain.NewWebhookSubscription(domain.NewWebhookSubscriptionData{
+		ID:         "sub-1",
+		Url:        "https://example.com/hook",
+		Secret:     "0123456789abcdef",
+		EventTypes: domain.WebhookEventTypes,
+		CreatedAt:  now,
+	})
+	pending := func(jobID string, nextAttemptAt time.Time) domain.PendingWebhookDelivery {
+		return domain.PendingWebhookDelivery{
+			Subscription:  subscription,
+			EventType:     domain.JobEventCompleted,
+			JobID:         jobID,
+			Body:          []byte(`{"sol":1}`),
+			NextAttemptAt: nextAttemptAt,
+			CreatedAt:     now,
+		}
+	}
+
+	testCases := []struct {
+		name string
+		run  func(t *testing.T, repo *WebhookRepo)
+	}{
+		{
+			name: "Should claim due deliveries once until their lease expires",
+			run: func(t *testing.T, repo *WebhookRepo) {
+				require.NoError(t, repo.EnqueueDeliveries(context.Background(), []domain.PendingWebhookDelivery{
+					pending("job-1", now), pending("job-2", now.Add(time.Hour)),
+				}))
+
+				claimed, err := repo.ClaimDueDelivery(context.Background(), now, now.Add(time.Minute))
+
+				require.NoError(t, err)
+				require.Equal(t, "job-1", claimed.JobID)
+				require.Equal(t, "https://example.com/hook", claimed.Subscription.GetUrl())
+				require.Equal(t, `{"sol":1}`, string(claimed.Body))
+				_, err = repo.ClaimDueDelivery(context.Background(), now, now.Add(time.Minute))
+				require.ErrorIs(t, err, domain.ErrNotFound)
+				reclaimed, err := repo.ClaimDueDelivery(context.Background(), now.Add(2*time.Minute), now.Add(3*time.Minute))
+				require.NoError(t, err)
+				require.Equal(t, claimed.ID, reclaimed.ID)
+			},
+		},
+		{
+			name: "Should release rescheduled delivery at its next attempt",
+			run: func(t *testing.T, repo *WebhookRepo) {
+				require.NoError(t, repo.EnqueueDeliveries(context.Background(), []domain.PendingWebhookDelivery{pending("job-1", now)}))
+				claimed, err := repo.ClaimDueDelivery(context.Background(), now, now.Add(time.Minute))
+				require.NoError(t, err)
+
+				require.NoError(t, repo.RescheduleDelivery(context.Background(), claimed.ID, 1, now.Add(time.Second)))
+
+				_, err = repo.ClaimDueDelivery(context.Background(), now, now.Add(time.Minute))
+				require.ErrorIs(t, err, domain.ErrNotFound)
+				retried, err := repo.ClaimDueDelivery(context.Background(), now.Add(time.Second), now.Add(time.Minute))
+				require.NoError(t, err)
+				require.Equal(t, 1, retried.Attempts)
+			},
+		},
+		{
+			name: "Should not claim finished delivery",
+			run: func(t *testing.T, repo *WebhookRepo) {
+				require.NoError(t, repo.EnqueueDeliveries(context.Background(), []domain.PendingWebhookDelivery{pending("job-1", now)}))
+				claimed, err := repo.ClaimDueDelivery(context.Background(), now, now.Add(time.Minute))
+				require.NoError(t, err)
+
+				require.NoError(t, repo.FinishDelivery(context.Background(), claimed.ID))
+
+				_, err = repo.ClaimDueDelivery(context.Background(), now.Add(time.Hour), now.Add(2*time.Hour))
+				require.ErrorIs(t, err, domain.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			_, err := db.ExecContext(context.Background(), "TRUNCATE webhook_subscriptions CASCADE")
+			require.NoError(t, err)
+			repo := NewWebhookRepo(db)
+			require.NoError(t, repo.CreateSubscription(context.Background(), subscription))
+
+			// When / Then
+			tc.run(t, &repo)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Package repotest holds contract tests that every repository implementation has to pass
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// PictureRepository is the behavior of services.PictureRepository under test
+type PictureRepository interface {
+	FindLargestPictureBySol(ctx context.Context, sol int) (domain.Picture, error)
+	FindLargestPictures(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error)
+	Save(ctx context.Context, picture domain.Picture) error
+	Exists(ctx context.Context, sol int) (bool, error)
+	CountByCamera(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.CameraCount, error)
+}
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse(time.DateOnly, value)
+	return parsed
+}
+
+func picture(sol, size int, camera, earthDate string) domain.Picture {
+	return domain.NewPicture(domain.NewPictureData{
+		Sol:       sol,
+		Size:      size,
+		Url:       "http://example.com/" + camera,
+		Rover:     "curiosity",
+		Camera:    camera,
+		EarthDate: date(earthDate),
+	})
+}
+
+func requirePictureEqual(t *testing.T, expected, actual domain.Picture) {
+	t.Helper()
+	require.Equal(t, expected.GetSol(), actual.GetSol())
+	require.Equal(t, expected.GetSize(), actual.GetSize())
+	require.Equal(t, expected.GetUrl(), actual.GetUrl())
+	require.Equal(t, expected.GetRover(), actual.GetRover())
+	require.Equal(t, expected.GetCamera(), actual.GetCamera())
+	require.True(t, expected.GetEarthDate().Equal(actual.GetEarthDate()),
+		"expected earth date %s, got %s", expected.GetEarthDate(), actual.GetEarthDate())
+}
+
+func sols(pictures []domain.Picture) []int {
+	result := make([]int, 0, len(pictures))
+	for _, picture := range pictures {
+		result = append(result, picture.GetSol())
+	}
+	return result
+}
+
+// TestPictureRepository runs the shared behavioral tests against an empty repository created by newRepo
+func TestPictureRepository(t *testing.T, newRepo func(t *testing.T) PictureRepository) {
+	ctx := context.Background()
+
+	t.Run("Should find saved picture", func(t *testing.T) {
+		// Given
+		repo := newRepo(t)
+		saved := picture(1000, 2048, "MAST", "2015-05-30")
+
+		// When
+		require.NoError(t, repo.Save(ctx, saved))
+		found, err := repo.FindLargestPictureBySol(ctx, 1000)
+
+		// Then
+		require.NoError(t, err)
+		requirePictureEqual(t, saved, found)
+	})
+
+	t.Run("Should record when picture was saved", func(t *testing.T) {
+		// Given
+		repo := newRepo(t)
+		before := time.Now().Add(-time.Second)
+
+		// When
+		require.NoError(t, repo.Save(ctx, picture(1000, 2048, "MAST", "2015-05-30")))
+		found, err := repo.FindLargestPictureBySol(ctx, 1000)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, found.GetUpdatedAt().After(before),
+			"expected updated at after %s, got %s", before, found.GetUpdatedAt())
+	})
+
+	t.Run("Should replace picture when sol is saved again", func(t *testing.T) {
+		// Given
+		repo := newRepo(t)
+		require.NoError(t, repo.Save(ctx, picture(1000, 2048, "MAST", "2015-05-30")))
+		replacement := picture(1000, 4096, "NAVCAM", "2015-05-31")
+
+		// When
+		require.NoError(t, repo.Save(ctx, replacement))
+		found, err := repo.FindLargestPictureBySol(ctx, 1000)
+
+		// Then
+		require.NoError(t, err)
+		requirePictureEqual(t, replacement, found)
+		all, err := repo.FindLargestPictures(ctx, domain.LeaderboardFilter{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+	})
+
+	t.Run("Should report whether picture exists", func(t *testing.T) {
+		// Given
+		repo := newRepo(t)
+		require.NoError(t, repo.Save(ctx, picture(1000, 2048, "MAST", "2015-05-30")))
+
+		// When
+		exists, err := repo.Exists(ctx, 1000)
+		require.NoError(t, err)
+		missing, err := repo.Exists(ctx, 1001)
+		require.NoError(t, err)
+
+		// Then
+		require.True(t, exists)
+		require.False(t, missing)
+	})
+
+	t.Run("Should return not found for unknown sol", func(t *testing.T) {
+		// Given
+		repo := newRepo(t)
+
+		// When
+		_, err := repo.FindLargestPictureBySol(ctx, 1000)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("Should list largest pictures", func(t *testing.T) {
+		// Given
+		repo := newRepo(t)
+		for _, saved := range []domain.Picture{
+			picture(1, 100, "MAST", "2012-08-07"),
+			picture(2, 300, "NAVCAM", "2012-08-08"),
+			picture(3, 200, "MAST", "2012-08-09"),
+			picture(4, 300, "MAST", "2012-08-10"),
+			picture(5, 50, "FHAZ", ""),
+		} {
+			require.NoError(t, repo.Save(ctx, saved))
+		}
+
+		testCases := []struct {
+			name         string
+			filter       domain.LeaderboardFilter
+			expectedSols []int
+		}{
+			{
+				name:         "ordered by size with ties broken by sol",
+				filter:       domain.LeaderboardFilter{Limit: 10},
+				expectedSols: []int{2, 4, 3, 1, 5},
+			},
+			{
+				name:         "limited",
+				filter:       domain.LeaderboardFilter{Limit: 2},
+				expectedSols: []int{2, 4},
+			},
+			{
+				name:         "filtered by camera",
+				filter:       domain.LeaderboardFilter{Limit: 10, Camera: "MAST"},
+				expectedSols: []int{4, 3, 1},
+			},
+			{
+				name:         "filtered by rover",
+				filter:       domain.LeaderboardFilter{Limit: 10, Rover: "perseverance"},
+				expectedSols: []int{},
+			},
+			{
+				name:         "filtered by earth date range",
+				filter:       domain.LeaderboardFilter{Limit: 10, From: date("2012-08-08"), To: date("2012-08-09")},
+				expectedSols: []int{2, 3},
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				// When
+				pictures, err := repo.FindLargestPictures(ctx, tc.filter)
+
+				// Then
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedSols, sols(pictures))
+			})
+		}
+	})
+
+	t.Run("Should count pictures by camera", func(t *testing.T) {
+		// Given
+		repo := newRepo(t)
+		for _, saved := range []domain.Picture{
+			picture(1, 100, "MAST", "2012-08-07"),
+			picture(2, 300, "NAVCAM", "2012-08-08"),
+			picture(3, 200, "MAST", "2012-08-09"),
+			picture(4, 300, "FHAZ", "2012-08-10"),
+		} {
+			require.NoError(t, repo.Save(ctx, saved))
+		}
+
+		// When
+		all, err := repo.CountByCamera(ctx, domain.LeaderboardFilter{Limit: 1})
+		require.NoError(t, err)
+		dated, err := repo.CountByCamera(ctx, domain.LeaderboardFilter{From: date("2012-08-08")})
+		require.NoError(t, err)
+
+		// Then
+		require.Equal(t, []domain.CameraCount{
+			{Camera: "MAST", Pictures: 2},
+			{Camera: "FHAZ", Pictures: 1},
+			{Camera: "NAVCAM", Pictures: 1},
+		}, all)
+		require.Equal(t, []domain.CameraCount{
+			{Camera: "FHAZ", Pictures: 1},
+			{Camera: "MAST", Pictures: 1},
+			{Camera: "NAVCAM", Pictures: 1},
+		}, dated)
+	})
+}
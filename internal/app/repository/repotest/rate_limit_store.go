@@ -0,0 +1,106 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// RateLimitStore is the behavior of httpserver.RateLimitStore under test
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitDecision, error)
+}
+
+// TestRateLimitStore runs the shared behavioral tests against an empty store created by newStore
+func TestRateLimitStore(t *testing.T, newStore func(t *testing.T) RateLimitStore) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	limit := domain.NewRateLimit(60, 2)
+
+	t.Run("Should allow burst then reject until a token refills", func(t *testing.T) {
+		// Given
+		store := newStore(t)
+
+		// When
+		first, err := store.Take(ctx, "client", limit, now)
+		require.NoError(t, err)
+		second, err := store.Take(ctx, "client", limit, now)
+		require.NoError(t, err)
+		third, err := store.Take(ctx, "client", limit, now)
+		require.NoError(t, err)
+
+		// Then
+		require.True(t, first.Allowed)
+		require.Equal(t, 1, first.Remaining)
+		require.True(t, second.Allowed)
+		require.Equal(t, 0, second.Remaining)
+		require.Equal(t, 2*time.Second, second.Reset)
+		require.False(t, third.Allowed)
+		require.Equal(t, time.Second, third.RetryAfter)
+	})
+
+	t.Run("Should refill tokens over time", func(t *testing.T) {
+		// Given
+		store := newStore(t)
+		for i := 0; i < 2; i++ {
+			_, err := store.Take(ctx, "client", limit, now)
+			require.NoError(t, err)
+		}
+
+		// When
+		decision, err := store.Take(ctx, "client", limit, now.Add(1500*time.Millisecond))
+		require.NoError(t, err)
+
+		// Then
+		require.True(t, decision.Allowed)
+		require.Equal(t, 0, decision.Remaining)
+		require.Equal(t, 1500*time.Millisecond, decision.Reset)
+	})
+
+	t.Run("Should keep clients apart", func(t *testing.T) {
+		// Given
+		store := newStore(t)
+		for i := 0; i < 3; i++ {
+			_, err := store.Take(ctx, "busy", limit, now)
+			require.NoError(t, err)
+		}
+
+		// When
+		decision, err := store.Take(ctx, "quiet", limit, now)
+		require.NoError(t, err)
+
+		// Then
+		require.True(t, decision.Allowed)
+	})
+
+	t.Run("Should not hand out more tokens than the burst to concurrent requests", func(t *testing.T) {
+		// Given
+		store := newStore(t)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+
+		// When
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				decision, err := store.Take(ctx, "client", limit, now)
+				require.NoError(t, err)
+				if decision.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Then
+		require.Equal(t, 2, allowed)
+	})
+}
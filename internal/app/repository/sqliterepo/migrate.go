@@ -0,0 +1,33 @@
+package sqliterepo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	sqlitemigrations "github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/migrations/sqlite"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// Migrate brings the schema of db up to date with the embedded SQLite migrations
+func Migrate(db *pkg.DB) error {
+	source, err := iofs.New(sqlitemigrations.FS, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read sqlite migrations: %w", err)
+	}
+	driver, err := sqlite.WithInstance(db.DB.DB, &sqlite.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create sqlite migration driver: %w", err)
+	}
+	// Migrate is not closed on purpose, closing it would close db
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		return err
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
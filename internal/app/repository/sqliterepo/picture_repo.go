@@ -0,0 +1,153 @@
+package sqliterepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+// PictureRepo stores pictures in a SQLite database opened with pkg.DialSQLite
+type PictureRepo struct {
+	db *pkg.DB
+}
+
+func NewPictureRepo(db *pkg.DB) PictureRepo {
+	return PictureRepo{db: db}
+}
+
+// FindLargestPictureBySol retrieves the largest picture (based on size) for the given sol
+func (r *PictureRepo) FindLargestPictureBySol(ctx context.Context, sol int) (domain.Picture, error) {
+	var picture models.Picture
+
+	err := r.db.NewSelect().Model(&picture).
+		Where("sol = ?", sol).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Picture{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Picture{}, fmt.Errorf("could not find picture: %w", err)
+	}
+
+	return toDomainPicture(picture), nil
+}
+
+// Save inserts or updates a picture record
+func (r *PictureRepo) Save(ctx context.Context, picture domain.Picture) error {
+	picture.Touch(time.Now().UTC())
+	modelPicture := domainToPicture(picture)
+	_, err := r.db.NewInsert().
+		Model(&modelPicture).
+		On("CONFLICT (sol) DO UPDATE").
+		Set("img_src = EXCLUDED.img_src, size = EXCLUDED.size, rover = EXCLUDED.rover, camera = EXCLUDED.camera, earth_date = EXCLUDED.earth_date, updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("could not save picture: %w", err)
+	}
+	return nil
+}
+
+// FindLargestPictures returns the largest pictures across all computed sols, ties are broken by sol
+func (r *PictureRepo) FindLargestPictures(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error) {
+	var pictures []models.Picture
+
+	query := applyLeaderboardFilter(r.db.NewSelect().Model(&pictures), filter)
+	err := query.
+		OrderExpr("size DESC, sol ASC"). // Served by pictures_size_sol_idx
+		Limit(filter.Limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not find largest pictures: %w", err)
+	}
+
+	result := make([]domain.Picture, 0, len(pictures))
+	for _, picture := range pictures {
+		result = append(result, toDomainPicture(picture))
+	}
+	return result, nil
+}
+
+// CountByCamera counts computed sols per camera of their largest picture, the filter limit is ignored
+func (r *PictureRepo) CountByCamera(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.CameraCount, error) {
+	var counts []struct {
+		Camera   string `bun:"camera"`
+		Pictures int    `bun:"pictures"`
+	}
+
+	err := applyLeaderboardFilter(r.db.NewSelect().Model((*models.Picture)(nil)), filter).
+		ColumnExpr("camera").
+		ColumnExpr("COUNT(*) AS pictures").
+		Group("camera").
+		OrderExpr("pictures DESC, camera ASC").
+		Scan(ctx, &counts)
+	if err != nil {
+		return nil, fmt.Errorf("could not count pictures by camera: %w", err)
+	}
+
+	result := make([]domain.CameraCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, domain.CameraCount{Camera: count.Camera, Pictures: count.Pictures})
+	}
+	return result, nil
+}
+
+// Exists checks if a picture exists in the database for the given sol
+func (r *PictureRepo) Exists(ctx context.Context, sol int) (bool, error) {
+	exists, err := r.db.NewSelect().
+		Model((*models.Picture)(nil)).
+		Where("sol = ?", sol).
+		Exists(ctx)
+	if err != nil {
+		return false, fmt.Errorf("could not check if picture exists: %w", err)
+	}
+	return exists, nil
+}
+
+func toDomainPicture(picture models.Picture) domain.Picture {
+	return domain.NewPicture(domain.NewPictureData{
+		Sol:       picture.Sol,
+		Size:      picture.Size,
+		Url:       picture.ImgSrc,
+		Rover:     picture.Rover,
+		Camera:    picture.Camera,
+		EarthDate: picture.EarthDate,
+		UpdatedAt: picture.UpdatedAt,
+	})
+}
+
+// domainToPicture stores earth_date in UTC, text timestamps only compare correctly in one zone
+func domainToPicture(domainPicture domain.Picture) models.Picture {
+	return models.Picture{
+		ImgSrc:    domainPicture.GetUrl(),
+		Size:      domainPicture.GetSize(),
+		Sol:       domainPicture.GetSol(),
+		Rover:     domainPicture.GetRover(),
+		Camera:    domainPicture.GetCamera(),
+		EarthDate: domainPicture.GetEarthDate().UTC(),
+		UpdatedAt: domainPicture.GetUpdatedAt().UTC(),
+	}
+}
+
+// applyLeaderboardFilter narrows query down to pictures matching filter, except for its limit
+func applyLeaderboardFilter(query *bun.SelectQuery, filter domain.LeaderboardFilter) *bun.SelectQuery {
+	if filter.Rover != "" {
+		query = query.Where("rover = ?", filter.Rover)
+	}
+	if filter.Camera != "" {
+		query = query.Where("camera = ?", filter.Camera)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("earth_date >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("earth_date <= ?", filter.To.UTC())
+	}
+	return query
+}
@@ -0,0 +1,22 @@
+package sqliterepo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/repository/repotest"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/pkg"
+)
+
+func TestPictureRepo(t *testing.T) {
+	repotest.TestPictureRepository(t, func(t *testing.T) repotest.PictureRepository {
+		db, err := pkg.DialSQLite(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+		require.NoError(t, Migrate(db))
+		repo := NewPictureRepo(db)
+		return &repo
+	})
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// APIKeyService issues API keys, authenticates requests with them and enforces their quotas
+type APIKeyService struct {
+	repo APIKeyRepository
+	now  func() time.Time
+}
+
+func NewAPIKeyService(repo APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo, now: time.Now}
+}
+
+// CreateAPIKey stores a new key and returns it together with its secret, the secret cannot be read again
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, data domain.NewAPIKeyData) (domain.APIKey, string, error) {
+	if err := validateAPIKey(&data); err != nil {
+		return domain.APIKey{}, "", err
+	}
+	secret, hash, prefix := domain.NewAPIKeySecret()
+	data.ID = domain.NewJobID()
+	data.Hash = hash
+	data.Prefix = prefix
+	data.CreatedAt = s.now().UTC()
+	data.RevokedAt = time.Time{}
+	key := domain.NewAPIKey(data)
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return domain.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id string) error {
+	return s.repo.RevokeAPIKey(ctx, id, s.now().UTC())
+}
+
+// Authenticate returns the active key with secret, unknown and revoked keys are domain.ErrUnauthorized
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (domain.APIKey, error) {
+	if secret == "" {
+		return domain.APIKey{}, domain.ErrUnauthorized
+	}
+	key, err := s.repo.FindAPIKeyByHash(ctx, domain.HashAPIKeySecret(secret))
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.APIKey{}, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	if key.IsRevoked() {
+		return domain.APIKey{}, domain.ErrUnauthorized
+	}
+	return key, nil
+}
+
+// ConsumeQuota counts one use of quota kind by key. Once the quota of the current window is spent
+// it returns *domain.QuotaExceededError telling when the window resets.
+func (s *APIKeyService) ConsumeQuota(ctx context.Context, key domain.APIKey, kind domain.QuotaKind) error {
+	limit := key.Quota(kind)
+	if limit <= 0 {
+		return nil
+	}
+	now := s.now().UTC()
+	windowStart := now.Truncate(kind.Window())
+	count, err := s.repo.IncrementUsage(ctx, key.GetID(), kind, windowStart)
+	if err != nil {
+		return err
+	}
+	if count > limit {
+		return &domain.QuotaExceededError{Kind: kind, RetryAfter: windowStart.Add(kind.Window()).Sub(now)}
+	}
+	return nil
+}
+
+func validateAPIKey(data *domain.NewAPIKeyData) error {
+	data.Name = strings.TrimSpace(data.Name)
+	if data.Name == "" || len(data.Name) > 128 {
+		return fmt.Errorf("%w: name must be between 1 and 128 characters", domain.ErrInvalidAPIKey)
+	}
+	if len(data.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", domain.ErrInvalidAPIKey)
+	}
+	for _, scope := range data.Scopes {
+		supported := false
+		for _, apiKeyScope := range domain.APIKeyScopes {
+			supported = supported || scope == apiKeyScope
+		}
+		if !supported {
+			return fmt.Errorf("%w: unsupported scope %q", domain.ErrInvalidAPIKey, scope)
+		}
+	}
+	if data.RequestQuota < 0 || data.EnqueueQuota < 0 {
+		return fmt.Errorf("%w: quotas must not be negative", domain.ErrInvalidAPIKey)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services/mocks"
+)
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          domain.NewAPIKeyData
+		mockSetup     func(repo *mocks.ApikeyRepository)
+		expectedError error
+	}{
+		{
+			name: "Should store hash of returned secret",
+			data: domain.NewAPIKeyData{Name: " ci ", Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}, RequestQuota: 60},
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(key domain.APIKey) bool {
+					return key.GetID() != "" && key.GetName() == "ci" && len(key.GetHash()) == 64 && key.GetRequestQuota() == 60
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name:          "Should reject empty name",
+			data:          domain.NewAPIKeyData{Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}},
+			mockSetup:     func(repo *mocks.ApikeyRepository) {},
+			expectedError: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:          "Should reject key without scopes",
+			data:          domain.NewAPIKeyData{Name: "ci"},
+			mockSetup:     func(repo *mocks.ApikeyRepository) {},
+			expectedError: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:          "Should reject unsupported scope",
+			data:          domain.NewAPIKeyData{Name: "ci", Scopes: []domain.APIKeyScope{"admin"}},
+			mockSetup:     func(repo *mocks.ApikeyRepository) {},
+			expectedError: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:          "Should reject negative quota",
+			data:          domain.NewAPIKeyData{Name: "ci", Scopes: []domain.APIKeyScope{domain.APIKeyScopeSubmit}, EnqueueQuota: -1},
+			mockSetup:     func(repo *mocks.ApikeyRepository) {},
+			expectedError: domain.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			repo := mocks.NewApikeyRepository(t)
+			tc.mockSetup(repo)
+			service := NewAPIKeyService(repo)
+
+			// When
+			key, secret, err := service.CreateAPIKey(context.Background(), tc.data)
+
+			// Then
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, domain.HashAPIKeySecret(secret), key.GetHash())
+			require.Equal(t, secret[:len(key.GetPrefix())], key.GetPrefix())
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	active := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-1", Hash: domain.HashAPIKeySecret("secret")})
+	revoked := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-2", RevokedAt: time.Now()})
+
+	testCases := []struct {
+		name          string
+		secret        string
+		mockSetup     func(repo *mocks.ApikeyRepository)
+		expectedKeyID string
+		expectedError error
+	}{
+		{
+			name:   "Should return active key",
+			secret: "secret",
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("FindAPIKeyByHash", mock.Anything, domain.HashAPIKeySecret("secret")).Return(active, nil)
+			},
+			expectedKeyID: "key-1",
+		},
+		{
+			name:          "Should reject missing secret",
+			secret:        "",
+			mockSetup:     func(repo *mocks.ApikeyRepository) {},
+			expectedError: domain.ErrUnauthorized,
+		},
+		{
+			name:   "Should reject unknown secret",
+			secret: "unknown",
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("FindAPIKeyByHash", mock.Anything, mock.Anything).Return(domain.APIKey{}, domain.ErrNotFound)
+			},
+			expectedError: domain.ErrUnauthorized,
+		},
+		{
+			name:   "Should reject revoked key",
+			secret: "revoked",
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("FindAPIKeyByHash", mock.Anything, mock.Anything).Return(revoked, nil)
+			},
+			expectedError: domain.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			repo := mocks.NewApikeyRepository(t)
+			tc.mockSetup(repo)
+			service := NewAPIKeyService(repo)
+
+			// When
+			key, err := service.Authenticate(context.Background(), tc.secret)
+
+			// Then
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedKeyID, key.GetID())
+		})
+	}
+}
+
+func TestAPIKeyService_ConsumeQuota(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 50, 0, time.UTC)
+	key := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-1", RequestQuota: 2, EnqueueQuota: 5})
+
+	testCases := []struct {
+		name               string
+		key                domain.APIKey
+		kind               domain.QuotaKind
+		mockSetup          func(repo *mocks.ApikeyRepository)
+		expectedRetryAfter time.Duration
+		expectedError      error
+	}{
+		{
+			name: "Should allow request within quota",
+			key:  key,
+			kind: domain.QuotaRequests,
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("IncrementUsage", mock.Anything, "key-1", domain.QuotaRequests, time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)).Return(2, nil)
+			},
+		},
+		{
+			name: "Should reject request over quota until the minute ends",
+			key:  key,
+			kind: domain.QuotaRequests,
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("IncrementUsage", mock.Anything, "key-1", domain.QuotaRequests, mock.Anything).Return(3, nil)
+			},
+			expectedRetryAfter: 10 * time.Second,
+		},
+		{
+			name: "Should reject enqueue over quota until the hour ends",
+			key:  key,
+			kind: domain.QuotaEnqueues,
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("IncrementUsage", mock.Anything, "key-1", domain.QuotaEnqueues, time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)).Return(6, nil)
+			},
+			expectedRetryAfter: 55*time.Minute + 10*time.Second,
+		},
+		{
+			name:      "Should not count unlimited quota",
+			key:       domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-2"}),
+			kind:      domain.QuotaEnqueues,
+			mockSetup: func(repo *mocks.ApikeyRepository) {},
+		},
+		{
+			name: "Should return repository error",
+			key:  key,
+			kind: domain.QuotaRequests,
+			mockSetup: func(repo *mocks.ApikeyRepository) {
+				repo.On("IncrementUsage", mock.Anything, "key-1", domain.QuotaRequests, mock.Anything).Return(0, errors.New("db is down"))
+			},
+			expectedError: errors.New("db is down"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			repo := mocks.NewApikeyRepository(t)
+			tc.mockSetup(repo)
+			service := NewAPIKeyService(repo)
+			service.now = func() time.Time { return now }
+
+			// When
+			err := service.ConsumeQuota(context.Background(), tc.key, tc.kind)
+
+			// Then
+			switch {
+			case tc.expectedError != nil:
+				require.EqualError(t, err, tc.expectedError.Error())
+			case tc.expectedRetryAfter > 0:
+				var quotaErr *domain.QuotaExceededError
+				require.ErrorAs(t, err, &quotaErr)
+				require.Equal(t, tc.kind, quotaErr.Kind)
+				require.Equal(t, tc.expectedRetryAfter, quotaErr.RetryAfter)
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
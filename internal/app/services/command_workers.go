@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultWorkers    = 4
+	DefaultJobTimeout = 5 * time.Minute
+)
+
+var (
+	errWorkersStopped    = errors.New("command workers are stopped")
+	errWorkersNotStarted = errors.New("command workers are not started")
+)
+
+// CommandWorkers consumes sol commands from the queue with a fixed number of goroutines,
+// so one slow sol does not hold up the rest of the queue
+type CommandWorkers struct {
+	service    LargestPictureService
+	queue      CommandQueue
+	workers    int
+	jobTimeout time.Duration
+	logger     zerolog.Logger
+
+	stopConsuming chan struct{}
+	stopOnce      sync.Once
+	cancelJobs    context.CancelCauseFunc
+	running       sync.WaitGroup
+	started       atomic.Bool
+	consumers     atomic.Int32
+
+	mu       sync.Mutex
+	inFlight map[*domain.SolCommand]struct{}
+}
+
+func NewCommandWorkers(service LargestPictureService, queue CommandQueue, workers int, jobTimeout time.Duration, logger zerolog.Logger) *CommandWorkers {
+	return &CommandWorkers{
+		service:       service,
+		queue:         queue,
+		workers:       max(1, workers),
+		jobTimeout:    jobTimeout,
+		logger:        logger,
+		stopConsuming: make(chan struct{}),
+		inFlight:      make(map[*domain.SolCommand]struct{}),
+	}
+}
+
+// Start runs the workers until ctx is done. Canceling ctx only stops taking new commands,
+// in-flight jobs keep running until they finish or Stop runs out of time.
+func (w *CommandWorkers) Start(ctx context.Context) {
+	jobsCtx, cancelJobs := context.WithCancelCause(context.WithoutCancel(ctx))
+	w.cancelJobs = cancelJobs
+	go func() {
+		<-ctx.Done()
+		w.stopOnce.Do(func() {
+			close(w.stopConsuming)
+		})
+	}()
+	messages := w.queue.GetMessage()
+	metrics.SetWorkers(w.workers)
+	for i := 0; i < w.workers; i++ {
+		w.running.Add(1)
+		w.consumers.Add(1)
+		go func() {
+			defer w.running.Done()
+			defer w.consumers.Add(-1)
+			w.consume(jobsCtx, messages)
+		}()
+	}
+	w.started.Store(true)
+}
+
+// Alive fails when a consumer goroutine has returned although the workers were not stopped,
+// e.g. because the queue closed its channel. The workers never restart them, the process has to be.
+func (w *CommandWorkers) Alive(ctx context.Context) error {
+	select {
+	case <-w.stopConsuming:
+		return nil
+	default:
+	}
+	if !w.started.Load() {
+		return errWorkersNotStarted
+	}
+	if running := int(w.consumers.Load()); running < w.workers {
+		return fmt.Errorf("%d of %d command consumers are running", running, w.workers)
+	}
+	return nil
+}
+
+func (w *CommandWorkers) consume(ctx context.Context, messages <-chan domain.CommandMessage) {
+	for {
+		select {
+		case <-w.stopConsuming:
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			select {
+			case <-w.stopConsuming:
+				// Received while stopping, leave it for another consumer
+				if err := message.Nack(true); err != nil {
+					w.logger.Error().Err(err).Msg("failed to requeue sol command")
+				}
+				metrics.CommandConsumed(metrics.ResultRequeued)
+				return
+			default:
+			}
+			w.handle(ctx, message)
+		}
+	}
+}
+
+// handle processes message in a consumer span, a child of the span that published it
+func (w *CommandWorkers) handle(ctx context.Context, message domain.CommandMessage) {
+	ctx, span := tracing.Tracer().Start(
+		tracing.Extract(ctx, message.GetHeaders()),
+		"command-queue consume",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+	)
+	defer span.End()
+
+	command, err := parseSolCommand(message.GetBody())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "malformed command")
+		logging.From(ctx, w.logger).Error().Err(err).Msg("failed to parse sol command")
+		// A malformed command never becomes valid, so it is dropped instead of redelivered
+		if err := message.Nack(false); err != nil {
+			logging.From(ctx, w.logger).Error().Err(err).Msg("failed to reject sol command")
+		}
+		metrics.CommandConsumed(metrics.ResultRejected)
+		return
+	}
+
+	span.SetAttributes(attribute.String("job.id", command.JobID), attribute.Int("sol", command.Sol))
+	if command.JobID != "" {
+		ctx = logging.WithJobID(ctx, command.JobID)
+	}
+	logger := logging.From(ctx, w.logger).With().Int("sol", command.Sol).Logger()
+
+	w.track(&command)
+	defer w.untrack(&command)
+
+	jobCtx, cancel := context.WithTimeout(ctx, w.jobTimeout)
+	defer cancel()
+	if err := w.service.processCommand(jobCtx, command); errors.Is(err, ErrJobInterrupted) {
+		if err := message.Nack(true); err != nil {
+			logger.Error().Err(err).Msg("failed to requeue sol command")
+		}
+		metrics.CommandConsumed(metrics.ResultRequeued)
+		return
+	}
+	if err := message.Ack(); err != nil {
+		logger.Error().Err(err).Msg("failed to ack sol command")
+	}
+	metrics.CommandConsumed(metrics.ResultOK)
+}
+
+func (w *CommandWorkers) track(command *domain.SolCommand) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.inFlight[command] = struct{}{}
+	metrics.WorkerStarted()
+}
+
+func (w *CommandWorkers) untrack(command *domain.SolCommand) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.inFlight, command)
+	metrics.WorkerFinished()
+}
+
+// InFlight returns the commands that are being processed right now, ordered by sol
+func (w *CommandWorkers) InFlight() []domain.SolCommand {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	commands := make([]domain.SolCommand, 0, len(w.inFlight))
+	for command := range w.inFlight {
+		commands = append(commands, *command)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Sol < commands[j].Sol
+	})
+	return commands
+}
+
+// Stop stops taking new commands and waits for in-flight jobs until ctx is done.
+// Jobs still running by then are canceled and their commands are nacked back to the queue.
+func (w *CommandWorkers) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() {
+		close(w.stopConsuming)
+	})
+	finished := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+	}
+	interrupted := w.InFlight()
+	if w.cancelJobs != nil {
+		w.cancelJobs(errWorkersStopped)
+	}
+	<-finished
+	return fmt.Errorf("%d jobs were interrupted and requeued: %v", len(interrupted), interrupted)
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients/models"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services/mocks"
+)
+
+// settlement records how a message was settled by the workers
+type settlement struct {
+	acked   bool
+	requeue bool
+}
+
+type recordingAcknowledger struct {
+	settled chan settlement
+}
+
+func (a recordingAcknowledger) Ack() error {
+	a.settled <- settlement{acked: true}
+	return nil
+}
+
+func (a recordingAcknowledger) Nack(requeue bool) error {
+	a.settled <- settlement{requeue: requeue}
+	return nil
+}
+
+func TestCommandWorkers(t *testing.T) {
+	testCases := []struct {
+		name               string
+		body               string
+		mockSetup          func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository)
+		stopTimeout        time.Duration
+		expectedSettlement settlement
+		expectedStopErr    bool
+	}{
+		{
+			name: "Should ack command once job is settled",
+			body: `{"job_id":"job-1","sol":123}`,
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository) {
+				repo.On("Exists", mock.Anything, 123).Return(true, nil).Once()
+				repo.On("FindLargestPictureBySol", mock.Anything, 123).Return(
+					domain.NewPicture(domain.NewPictureData{Sol: 123, Url: "http://example.com", Size: 1024}), nil,
+				).Once()
+				jobRepo.On("Update", mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+			stopTimeout:        time.Second,
+			expectedSettlement: settlement{acked: true},
+		},
+		{
+			name:               "Should drop malformed command",
+			body:               "not a sol",
+			mockSetup:          func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository) {},
+			stopTimeout:        time.Second,
+			expectedSettlement: settlement{requeue: false},
+		},
+		{
+			name: "Should requeue job still running when shutdown deadline passes",
+			body: `{"job_id":"job-2","sol":456}`,
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository) {
+				repo.On("Exists", mock.Anything, 456).Return(false, nil).Once()
+				apiClient.On("FindNasaPhotos", mock.Anything, 456).Return(
+					func(ctx context.Context, sol int) (models.NasaPhotos, error) {
+						<-ctx.Done()
+						return models.NasaPhotos{}, ctx.Err()
+					}, nil,
+				).Once()
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job domain.Job) bool {
+					return job.GetStatus() == domain.JobStatusRunning
+				})).Return(nil).Once()
+			},
+			stopTimeout:        50 * time.Millisecond,
+			expectedSettlement: settlement{requeue: true},
+			expectedStopErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockRepo := mocks.NewPictureRepository(t)
+			mockApi := mocks.NewNasaApiclient(t)
+			mockJobRepo := mocks.NewJobRepository(t)
+			mockQueue := mocks.NewCommandQueue(t)
+			mockWebhooks := mocks.NewWebhookNotifier(t)
+			mockWebhooks.On("Notify", mock.Anything, mock.Anything).Maybe()
+			tc.mockSetup(mockRepo, mockApi, mockJobRepo)
+
+			settled := make(chan settlement, 1)
+			messages := make(chan domain.CommandMessage, 1)
+			messages <- domain.NewCommandMessage([]byte(tc.body), recordingAcknowledger{settled: settled})
+			mockQueue.On("GetMessage").Return((<-chan domain.CommandMessage)(messages)).Once()
+
+			lps := NewLargestPictureService(mockQueue, mockRepo, mockApi, mockJobRepo, newTestJobEvents(), mockWebhooks, zerolog.Nop())
+			workers := NewCommandWorkers(lps, mockQueue, 2, time.Minute, zerolog.Nop())
+
+			// When
+			workers.Start(context.Background())
+			if tc.expectedStopErr {
+				// Let the worker pick up the command before stopping
+				require.Eventually(t, func() bool { return len(workers.InFlight()) == 1 }, time.Second, time.Millisecond)
+			} else {
+				require.Equal(t, tc.expectedSettlement, <-settled)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), tc.stopTimeout)
+			defer cancel()
+			stopErr := workers.Stop(ctx)
+
+			// Then
+			if tc.expectedStopErr {
+				require.Error(t, stopErr)
+				require.Equal(t, tc.expectedSettlement, <-settled)
+			} else {
+				require.NoError(t, stopErr)
+			}
+			require.Empty(t, workers.InFlight())
+		})
+	}
+}
+
+func TestCommandWorkers_Alive(t *testing.T) {
+	// Given
+	messages := make(chan domain.CommandMessage)
+	mockQueue := mocks.NewCommandQueue(t)
+	mockQueue.On("GetMessage").Return((<-chan domain.CommandMessage)(messages)).Once()
+	workers := NewCommandWorkers(LargestPictureService{}, mockQueue, 2, time.Minute, zerolog.Nop())
+	notStarted := workers.Alive(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	workers.Start(ctx)
+	started := workers.Alive(context.Background())
+
+	// When
+	close(messages)
+	require.Eventually(t, func() bool {
+		return workers.consumers.Load() == 0
+	}, time.Second, time.Millisecond)
+
+	// Then
+	require.ErrorIs(t, notStarted, errWorkersNotStarted)
+	require.NoError(t, started)
+	require.EqualError(t, workers.Alive(context.Background()), "0 of 2 command consumers are running")
+	cancel()
+	require.Eventually(t, func() bool {
+		return workers.Alive(context.Background()) == nil
+	}, time.Second, time.Millisecond, "stopping workers are not dead")
+}
@@ -4,8 +4,7 @@ package services
 
 import (
 	"context"
-
-	amqp "github.com/rabbitmq/amqp091-go"
+	"time"
 
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients/models"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
@@ -13,8 +12,18 @@ import (
 
 type PictureRepository interface {
 	FindLargestPictureBySol(ctx context.Context, sol int) (domain.Picture, error)
+	FindLargestPictures(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error)
 	Save(ctx context.Context, picture domain.Picture) error
 	Exists(ctx context.Context, sol int) (bool, error)
+	CountByCamera(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.CameraCount, error)
+}
+
+type JobRepository interface {
+	Create(ctx context.Context, job domain.Job) error
+	CreateWithCommand(ctx context.Context, job domain.Job, command domain.SolCommand, activeSince time.Time) error
+	Update(ctx context.Context, job domain.Job) error
+	FindByID(ctx context.Context, id string) (domain.Job, error)
+	FindActiveBySol(ctx context.Context, sol int, activeSince time.Time) (domain.Job, error)
 }
 
 type NasaAPIClient interface {
@@ -22,7 +31,46 @@ type NasaAPIClient interface {
 	FindPhotoSize(ctx *context.Context, imgUrl string) (int, error)
 }
 
-type RabbitMQClient interface {
-	PublishCommand(ctx context.Context, solCommand int) error
-	GetMessage() <-chan amqp.Delivery
+// CommandQueue is implemented by every command queue backend: RabbitMQ, in-memory and Postgres
+type CommandQueue interface {
+	PublishCommand(ctx context.Context, command domain.SolCommand) error
+	GetMessage() <-chan domain.CommandMessage
+}
+
+type OutboxRepository interface {
+	PublishPending(
+		ctx context.Context,
+		limit int,
+		publish func(ctx context.Context, message domain.OutboxMessage) error,
+	) (int, error)
+}
+
+type JobEventBroker interface {
+	Publish(event domain.JobEvent) domain.JobEvent
+	Subscribe(ctx context.Context, filter domain.JobEventFilter, lastEventID int64) <-chan domain.JobEvent
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	FindSubscriptionsByEventType(ctx context.Context, eventType domain.JobEventType) ([]domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]domain.WebhookDelivery, error)
+	EnqueueDeliveries(ctx context.Context, deliveries []domain.PendingWebhookDelivery) error
+	ClaimDueDelivery(ctx context.Context, now, lockedUntil time.Time) (domain.PendingWebhookDelivery, error)
+	RescheduleDelivery(ctx context.Context, id int64, attempts int, nextAttemptAt time.Time) error
+	FinishDelivery(ctx context.Context, id int64) error
+}
+
+type WebhookNotifier interface {
+	Notify(ctx context.Context, event domain.JobEvent)
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key domain.APIKey) error
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	FindAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) error
+	IncrementUsage(ctx context.Context, keyID string, kind domain.QuotaKind, windowStart time.Time) (int, error)
 }
@@ -2,35 +2,116 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/slugerrors"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
+// progressSteps is roughly how many times progress is stored and emitted per job
+const progressSteps = 20
+
+const jobTimedOutSlug = "job-timed-out"
+
+// DefaultCommandCoalesceWindow is how long after its last update an unfinished job absorbs new commands
+// for its sol. A job stuck for longer, e.g. because its worker crashed, no longer blocks the sol.
+const DefaultCommandCoalesceWindow = 15 * time.Minute
+
+// ErrJobInterrupted is returned when a job is stopped by shutdown and has to be redelivered
+var ErrJobInterrupted = errors.New("job was interrupted")
+
 type LargestPictureService struct {
-	rabbitMQClient RabbitMQClient
-	pictureRepo    PictureRepository
-	nasaAPIClient  NasaAPIClient
+	commandQueue  CommandQueue
+	pictureRepo   PictureRepository
+	nasaAPIClient NasaAPIClient
+	jobRepo       JobRepository
+	jobEvents     JobEventBroker
+	webhooks      WebhookNotifier
+	logger        zerolog.Logger
+
+	coalesceWindow time.Duration
 }
 
 func NewLargestPictureService(
-	rabbitMQClient RabbitMQClient,
+	commandQueue CommandQueue,
 	pictureRepo PictureRepository,
 	nasaApiClient NasaAPIClient,
+	jobRepo JobRepository,
+	jobEvents JobEventBroker,
+	webhooks WebhookNotifier,
+	logger zerolog.Logger,
 ) LargestPictureService {
 	return LargestPictureService{
-		rabbitMQClient: rabbitMQClient,
-		pictureRepo:    pictureRepo,
-		nasaAPIClient:  nasaApiClient,
+		commandQueue:  commandQueue,
+		pictureRepo:   pictureRepo,
+		nasaAPIClient: nasaApiClient,
+		jobRepo:       jobRepo,
+		jobEvents:     jobEvents,
+		webhooks:      webhooks,
+		logger:        logger,
+
+		coalesceWindow: DefaultCommandCoalesceWindow,
 	}
 }
-func (lps LargestPictureService) PublishCommand(ctx context.Context, sol int) error {
-	return lps.rabbitMQClient.PublishCommand(ctx, sol)
+
+// WithCommandCoalesceWindow sets how long after its last update an unfinished job absorbs new commands
+func (lps LargestPictureService) WithCommandCoalesceWindow(window time.Duration) LargestPictureService {
+	lps.coalesceWindow = window
+	return lps
+}
+
+// PublishCommand registers a new job for sol. The command is stored in the outbox
+// together with the job and published to the queue by OutboxRelay.
+// A sol that is already queued or running is coalesced into its existing job, unless that job
+// was not updated within the coalesce window.
+func (lps LargestPictureService) PublishCommand(ctx context.Context, sol int) (domain.Job, error) {
+	now := time.Now().UTC()
+	job := domain.NewQueuedJob(sol, now)
+	activeSince := now.Add(-lps.coalesceWindow)
+	err := lps.jobRepo.CreateWithCommand(ctx, job, domain.SolCommand{JobID: job.GetID(), Sol: sol}, activeSince)
+	if errors.Is(err, domain.ErrJobAlreadyActive) {
+		active, findErr := lps.jobRepo.FindActiveBySol(ctx, sol, activeSince)
+		if findErr == nil {
+			return active, nil
+		}
+		if !errors.Is(findErr, domain.ErrNotFound) {
+			return domain.Job{}, fmt.Errorf("failed to find active job: %w", findErr)
+		}
+		// The active job finished in the meantime, so sol needs a job of its own again
+		err = lps.jobRepo.CreateWithCommand(ctx, job, domain.SolCommand{JobID: job.GetID(), Sol: sol}, activeSince)
+	}
+	if err != nil {
+		return domain.Job{}, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	logging.From(logging.WithJobID(ctx, job.GetID()), lps.logger).Info().Int("sol", sol).Msg("command accepted")
+	lps.jobEvents.Publish(domain.JobEvent{
+		Type:      domain.JobEventQueued,
+		JobID:     job.GetID(),
+		Sol:       sol,
+		CreatedAt: job.GetCreatedAt(),
+	})
+	return job, nil
+}
+
+// SubscribeJobEvents streams job lifecycle events matching filter, resuming after lastEventID
+func (lps LargestPictureService) SubscribeJobEvents(ctx context.Context, filter domain.JobEventFilter, lastEventID int64) <-chan domain.JobEvent {
+	return lps.jobEvents.Subscribe(ctx, filter, lastEventID)
 }
 
 func (lps LargestPictureService) GetPictureBySol(ctx context.Context, sol int) (domain.Picture, error) {
@@ -46,47 +127,154 @@ func (lps LargestPictureService) GetPictureBySol(ctx context.Context, sol int) (
 	return pictureBySol, nil
 }
 
+// GetJob returns the job with id
+func (lps LargestPictureService) GetJob(ctx context.Context, id string) (domain.Job, error) {
+	job, err := lps.jobRepo.FindByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Job{}, domain.ErrNotFound
+	}
+	if err != nil {
+		return domain.Job{}, fmt.Errorf("failed to find job: %w", err)
+	}
+	return job, nil
+}
+
+// GetLeaderboard returns the largest pictures across all computed sols
+func (lps LargestPictureService) GetLeaderboard(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = domain.DefaultLeaderboardLimit
+	}
+	if filter.Limit > domain.MaxLeaderboardLimit {
+		filter.Limit = domain.MaxLeaderboardLimit
+	}
+	pictures, err := lps.pictureRepo.FindLargestPictures(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find largest pictures: %w", err)
+	}
+	return pictures, nil
+}
+
 func (lps LargestPictureService) CheckIfPictureExistsSaveIfNecessary(ctx context.Context, sol int) error {
+	_, err := lps.checkIfPictureExistsSaveIfNecessary(ctx, sol, nil)
+	return err
+}
+
+func (lps LargestPictureService) checkIfPictureExistsSaveIfNecessary(
+	ctx context.Context,
+	sol int,
+	onProgress func(sized, total int),
+) (domain.Picture, error) {
 	exists, err := lps.pictureRepo.Exists(ctx, sol)
 	if err != nil && !errors.Is(err, domain.ErrNotFound) {
-		return fmt.Errorf("failed to check if picture exists: %w", err)
+		return domain.Picture{}, slugerrors.NewUnknownError(
+			fmt.Sprintf("failed to check if picture exists: %v", err),
+			"could-not-check-picture",
+		)
 	}
 	if exists {
-		return fmt.Errorf("picture for sol %d already exists", sol)
+		return domain.Picture{}, fmt.Errorf("picture for sol %d: %w", sol, domain.ErrPictureAlreadyExists)
 	}
+	picture, err := lps.findLargestPictureViaAPI(ctx, sol, onProgress)
 	if err != nil {
-		return fmt.Errorf("failed to save picture: %w", err)
+		return domain.Picture{}, fmt.Errorf("failed to find largest picture via API: %w", err)
 	}
-	err = lps.findLargestPictureViaAPI(ctx, sol)
+	return picture, nil
+}
+
+// ComputeLargestPictures sizes every photo taken on sol and returns the top largest ones,
+// largest first, without touching the repository. top <= 0 returns all of them.
+func (lps LargestPictureService) ComputeLargestPictures(ctx context.Context, sol int, top int) ([]domain.Picture, error) {
+	pictures, err := lps.rankPicturesViaAPI(ctx, sol, nil)
 	if err != nil {
-		return fmt.Errorf("failed to find largest picture via API: %w", err)
+		return nil, err
+	}
+	if top > 0 && len(pictures) > top {
+		pictures = pictures[:top]
+	}
+	return pictures, nil
+}
+
+// SavePicture stores picture as the largest one for its sol
+func (lps LargestPictureService) SavePicture(ctx context.Context, picture domain.Picture) error {
+	if err := lps.pictureRepo.Save(ctx, picture); err != nil {
+		return slugerrors.NewUnknownError(
+			fmt.Sprintf("failed to save picture: %v", err),
+			"could-not-save-picture",
+		)
 	}
 	return nil
 }
 
-func (lps LargestPictureService) findLargestPictureViaAPI(ctx context.Context, sol int) error {
-	photos, err := lps.nasaAPIClient.FindNasaPhotos(ctx, sol)
+func (lps LargestPictureService) findLargestPictureViaAPI(
+	ctx context.Context,
+	sol int,
+	onProgress func(sized, total int),
+) (domain.Picture, error) {
+	pictures, err := lps.rankPicturesViaAPI(ctx, sol, onProgress)
 	if err != nil {
-		return fmt.Errorf("failed to find nasa photos: %w", err)
+		return domain.Picture{}, err
+	}
+	largestPicture := pictures[0]
+	if err := lps.SavePicture(ctx, largestPicture); err != nil {
+		return domain.Picture{}, err
 	}
+	return largestPicture, nil
+}
+
+// rankPicturesViaAPI sizes every photo taken on sol in parallel and sorts them largest first
+func (lps LargestPictureService) rankPicturesViaAPI(
+	ctx context.Context,
+	sol int,
+	onProgress func(sized, total int),
+) ([]domain.Picture, error) {
+	listingCtx, span := tracing.Tracer().Start(ctx, "FindNasaPhotos", trace.WithAttributes(attribute.Int("sol", sol)))
+	photos, err := lps.nasaAPIClient.FindNasaPhotos(listingCtx, sol)
+	span.SetAttributes(attribute.Int("photos", len(photos.Photos)))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, slugerrors.NewUnknownError(
+			fmt.Sprintf("failed to find nasa photos: %v", err),
+			"could-not-find-nasa-photos",
+		)
+	}
+	if len(photos.Photos) == 0 {
+		return nil, slugerrors.NewUnknownError(
+			fmt.Sprintf("no nasa photos were taken on sol %d", sol),
+			"no-nasa-photos-for-sol",
+		)
+	}
+	total := len(photos.Photos)
+	var sized atomic.Int64
 	g, currContext := errgroup.WithContext(ctx)
 	g.SetLimit(len(photos.Photos))
 	nasaPhotoChannels := make(chan domain.NewPictureData, len(photos.Photos))
 
 	for _, photo := range photos.Photos {
 		g.Go(func() error {
-			size, currError := lps.nasaAPIClient.FindPhotoSize(&currContext, photo.ImageSrc)
+			sizeCtx, span := tracing.Tracer().Start(currContext, "FindPhotoSize", trace.WithAttributes(attribute.String("photo.url", photo.ImageSrc)))
+			size, currError := lps.nasaAPIClient.FindPhotoSize(&sizeCtx, photo.ImageSrc)
+			span.SetAttributes(attribute.Int("photo.size", size))
+			tracing.End(span, currError)
 			if currError != nil {
 				return fmt.Errorf("findLargestPicture %w", currError)
 			}
+			sizedSoFar := int(sized.Add(1))
+			if onProgress != nil {
+				onProgress(sizedSoFar, total)
+			}
+			// earth_date is informational only, a malformed value must not fail the whole sol
+			earthDate, _ := time.Parse(time.DateOnly, photo.EarthDate)
 			currNasaPicture := domain.NewPictureData{
-				Size: size,
-				Sol:  sol,
-				Url:  photo.ImageSrc,
+				Size:      size,
+				Sol:       sol,
+				Url:       photo.ImageSrc,
+				Rover:     strings.ToLower(photo.Rover.Name),
+				Camera:    strings.ToUpper(photo.Camera.Name),
+				EarthDate: earthDate,
 			}
 			select {
 			case <-currContext.Done():
-				fmt.Println("error was called so context is done")
+				logging.From(ctx, lps.logger).Debug().Err(currContext.Err()).Int("sol", sol).Msg("photo sizing stopped")
 				return currContext.Err()
 			case nasaPhotoChannels <- currNasaPicture:
 				return nil
@@ -94,38 +282,153 @@ func (lps LargestPictureService) findLargestPictureViaAPI(ctx context.Context, s
 
 		})
 	}
-	if errorGroup := g.Wait(); errorGroup != nil {
-		return fmt.Errorf("errorGroup: %w", errorGroup)
+	errorGroup := g.Wait()
+	metrics.PhotosSized(int(sized.Load()))
+	if errorGroup != nil {
+		return nil, slugerrors.NewUnknownError(
+			fmt.Sprintf("errorGroup: %v", errorGroup),
+			"could-not-find-photo-size",
+		)
 	}
 	close(nasaPhotoChannels)
-	nasaPhotos := make([]domain.NewPictureData, len(photos.Photos))
+	nasaPhotos := make([]domain.NewPictureData, 0, len(photos.Photos))
 	for nasaPhoto := range nasaPhotoChannels {
 		nasaPhotos = append(nasaPhotos, nasaPhoto)
 	}
+	// Ties are broken by URL so the ranking is the same on every run
 	sort.Slice(nasaPhotos, func(i, j int) bool {
-		return nasaPhotos[i].Size >= nasaPhotos[j].Size
+		if nasaPhotos[i].Size != nasaPhotos[j].Size {
+			return nasaPhotos[i].Size > nasaPhotos[j].Size
+		}
+		return nasaPhotos[i].Url < nasaPhotos[j].Url
 	})
-	err = lps.pictureRepo.Save(ctx, domain.NewPicture(nasaPhotos[0]))
+	pictures := make([]domain.Picture, 0, len(nasaPhotos))
+	for _, nasaPhoto := range nasaPhotos {
+		pictures = append(pictures, domain.NewPicture(nasaPhoto))
+	}
+	return pictures, nil
+}
+
+// processCommand runs the largest picture calculation for a single queued job,
+// keeping the job record and event subscribers up to date. It returns ErrJobInterrupted
+// when ctx is canceled before the job is settled, the job is then left to be redelivered.
+func (lps LargestPictureService) processCommand(ctx context.Context, command domain.SolCommand) error {
+	now := time.Now().UTC()
+	job := domain.NewJob(domain.NewJobData{
+		ID:        command.JobID,
+		Sol:       command.Sol,
+		Status:    domain.JobStatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if command.JobID == "" {
+		// Commands published before jobs were introduced carry only the sol
+		job = domain.NewQueuedJob(command.Sol, now)
+		if err := lps.jobRepo.Create(ctx, job); err != nil {
+			logging.From(ctx, lps.logger).Error().Err(err).Int("sol", command.Sol).Msg("failed to create job")
+		}
+	}
+	ctx = logging.WithJobID(ctx, job.GetID())
+	logger := logging.From(ctx, lps.logger).With().Int("sol", command.Sol).Logger()
+	job.Start(time.Now().UTC())
+	lps.updateJob(ctx, job)
+
+	// Photos are sized concurrently, progressMu keeps the stored progress from going backwards
+	var progressMu sync.Mutex
+	picture, err := lps.checkIfPictureExistsSaveIfNecessary(ctx, command.Sol, func(sized, total int) {
+		if sized != total && sized%max(1, total/progressSteps) != 0 {
+			return
+		}
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		if sized <= job.GetPhotosSized() {
+			return
+		}
+		job.Progress(sized, total, time.Now().UTC())
+		lps.updateJob(ctx, job)
+		lps.jobEvents.Publish(domain.JobEvent{
+			Type:        domain.JobEventProgress,
+			JobID:       job.GetID(),
+			Sol:         job.GetSol(),
+			PhotosSized: sized,
+			PhotosTotal: total,
+			CreatedAt:   job.GetUpdatedAt(),
+		})
+	})
+	// The job that saved the picture already notified webhooks about it
+	alreadySaved := errors.Is(err, domain.ErrPictureAlreadyExists)
+	if alreadySaved {
+		picture, err = lps.pictureRepo.FindLargestPictureBySol(ctx, command.Sol)
+	}
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		logger.Warn().Err(err).Msg("job was interrupted")
+		metrics.JobFinished(metrics.OutcomeInterrupted, "")
+		return ErrJobInterrupted
+	}
+	// The outcome is recorded even when the job ran out of time
+	settleCtx := context.WithoutCancel(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to save picture: %w", err)
+		logger.Error().Err(err).Msg("failed to calculate largest picture")
+		slug := jobFailureSlug(err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			slug = jobTimedOutSlug
+		}
+		job.Fail(slug, time.Now().UTC())
+		metrics.JobFinished(metrics.OutcomeFailed, slug)
+		lps.updateJob(settleCtx, job)
+		failedEvent := lps.jobEvents.Publish(domain.JobEvent{
+			Type:      domain.JobEventFailed,
+			JobID:     job.GetID(),
+			Sol:       job.GetSol(),
+			Slug:      slug,
+			CreatedAt: job.GetUpdatedAt(),
+		})
+		lps.webhooks.Notify(settleCtx, failedEvent)
+		return nil
+	}
+
+	job.Complete(time.Now().UTC())
+	metrics.JobFinished(metrics.OutcomeCompleted, "")
+	logger.Info().Msg("job completed")
+	lps.updateJob(settleCtx, job)
+	// Subscribers are notified once the job is stored as completed, so they can read it right away
+	completedEvent := lps.jobEvents.Publish(domain.JobEvent{
+		Type:      domain.JobEventCompleted,
+		JobID:     job.GetID(),
+		Sol:       job.GetSol(),
+		Picture:   &picture,
+		CreatedAt: job.GetUpdatedAt(),
+	})
+	if !alreadySaved {
+		lps.webhooks.Notify(settleCtx, completedEvent)
 	}
 	return nil
 }
 
-func (lps LargestPictureService) StartListeningSolCommands(ctx context.Context) {
-	go func() {
-		for message := range lps.rabbitMQClient.GetMessage() {
-			strSol := string(message.Body)
-			solInt, err := strconv.Atoi(strSol)
-			if err != nil {
-				log.Printf("failed to convert string to int: %v", err)
-				return
-			}
-			err = lps.CheckIfPictureExistsSaveIfNecessary(ctx, solInt)
-			if err != nil {
-				log.Printf("failed to check if picture exists: %v", err)
-				return
-			}
-		}
-	}()
+func (lps LargestPictureService) updateJob(ctx context.Context, job domain.Job) {
+	if err := lps.jobRepo.Update(ctx, job); err != nil {
+		logging.From(ctx, lps.logger).Error().Err(err).Msg("failed to update job")
+	}
+}
+
+// parseSolCommand accepts JSON encoded commands as well as legacy plain sol numbers
+func parseSolCommand(body []byte) (domain.SolCommand, error) {
+	var command domain.SolCommand
+	if err := json.Unmarshal(body, &command); err == nil && command.Sol > 0 {
+		return command, nil
+	}
+	sol, err := strconv.Atoi(strings.TrimSpace(string(body)))
+	if err != nil {
+		return domain.SolCommand{}, fmt.Errorf("failed to convert string to int: %w", err)
+	}
+	return domain.SolCommand{Sol: sol}, nil
+}
+
+// jobFailureSlug extracts the slug reported to clients for a failed job
+func jobFailureSlug(err error) string {
+	var slugErr slugerrors.SlugError
+	if errors.As(err, &slugErr) {
+		return slugErr.Slug()
+	}
+	return "could-not-calculate-largest-picture"
 }
@@ -3,59 +3,166 @@ package services
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/clients/models"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/events"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services/mocks"
 )
 
 func TestLargestPictureService_PublishCommand(t *testing.T) {
+	// withinCoalesceWindow matches the cutoff below which unfinished jobs no longer absorb commands
+	withinCoalesceWindow := mock.MatchedBy(func(activeSince time.Time) bool {
+		age := time.Since(activeSince)
+		return age >= DefaultCommandCoalesceWindow && age < DefaultCommandCoalesceWindow+time.Minute
+	})
 	testCases := []struct {
-		name      string
-		sol       int
-		mockSetup func(m *mocks.RabbitMqclient)
+		name          string
+		sol           int
+		mockSetup     func(jobRepo *mocks.JobRepository)
+		expectedError bool
+		// coalescedJobID is set when the command joins an already active job
+		coalescedJobID string
 	}{
 		{
 			name: "Should publish command successfully",
 			sol:  123,
-			mockSetup: func(m *mocks.RabbitMqclient) {
-				m.On("PublishCommand", mock.Anything, 123).Return(nil).Once()
+			mockSetup: func(jobRepo *mocks.JobRepository) {
+				jobRepo.On("CreateWithCommand", mock.Anything,
+					mock.MatchedBy(func(job domain.Job) bool {
+						return job.GetSol() == 123 && job.GetStatus() == domain.JobStatusQueued
+					}),
+					mock.MatchedBy(func(command domain.SolCommand) bool {
+						return command.Sol == 123 && command.JobID != ""
+					}),
+					withinCoalesceWindow,
+				).Return(nil).Once()
 			},
 		},
 		{
 			name: "Should handle context cancellation before publishing",
 			sol:  456,
-			mockSetup: func(m *mocks.RabbitMqclient) {
+			mockSetup: func(jobRepo *mocks.JobRepository) {
 				// Simulate no call since context cancels
-				m.On("PublishCommand", mock.Anything, mock.Anything).Return(nil).Maybe()
+				jobRepo.On("CreateWithCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			},
+		},
+		{
+			name: "Should return error when job and command could not be stored",
+			sol:  321,
+			mockSetup: func(jobRepo *mocks.JobRepository) {
+				jobRepo.On("CreateWithCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db is down")).Once()
+			},
+			expectedError: true,
+		},
+		{
+			name: "Should coalesce command into active job of sol",
+			sol:  654,
+			mockSetup: func(jobRepo *mocks.JobRepository) {
+				jobRepo.On("CreateWithCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(domain.ErrJobAlreadyActive).Once()
+				jobRepo.On("FindActiveBySol", mock.Anything, 654, withinCoalesceWindow).Return(
+					domain.NewJob(domain.NewJobData{ID: "active-job", Sol: 654, Status: domain.JobStatusRunning}), nil,
+				).Once()
+			},
+			coalescedJobID: "active-job",
+		},
+		{
+			name: "Should create job when active job finished in the meantime",
+			sol:  655,
+			mockSetup: func(jobRepo *mocks.JobRepository) {
+				jobRepo.On("CreateWithCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(domain.ErrJobAlreadyActive).Once()
+				jobRepo.On("FindActiveBySol", mock.Anything, 655, mock.Anything).Return(domain.Job{}, domain.ErrNotFound).Once()
+				jobRepo.On("CreateWithCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			name: "Should return error when active job could not be found",
+			sol:  656,
+			mockSetup: func(jobRepo *mocks.JobRepository) {
+				jobRepo.On("CreateWithCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(domain.ErrJobAlreadyActive).Once()
+				jobRepo.On("FindActiveBySol", mock.Anything, 656, mock.Anything).Return(domain.Job{}, errors.New("db is down")).Once()
 			},
+			expectedError: true,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Given
-			mockRabbitMQ := mocks.NewRabbitMqclient(t)
-			tc.mockSetup(mockRabbitMQ)
-			lps := NewLargestPictureService(mockRabbitMQ, nil, nil)
+			mockJobRepo := mocks.NewJobRepository(t)
+			tc.mockSetup(mockJobRepo)
+			broker := newTestJobEvents()
+			lps := NewLargestPictureService(nil, nil, nil, mockJobRepo, broker, nil, zerolog.Nop())
 
 			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			queued := broker.Subscribe(ctx, domain.JobEventFilter{Sol: tc.sol}, 0)
 			if tc.name == "Should handle context cancellation before publishing" {
 				cancel() // Trigger context cancellation
 			}
 
 			// When
-			lps.PublishCommand(ctx, tc.sol)
+			job, err := lps.PublishCommand(ctx, tc.sol)
 
 			// Then
-			mockRabbitMQ.AssertExpectations(t)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.sol, job.GetSol())
+				if tc.coalescedJobID != "" {
+					require.Equal(t, tc.coalescedJobID, job.GetID())
+				} else if ctx.Err() == nil {
+					require.Equal(t, domain.JobEventQueued, (<-queued).Type)
+				}
+			}
+			mockJobRepo.AssertExpectations(t)
 		})
 	}
 }
 
+// jobEventStore keeps job events in memory in place of Postgres
+type jobEventStore struct {
+	mu     sync.Mutex
+	events []domain.JobEvent
+}
+
+func (s *jobEventStore) Append(ctx context.Context, event domain.JobEvent) (domain.JobEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.ID = int64(len(s.events) + 1)
+	s.events = append(s.events, event)
+	return event, nil
+}
+
+func (s *jobEventStore) ListAfter(ctx context.Context, afterID int64, filter domain.JobEventFilter, limit int) ([]domain.JobEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []domain.JobEvent
+	for _, event := range s.events {
+		if event.ID > afterID && filter.Matches(event) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (s *jobEventStore) LastID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.events)), nil
+}
+
+func newTestJobEvents() *events.StoreBroker {
+	return events.NewStoreBroker(&jobEventStore{}, time.Millisecond, zerolog.Nop())
+}
+
 func TestLargestPictureService_GetPictureBySol(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -105,7 +212,7 @@ func TestLargestPictureService_GetPictureBySol(t *testing.T) {
 			// Given
 			mockRepo := mocks.NewPictureRepository(t)
 			tc.mockSetup(mockRepo)
-			lps := NewLargestPictureService(nil, mockRepo, nil)
+			lps := NewLargestPictureService(nil, mockRepo, nil, nil, nil, nil, zerolog.Nop())
 
 			// When
 			result, err := lps.GetPictureBySol(context.Background(), tc.sol)
@@ -123,30 +230,82 @@ func TestLargestPictureService_GetPictureBySol(t *testing.T) {
 	}
 }
 
+func TestLargestPictureService_GetJob(t *testing.T) {
+	job := domain.NewJob(domain.NewJobData{ID: "job-123", Sol: 123, Status: domain.JobStatusRunning})
+	testCases := []struct {
+		name          string
+		mockSetup     func(m *mocks.JobRepository)
+		expectedError error
+		expectedJob   domain.Job
+	}{
+		{
+			name: "Should return job successfully",
+			mockSetup: func(m *mocks.JobRepository) {
+				m.On("FindByID", mock.Anything, "job-123").Return(job, nil).Once()
+			},
+			expectedJob: job,
+		},
+		{
+			name: "Should return not found error if job doesn't exist",
+			mockSetup: func(m *mocks.JobRepository) {
+				m.On("FindByID", mock.Anything, "job-123").Return(domain.Job{}, domain.ErrNotFound).Once()
+			},
+			expectedError: domain.ErrNotFound,
+		},
+		{
+			name: "Should return error on repository failure",
+			mockSetup: func(m *mocks.JobRepository) {
+				m.On("FindByID", mock.Anything, "job-123").Return(domain.Job{}, errors.New("connection refused")).Once()
+			},
+			expectedError: errors.New("failed to find job: connection refused"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockJobRepo := mocks.NewJobRepository(t)
+			tc.mockSetup(mockJobRepo)
+			lps := NewLargestPictureService(nil, nil, nil, mockJobRepo, nil, nil, zerolog.Nop())
+
+			// When
+			result, err := lps.GetJob(context.Background(), "job-123")
+
+			// Then
+			if tc.expectedError != nil {
+				require.EqualError(t, err, tc.expectedError.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedJob, result)
+			}
+		})
+	}
+}
+
 func TestLargestPictureService_CheckIfPictureExistsSaveIfNecessary(t *testing.T) {
 	testCases := []struct {
 		name      string
 		sol       int
-		mockSetup func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient)
+		mockSetup func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, webhooks *mocks.WebhookNotifier)
 	}{
 		{
 			name: "Should not save picture if it already exists",
 			sol:  123,
-			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient) {
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, webhooks *mocks.WebhookNotifier) {
 				repo.On("Exists", mock.Anything, 123).Return(true, nil).Once()
 			},
 		},
 		{
 			name: "Should handle unexpected repository error",
 			sol:  999,
-			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient) {
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, webhooks *mocks.WebhookNotifier) {
 				repo.On("Exists", mock.Anything, 999).Return(false, errors.New("repository error")).Once()
 			},
 		},
 		{
 			name: "Should call Nasa API and save the largest picture when it doesn't exist",
 			sol:  456,
-			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient) {
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, webhooks *mocks.WebhookNotifier) {
 				repo.On("Exists", mock.Anything, 456).Return(false, nil).Once()
 				apiClient.On("FindNasaPhotos", mock.Anything, 456).Return(models.NasaPhotos{
 					Photos: []models.NasaPhoto{
@@ -167,9 +326,10 @@ func TestLargestPictureService_CheckIfPictureExistsSaveIfNecessary(t *testing.T)
 			// Given
 			mockRepo := mocks.NewPictureRepository(t)
 			mockApi := mocks.NewNasaApiclient(t)
-			tc.mockSetup(mockRepo, mockApi)
+			mockWebhooks := mocks.NewWebhookNotifier(t)
+			tc.mockSetup(mockRepo, mockApi, mockWebhooks)
 
-			lps := NewLargestPictureService(nil, mockRepo, mockApi)
+			lps := NewLargestPictureService(nil, mockRepo, mockApi, nil, nil, mockWebhooks, zerolog.Nop())
 
 			// When
 			lps.CheckIfPictureExistsSaveIfNecessary(context.Background(), tc.sol)
@@ -177,6 +337,251 @@ func TestLargestPictureService_CheckIfPictureExistsSaveIfNecessary(t *testing.T)
 			// Then
 			mockRepo.AssertExpectations(t)
 			mockApi.AssertExpectations(t)
+			mockWebhooks.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLargestPictureService_GetLeaderboard(t *testing.T) {
+	testCases := []struct {
+		name          string
+		filter        domain.LeaderboardFilter
+		mockSetup     func(m *mocks.PictureRepository)
+		expectedError bool
+	}{
+		{
+			name:   "Should pass filter to repository",
+			filter: domain.LeaderboardFilter{Limit: 50, Rover: "curiosity"},
+			mockSetup: func(m *mocks.PictureRepository) {
+				m.On("FindLargestPictures", mock.Anything, domain.LeaderboardFilter{Limit: 50, Rover: "curiosity"}).
+					Return([]domain.Picture{}, nil).Once()
+			},
+		},
+		{
+			name:   "Should apply default limit when limit is not set",
+			filter: domain.LeaderboardFilter{},
+			mockSetup: func(m *mocks.PictureRepository) {
+				m.On("FindLargestPictures", mock.Anything, domain.LeaderboardFilter{Limit: domain.DefaultLeaderboardLimit}).
+					Return([]domain.Picture{}, nil).Once()
+			},
+		},
+		{
+			name:   "Should cap limit to the maximum",
+			filter: domain.LeaderboardFilter{Limit: 10_000},
+			mockSetup: func(m *mocks.PictureRepository) {
+				m.On("FindLargestPictures", mock.Anything, domain.LeaderboardFilter{Limit: domain.MaxLeaderboardLimit}).
+					Return([]domain.Picture{}, nil).Once()
+			},
+		},
+		{
+			name:   "Should return error on repository failure",
+			filter: domain.LeaderboardFilter{Limit: 5},
+			mockSetup: func(m *mocks.PictureRepository) {
+				m.On("FindLargestPictures", mock.Anything, mock.Anything).Return(nil, errors.New("repository error")).Once()
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockRepo := mocks.NewPictureRepository(t)
+			tc.mockSetup(mockRepo)
+			lps := NewLargestPictureService(nil, mockRepo, nil, nil, nil, nil, zerolog.Nop())
+
+			// When
+			_, err := lps.GetLeaderboard(context.Background(), tc.filter)
+
+			// Then
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLargestPictureService_ProcessCommand(t *testing.T) {
+	testCases := []struct {
+		name               string
+		command            domain.SolCommand
+		mockSetup          func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository, webhooks *mocks.WebhookNotifier)
+		expectedEventTypes []domain.JobEventType
+		expectedSlug       string
+	}{
+		{
+			name:    "Should complete job with the largest picture",
+			command: domain.SolCommand{JobID: "job-1", Sol: 456},
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository, webhooks *mocks.WebhookNotifier) {
+				repo.On("Exists", mock.Anything, 456).Return(false, nil).Once()
+				apiClient.On("FindNasaPhotos", mock.Anything, 456).Return(models.NasaPhotos{
+					Photos: []models.NasaPhoto{
+						{ImageSrc: "http://example1.com"},
+						{ImageSrc: "http://example2.com"},
+					},
+				}, nil).Once()
+				apiClient.On("FindPhotoSize", mock.Anything, "http://example1.com").Return(1024, nil).Once()
+				apiClient.On("FindPhotoSize", mock.Anything, "http://example2.com").Return(2048, nil).Once()
+				repo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job domain.Job) bool {
+					return job.GetID() == "job-1" && job.GetStatus() == domain.JobStatusRunning && job.GetPhotosSized() == 0
+				})).Return(nil).Once()
+				jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job domain.Job) bool {
+					return job.GetStatus() == domain.JobStatusRunning && job.GetPhotosSized() > 0 && job.GetPhotosTotal() == 2
+				})).Return(nil).Twice()
+				mock.InOrder(
+					jobRepo.On("Update", mock.Anything, mock.MatchedBy(func(job domain.Job) bool {
+						return job.GetID() == "job-1" && job.GetStatus() == domain.JobStatusCompleted && job.GetPhotosSized() == 2
+					})).Return(nil).Once(),
+					webhooks.On("Notify", mock.Anything, mock.MatchedBy(func(event domain.JobEvent) bool {
+						return event.Type == domain.JobEventCompleted && event.JobID == "job-1" && event.Picture.GetSize() == 2048
+					})).Once(),
+				)
+			},
+			expectedEventTypes: []domain.JobEventType{domain.JobEventProgress, domain.JobEventProgress, domain.JobEventCompleted},
+		},
+		{
+			name:    "Should complete job with stored picture without notifying webhooks when sol was already calculated",
+			command: domain.SolCommand{JobID: "job-2", Sol: 123},
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository, webhooks *mocks.WebhookNotifier) {
+				repo.On("Exists", mock.Anything, 123).Return(true, nil).Once()
+				repo.On("FindLargestPictureBySol", mock.Anything, 123).Return(
+					domain.NewPicture(domain.NewPictureData{Sol: 123, Url: "http://example.com", Size: 1024}), nil,
+				).Once()
+				jobRepo.On("Update", mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+			expectedEventTypes: []domain.JobEventType{domain.JobEventCompleted},
+		},
+		{
+			name:    "Should fail job with slug when NASA API is unavailable",
+			command: domain.SolCommand{JobID: "job-3", Sol: 789},
+			mockSetup: func(repo *mocks.PictureRepository, apiClient *mocks.NasaApiclient, jobRepo *mocks.JobRepository, webhooks *mocks.WebhookNotifier) {
+				repo.On("Exists", mock.Anything, 789).Return(false, nil).Once()
+				apiClient.On("FindNasaPhotos", mock.Anything, 789).Return(models.NasaPhotos{}, errors.New("timeout")).Once()
+				webhooks.On("Notify", mock.Anything, mock.MatchedBy(func(event domain.JobEvent) bool {
+					return event.Type == domain.JobEventFailed && event.Slug == "could-not-find-nasa-photos"
+				})).Once()
+				jobRepo.On("Update", mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+			expectedEventTypes: []domain.JobEventType{domain.JobEventFailed},
+			expectedSlug:       "could-not-find-nasa-photos",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockRepo := mocks.NewPictureRepository(t)
+			mockApi := mocks.NewNasaApiclient(t)
+			mockJobRepo := mocks.NewJobRepository(t)
+			mockWebhooks := mocks.NewWebhookNotifier(t)
+			tc.mockSetup(mockRepo, mockApi, mockJobRepo, mockWebhooks)
+			broker := newTestJobEvents()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			jobEvents := broker.Subscribe(ctx, domain.JobEventFilter{JobID: tc.command.JobID}, 0)
+
+			lps := NewLargestPictureService(nil, mockRepo, mockApi, mockJobRepo, broker, mockWebhooks, zerolog.Nop())
+
+			// When
+			lps.processCommand(ctx, tc.command)
+
+			// Then
+			var eventTypes []domain.JobEventType
+			for range tc.expectedEventTypes {
+				event := <-jobEvents
+				eventTypes = append(eventTypes, event.Type)
+				if event.Type == domain.JobEventFailed {
+					require.Equal(t, tc.expectedSlug, event.Slug)
+				}
+				if event.Type == domain.JobEventCompleted {
+					require.NotNil(t, event.Picture)
+				}
+			}
+			require.Equal(t, tc.expectedEventTypes, eventTypes)
+			mockRepo.AssertExpectations(t)
+			mockApi.AssertExpectations(t)
+			mockJobRepo.AssertExpectations(t)
+			mockWebhooks.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLargestPictureService_ComputeLargestPictures(t *testing.T) {
+	testCases := []struct {
+		name         string
+		top          int
+		mockSetup    func(apiClient *mocks.NasaApiclient)
+		expectedUrls []string
+		expectedSlug string
+	}{
+		{
+			name: "Should rank pictures largest first and keep top",
+			top:  2,
+			mockSetup: func(apiClient *mocks.NasaApiclient) {
+				apiClient.On("FindNasaPhotos", mock.Anything, 1000).Return(models.NasaPhotos{
+					Photos: []models.NasaPhoto{
+						{ImageSrc: "http://example1.com"},
+						{ImageSrc: "http://example2.com"},
+						{ImageSrc: "http://example3.com"},
+					},
+				}, nil).Once()
+				apiClient.On("FindPhotoSize", mock.Anything, "http://example1.com").Return(1024, nil).Once()
+				apiClient.On("FindPhotoSize", mock.Anything, "http://example2.com").Return(4096, nil).Once()
+				apiClient.On("FindPhotoSize", mock.Anything, "http://example3.com").Return(2048, nil).Once()
+			},
+			expectedUrls: []string{"http://example2.com", "http://example3.com"},
+		},
+		{
+			name: "Should break ties by url",
+			top:  0,
+			mockSetup: func(apiClient *mocks.NasaApiclient) {
+				apiClient.On("FindNasaPhotos", mock.Anything, 1000).Return(models.NasaPhotos{
+					Photos: []models.NasaPhoto{
+						{ImageSrc: "http://example2.com"},
+						{ImageSrc: "http://example1.com"},
+					},
+				}, nil).Once()
+				apiClient.On("FindPhotoSize", mock.Anything, mock.Anything).Return(1024, nil).Twice()
+			},
+			expectedUrls: []string{"http://example1.com", "http://example2.com"},
+		},
+		{
+			name: "Should return slug error when sol has no photos",
+			top:  5,
+			mockSetup: func(apiClient *mocks.NasaApiclient) {
+				apiClient.On("FindNasaPhotos", mock.Anything, 1000).Return(models.NasaPhotos{}, nil).Once()
+			},
+			expectedSlug: "no-nasa-photos-for-sol",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockApi := mocks.NewNasaApiclient(t)
+			tc.mockSetup(mockApi)
+			lps := NewLargestPictureService(nil, nil, mockApi, nil, nil, nil, zerolog.Nop())
+
+			// When
+			pictures, err := lps.ComputeLargestPictures(context.Background(), 1000, tc.top)
+
+			// Then
+			if tc.expectedSlug != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.expectedSlug, jobFailureSlug(err))
+				return
+			}
+			require.NoError(t, err)
+			var urls []string
+			for _, picture := range pictures {
+				urls = append(urls, picture.GetUrl())
+			}
+			require.Equal(t, tc.expectedUrls, urls)
 		})
 	}
 }
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultOutboxPollInterval = 500 * time.Millisecond
+	DefaultOutboxBatchSize    = 100
+)
+
+// OutboxRelay publishes commands stored in the outbox to the queue and marks them as sent.
+// Publishing is at-least-once: a crash between publish and commit re-sends the command.
+type OutboxRelay struct {
+	outboxRepo   OutboxRepository
+	publisher    CommandQueue
+	pollInterval time.Duration
+	batchSize    int
+	logger       zerolog.Logger
+	stopped      chan struct{}
+}
+
+func NewOutboxRelay(outboxRepo OutboxRepository, publisher CommandQueue, pollInterval time.Duration, batchSize int, logger zerolog.Logger) OutboxRelay {
+	return OutboxRelay{
+		outboxRepo:   outboxRepo,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		logger:       logger,
+		stopped:      make(chan struct{}),
+	}
+}
+
+// Start polls the outbox until ctx is done
+func (r OutboxRelay) Start(ctx context.Context) {
+	go func() {
+		defer close(r.stopped)
+		ticker := time.NewTicker(r.pollInterval)
+		defer ticker.Stop()
+		for {
+			r.drain(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits until the relay started by Start has returned after its ctx was canceled
+func (r OutboxRelay) Stop(ctx context.Context) error {
+	select {
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain publishes batches until the outbox is empty or publishing fails
+func (r OutboxRelay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := r.outboxRepo.PublishPending(ctx, r.batchSize, r.publish)
+		if err != nil {
+			r.logger.Error().Err(err).Int("published", published).Msg("outbox relay failed")
+			return
+		}
+		if published < r.batchSize {
+			return
+		}
+	}
+}
+
+// publish sends message to the queue in a producer span continuing the trace of the accepting request
+func (r OutboxRelay) publish(ctx context.Context, message domain.OutboxMessage) error {
+	ctx, span := tracing.Tracer().Start(
+		tracing.Extract(ctx, message.TraceContext),
+		"command-queue publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("job.id", message.Command.JobID), attribute.Int("sol", message.Command.Sol)),
+	)
+	err := r.publisher.PublishCommand(ctx, message.Command)
+	tracing.End(span, err)
+	metrics.CommandPublished(err)
+	if err == nil {
+		logging.From(logging.WithJobID(ctx, message.Command.JobID), r.logger).Debug().Int("sol", message.Command.Sol).Msg("command published")
+	}
+	return err
+}
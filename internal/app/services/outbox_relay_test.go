@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services/mocks"
+)
+
+// publishPendingWith makes the outbox mock hand messages to the relay publish callback
+func publishPendingWith(messages ...domain.OutboxMessage) func(ctx context.Context, limit int, publish func(context.Context, domain.OutboxMessage) error) (int, error) {
+	return func(ctx context.Context, limit int, publish func(context.Context, domain.OutboxMessage) error) (int, error) {
+		published := 0
+		for _, message := range messages {
+			if err := publish(ctx, message); err != nil {
+				return published, err
+			}
+			published++
+		}
+		return published, nil
+	}
+}
+
+func TestOutboxRelay_Drain(t *testing.T) {
+	first := domain.OutboxMessage{ID: 1, Command: domain.SolCommand{JobID: "job-1", Sol: 1}}
+	second := domain.OutboxMessage{ID: 2, Command: domain.SolCommand{JobID: "job-2", Sol: 2}}
+
+	testCases := []struct {
+		name      string
+		batchSize int
+		mockSetup func(outbox *mocks.OutboxRepository, publisher *mocks.CommandQueue)
+	}{
+		{
+			name:      "Should publish pending commands",
+			batchSize: 10,
+			mockSetup: func(outbox *mocks.OutboxRepository, publisher *mocks.CommandQueue) {
+				outbox.On("PublishPending", mock.Anything, 10, mock.Anything).Return(publishPendingWith(first, second)).Once()
+				publisher.On("PublishCommand", mock.Anything, first.Command).Return(nil).Once()
+				publisher.On("PublishCommand", mock.Anything, second.Command).Return(nil).Once()
+			},
+		},
+		{
+			name:      "Should keep draining while batches are full",
+			batchSize: 1,
+			mockSetup: func(outbox *mocks.OutboxRepository, publisher *mocks.CommandQueue) {
+				outbox.On("PublishPending", mock.Anything, 1, mock.Anything).Return(publishPendingWith(first)).Once()
+				outbox.On("PublishPending", mock.Anything, 1, mock.Anything).Return(publishPendingWith(second)).Once()
+				outbox.On("PublishPending", mock.Anything, 1, mock.Anything).Return(publishPendingWith()).Once()
+				publisher.On("PublishCommand", mock.Anything, first.Command).Return(nil).Once()
+				publisher.On("PublishCommand", mock.Anything, second.Command).Return(nil).Once()
+			},
+		},
+		{
+			name:      "Should stop draining when broker rejects a command",
+			batchSize: 1,
+			mockSetup: func(outbox *mocks.OutboxRepository, publisher *mocks.CommandQueue) {
+				outbox.On("PublishPending", mock.Anything, 1, mock.Anything).Return(publishPendingWith(first)).Once()
+				publisher.On("PublishCommand", mock.Anything, first.Command).Return(errors.New("broker is down")).Once()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockOutbox := mocks.NewOutboxRepository(t)
+			mockPublisher := mocks.NewCommandQueue(t)
+			tc.mockSetup(mockOutbox, mockPublisher)
+			relay := NewOutboxRelay(mockOutbox, mockPublisher, time.Hour, tc.batchSize, zerolog.Nop())
+
+			// When
+			relay.drain(context.Background())
+
+			// Then
+			mockOutbox.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services/mocks"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider keeping every ended span for the duration of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	_, err := tracing.Setup(context.Background(), nil, 1)
+	require.NoError(t, err)
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+	return recorder
+}
+
+func TestTracePropagation_FromOutboxToWorker(t *testing.T) {
+	// Given
+	recorder := recordSpans(t)
+	requestCtx, requestSpan := tracing.Tracer().Start(context.Background(), "POST /mars/pictures/largest/command")
+	message := domain.OutboxMessage{
+		ID:           1,
+		Command:      domain.SolCommand{JobID: "job-1", Sol: 1},
+		TraceContext: tracing.Inject(requestCtx),
+	}
+	requestSpan.End()
+
+	var headers map[string]string
+	publisher := mocks.NewCommandQueue(t)
+	publisher.On("PublishCommand", mock.Anything, message.Command).Run(func(args mock.Arguments) {
+		headers = tracing.Inject(args.Get(0).(context.Context))
+	}).Return(nil).Once()
+	relay := NewOutboxRelay(mocks.NewOutboxRepository(t), publisher, time.Hour, 1, zerolog.Nop())
+	workers := NewCommandWorkers(LargestPictureService{}, nil, 1, time.Minute, zerolog.Nop())
+	settled := make(chan settlement, 1)
+
+	// When
+	require.NoError(t, relay.publish(context.Background(), message))
+	// A malformed body is dropped right after the consumer span starts, no service is needed
+	workers.handle(context.Background(), domain.NewCommandMessage([]byte("not a sol"), recordingAcknowledger{settled: settled}).WithHeaders(headers))
+
+	// Then
+	require.Equal(t, settlement{requeue: false}, <-settled)
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	request, publish, consume := spans[0], spans[1], spans[2]
+	require.Equal(t, "command-queue publish", publish.Name())
+	require.Equal(t, "command-queue consume", consume.Name())
+	require.Equal(t, request.SpanContext().TraceID(), consume.SpanContext().TraceID())
+	require.Equal(t, request.SpanContext().SpanID(), publish.Parent().SpanID())
+	require.Equal(t, publish.SpanContext().SpanID(), consume.Parent().SpanID())
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+var errInternalWebhookAddress = errors.New("webhook address is internal")
+
+// WebhookResolver resolves webhook hosts, *net.Resolver implements it
+type WebhookResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NewWebhookClient returns an HTTP client that refuses to connect to loopback, private, link-local
+// and other internal addresses. The check runs on the address actually dialed, so a host that
+// resolves to a public address when subscribing and to an internal one later is still refused.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isInternalAddress(ip) {
+				return fmt.Errorf("%w: %s", errInternalWebhookAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the subscriber and bypass the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// validateWebhookHost rejects webhook urls whose host resolves to an internal address
+func validateWebhookHost(ctx context.Context, resolver WebhookResolver, webhookUrl *url.URL) error {
+	addresses, err := resolver.LookupIPAddr(ctx, webhookUrl.Hostname())
+	if err != nil || len(addresses) == 0 {
+		return fmt.Errorf("%w: url host %q could not be resolved", domain.ErrInvalidWebhook, webhookUrl.Hostname())
+	}
+	for _, address := range addresses {
+		if isInternalAddress(address.IP) {
+			return fmt.Errorf("%w: url host %q resolves to an internal address", domain.ErrInvalidWebhook, webhookUrl.Hostname())
+		}
+	}
+	return nil
+}
+
+func isInternalAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, not routable on the internet either
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+
+	defaultWebhookMaxAttempts  = 5
+	defaultWebhookBackoff      = time.Second
+	defaultWebhookPollInterval = time.Second
+	maxWebhookBackoff          = time.Minute
+	// webhookLease hides a claimed delivery from other workers, far longer than a single attempt takes
+	webhookLease = time.Minute
+)
+
+// WebhookPayload is the JSON body POSTed to subscribers
+type WebhookPayload struct {
+	Type      domain.JobEventType `json:"type"`
+	JobID     string              `json:"job_id,omitempty"`
+	Sol       int                 `json:"sol"`
+	ImgSrc    string              `json:"img_src,omitempty"`
+	Size      int                 `json:"size,omitempty"`
+	Slug      string              `json:"slug,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// WebhookService manages webhook subscriptions and delivers job events to them.
+// Deliveries are stored until they succeed or give up, signed with HMAC-SHA256 and
+// retried with exponential backoff.
+type WebhookService struct {
+	repo         WebhookRepository
+	client       *http.Client
+	maxAttempts  int
+	backoff      time.Duration
+	pollInterval time.Duration
+	resolver     WebhookResolver
+	logger       zerolog.Logger
+	running      sync.WaitGroup
+}
+
+func NewWebhookService(repo WebhookRepository, client *http.Client, logger zerolog.Logger) *WebhookService {
+	return &WebhookService{
+		repo:         repo,
+		client:       client,
+		maxAttempts:  defaultWebhookMaxAttempts,
+		backoff:      defaultWebhookBackoff,
+		pollInterval: defaultWebhookPollInterval,
+		resolver:     net.DefaultResolver,
+		logger:       logger,
+	}
+}
+
+// WithRetryPolicy overrides how many times and how quickly failed deliveries are retried
+func (s *WebhookService) WithRetryPolicy(maxAttempts int, backoff time.Duration) *WebhookService {
+	s.maxAttempts = maxAttempts
+	s.backoff = backoff
+	return s
+}
+
+// WithPollInterval overrides how often idle workers look for due deliveries
+func (s *WebhookService) WithPollInterval(pollInterval time.Duration) *WebhookService {
+	s.pollInterval = pollInterval
+	return s
+}
+
+// WithResolver overrides how subscription hosts are resolved to check they are not internal
+func (s *WebhookService) WithResolver(resolver WebhookResolver) *WebhookService {
+	s.resolver = resolver
+	return s
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, data domain.NewWebhookSubscriptionData) (domain.WebhookSubscription, error) {
+	if err := validateWebhookSubscription(ctx, s.resolver, &data); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	data.ID = domain.NewJobID()
+	data.CreatedAt = time.Now().UTC()
+	subscription := domain.NewWebhookSubscription(data)
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]domain.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = domain.DefaultWebhookDeliveriesLimit
+	}
+	if limit > domain.MaxWebhookDeliveriesLimit {
+		limit = domain.MaxWebhookDeliveriesLimit
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// Notify stores event for delivery to every interested subscription, workers started by Start deliver it
+func (s *WebhookService) Notify(ctx context.Context, event domain.JobEvent) {
+	logger := logging.From(ctx, s.logger).With().Str("event_type", string(event.Type)).Logger()
+	subscriptions, err := s.repo.FindSubscriptionsByEventType(ctx, event.Type)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to find webhook subscriptions")
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+	body, err := json.Marshal(toWebhookPayload(event))
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to marshal webhook payload")
+		return
+	}
+
+	now := time.Now().UTC()
+	deliveries := make([]domain.PendingWebhookDelivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, domain.PendingWebhookDelivery{
+			Subscription:  subscription,
+			EventType:     event.Type,
+			JobID:         event.JobID,
+			Body:          body,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	if err := s.repo.EnqueueDeliveries(ctx, deliveries); err != nil {
+		logger.Error().Err(err).Msg("failed to enqueue webhook deliveries")
+	}
+}
+
+// Start runs delivery workers until ctx is done. Every worker claims one due delivery at a time,
+// so a subscriber waiting for its next retry never holds up the others.
+func (s *WebhookService) Start(ctx context.Context, workers int) {
+	for i := 0; i < max(1, workers); i++ {
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			ticker := time.NewTicker(s.pollInterval)
+			defer ticker.Stop()
+			for ctx.Err() == nil {
+				if s.deliverDue(ctx) {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+}
+
+// Stop waits for the workers started by Start to return after their ctx was canceled.
+// Deliveries not made yet stay stored and are picked up again after a restart.
+func (s *WebhookService) Stop(ctx context.Context) error {
+	finished := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliverDue attempts the delivery due the longest and reports whether there was one
+func (s *WebhookService) deliverDue(ctx context.Context) bool {
+	now := time.Now().UTC()
+	pending, err := s.repo.ClaimDueDelivery(ctx, now, now.Add(webhookLease))
+	if errors.Is(err, domain.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.From(ctx, s.logger).Error().Err(err).Msg("failed to claim webhook delivery")
+		}
+		return false
+	}
+	s.deliver(ctx, pending)
+	return true
+}
+
+// deliver makes the next attempt of pending and then finishes or reschedules it
+func (s *WebhookService) deliver(ctx context.Context, pending domain.PendingWebhookDelivery) {
+	logger := logging.From(logging.WithJobID(ctx, pending.JobID), s.logger).With().
+		Str("subscription_id", pending.Subscription.GetID()).
+		Logger()
+	attempt := pending.Attempts + 1
+	delivery, retryable := s.attempt(ctx, pending, attempt)
+
+	// The outcome is recorded even when ctx was canceled meanwhile
+	settleCtx := context.WithoutCancel(ctx)
+	if ctx.Err() != nil {
+		// An attempt cut short by shutdown does not count, it is made again right after a restart
+		if err := s.repo.RescheduleDelivery(settleCtx, pending.ID, pending.Attempts, time.Now().UTC()); err != nil {
+			logger.Error().Err(err).Msg("failed to release webhook delivery")
+		}
+		return
+	}
+	if err := s.repo.SaveDelivery(settleCtx, delivery); err != nil {
+		logger.Error().Err(err).Msg("failed to save webhook delivery")
+	}
+	if delivery.Succeeded || !retryable || attempt >= s.maxAttempts {
+		if err := s.repo.FinishDelivery(settleCtx, pending.ID); err != nil {
+			logger.Error().Err(err).Msg("failed to finish webhook delivery")
+		}
+		return
+	}
+	nextAttemptAt := time.Now().UTC().Add(s.backoffAfter(attempt))
+	if err := s.repo.RescheduleDelivery(settleCtx, pending.ID, attempt, nextAttemptAt); err != nil {
+		logger.Error().Err(err).Msg("failed to reschedule webhook delivery")
+	}
+}
+
+// backoffAfter doubles the backoff with every failed attempt up to maxWebhookBackoff
+func (s *WebhookService) backoffAfter(attempt int) time.Duration {
+	backoff := s.backoff
+	for i := 1; i < attempt && backoff < maxWebhookBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxWebhookBackoff)
+}
+
+// attempt performs a single signed POST and reports whether a failure is worth retrying
+func (s *WebhookService) attempt(ctx context.Context, pending domain.PendingWebhookDelivery, attempt int) (domain.WebhookDelivery, bool) {
+	delivery := domain.WebhookDelivery{
+		SubscriptionID: pending.Subscription.GetID(),
+		EventType:      pending.EventType,
+		JobID:          pending.JobID,
+		Attempt:        attempt,
+		CreatedAt:      time.Now().UTC(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pending.Subscription.GetUrl(), bytes.NewReader(pending.Body))
+	if err != nil {
+		delivery.Error = fmt.Sprintf("failed to build request: %v", err)
+		return delivery, false
+	}
+	timestamp := strconv.FormatInt(delivery.CreatedAt.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(WebhookEventHeader, string(pending.EventType))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(pending.Subscription.GetSecret(), timestamp, pending.Body))
+
+	resp, err := s.client.Do(req)
+	delivery.Duration = time.Since(delivery.CreatedAt)
+	if err != nil {
+		delivery.Error = fmt.Sprintf("could not send request: %v", err)
+		return delivery, true
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.Succeeded = true
+		return delivery, false
+	}
+	delivery.Error = fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+	retryable := resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return delivery, retryable
+}
+
+// SignWebhook computes hex encoded HMAC-SHA256 of "<timestamp>.<body>" with secret
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func toWebhookPayload(event domain.JobEvent) WebhookPayload {
+	payload := WebhookPayload{
+		Type:      event.Type,
+		JobID:     event.JobID,
+		Sol:       event.Sol,
+		Slug:      event.Slug,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.Picture != nil {
+		payload.ImgSrc = event.Picture.GetUrl()
+		payload.Size = event.Picture.GetSize()
+	}
+	return payload
+}
+
+func validateWebhookSubscription(ctx context.Context, resolver WebhookResolver, data *domain.NewWebhookSubscriptionData) error {
+	parsedUrl, err := url.Parse(data.Url)
+	if err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", domain.ErrInvalidWebhook)
+	}
+	if len(data.Secret) < 16 {
+		return fmt.Errorf("%w: secret must be at least 16 characters", domain.ErrInvalidWebhook)
+	}
+	if len(data.EventTypes) == 0 {
+		data.EventTypes = domain.WebhookEventTypes
+	}
+	for _, eventType := range data.EventTypes {
+		supported := false
+		for _, webhookEventType := range domain.WebhookEventTypes {
+			supported = supported || eventType == webhookEventType
+		}
+		if !supported {
+			return fmt.Errorf("%w: unsupported event type %q", domain.ErrInvalidWebhook, eventType)
+		}
+	}
+	return validateWebhookHost(ctx, resolver, parsedUrl)
+}
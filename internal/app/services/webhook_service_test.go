@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/services/mocks"
+)
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          domain.NewWebhookSubscriptionData
+		mockSetup     func(repo *mocks.WebhookRepository)
+		expectedError error
+	}{
+		{
+			name: "Should create subscription with all event types by default",
+			data: domain.NewWebhookSubscriptionData{Url: "https://example.com/hook", Secret: "0123456789abcdef"},
+			mockSetup: func(repo *mocks.WebhookRepository) {
+				repo.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(sub domain.WebhookSubscription) bool {
+					return sub.GetID() != "" && len(sub.GetEventTypes()) == len(domain.WebhookEventTypes)
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name:          "Should reject relative url",
+			data:          domain.NewWebhookSubscriptionData{Url: "/hook", Secret: "0123456789abcdef"},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+		{
+			name:          "Should reject loopback address",
+			data:          domain.NewWebhookSubscriptionData{Url: "http://127.0.0.1:8080/hook", Secret: "0123456789abcdef"},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+		{
+			name:          "Should reject IPv6 loopback address",
+			data:          domain.NewWebhookSubscriptionData{Url: "http://[::1]/hook", Secret: "0123456789abcdef"},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+		{
+			name:          "Should reject link-local metadata address",
+			data:          domain.NewWebhookSubscriptionData{Url: "http://169.254.169.254/latest/meta-data", Secret: "0123456789abcdef"},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+		{
+			name:          "Should reject host resolving to a private address",
+			data:          domain.NewWebhookSubscriptionData{Url: "https://internal.example.com/hook", Secret: "0123456789abcdef"},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+		{
+			name:          "Should reject host that does not resolve",
+			data:          domain.NewWebhookSubscriptionData{Url: "https://unknown.example.com/hook", Secret: "0123456789abcdef"},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+		{
+			name:          "Should reject short secret",
+			data:          domain.NewWebhookSubscriptionData{Url: "https://example.com/hook", Secret: "short"},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+		{
+			name: "Should reject unsupported event type",
+			data: domain.NewWebhookSubscriptionData{
+				Url:        "https://example.com/hook",
+				Secret:     "0123456789abcdef",
+				EventTypes: []domain.JobEventType{domain.JobEventProgress},
+			},
+			mockSetup:     func(repo *mocks.WebhookRepository) {},
+			expectedError: domain.ErrInvalidWebhook,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockRepo := mocks.NewWebhookRepository(t)
+			tc.mockSetup(mockRepo)
+			webhookService := NewWebhookService(mockRepo, http.DefaultClient, zerolog.Nop()).WithResolver(fakeResolver{
+				"example.com":          {{IP: net.ParseIP("93.184.215.14")}},
+				"internal.example.com": {{IP: net.ParseIP("93.184.215.14")}, {IP: net.ParseIP("10.0.0.5")}},
+			})
+
+			// When
+			_, err := webhookService.CreateSubscription(context.Background(), tc.data)
+
+			// Then
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookService_ListDeliveries(t *testing.T) {
+	testCases := []struct {
+		name          string
+		limit         int
+		expectedLimit int
+	}{
+		{name: "Should pass limit through", limit: 5, expectedLimit: 5},
+		{name: "Should use default limit when none is given", limit: 0, expectedLimit: domain.DefaultWebhookDeliveriesLimit},
+		{name: "Should clamp limit to maximum", limit: 1000, expectedLimit: domain.MaxWebhookDeliveriesLimit},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			mockRepo := mocks.NewWebhookRepository(t)
+			mockRepo.On("ListDeliveries", mock.Anything, "sub-1", tc.expectedLimit).Return([]domain.WebhookDelivery{}, nil).Once()
+			webhookService := NewWebhookService(mockRepo, http.DefaultClient, zerolog.Nop())
+
+			// When
+			_, err := webhookService.ListDeliveries(context.Background(), "sub-1", tc.limit)
+
+			// Then
+			require.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookClient_RefusesInternalAddress(t *testing.T) {
+	// Given
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	client := NewWebhookClient(time.Second)
+
+	// When
+	_, err := client.Get(receiver.URL)
+
+	// Then
+	require.ErrorIs(t, err, errInternalWebhookAddress)
+}
+
+func TestIsInternalAddress(t *testing.T) {
+	testCases := []struct {
+		address  string
+		internal bool
+	}{
+		{address: "127.0.0.1", internal: true},
+		{address: "::1", internal: true},
+		{address: "10.1.2.3", internal: true},
+		{address: "172.16.0.1", internal: true},
+		{address: "192.168.1.1", internal: true},
+		{address: "169.254.169.254", internal: true},
+		{address: "100.64.0.1", internal: true},
+		{address: "0.0.0.0", internal: true},
+		{address: "fe80::1", internal: true},
+		{address: "fd00::1", internal: true},
+		{address: "::ffff:127.0.0.1", internal: true},
+		{address: "224.0.0.1", internal: true},
+		{address: "93.184.215.14", internal: false},
+		{address: "2606:2800:21f:cb07:6820:80da:af6b:8b2c", internal: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			// When
+			internal := isInternalAddress(net.ParseIP(tc.address))
+
+			// Then
+			require.Equal(t, tc.internal, internal)
+		})
+	}
+}
+
+// fakeResolver resolves the hosts it knows and IP literals, like net.Resolver does
+type fakeResolver map[string][]net.IPAddr
+
+func (r fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IPAddr{{IP: ip}}, nil
+	}
+	if addresses, ok := r[host]; ok {
+		return addresses, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestWebhookService_Notify(t *testing.T) {
+	// Given
+	subscription := domain.NewWebhookSubscription(domain.NewWebhookSubscriptionData{ID: "sub-1", EventTypes: domain.WebhookEventTypes})
+	mockRepo := mocks.NewWebhookRepository(t)
+	mockRepo.On("FindSubscriptionsByEventType", mock.Anything, domain.JobEventCompleted).
+		Return([]domain.WebhookSubscription{subscription}, nil).Once()
+	mockRepo.On("EnqueueDeliveries", mock.Anything, mock.MatchedBy(func(deliveries []domain.PendingWebhookDelivery) bool {
+		return len(deliveries) == 1 &&
+			deliveries[0].Subscription.GetID() == "sub-1" &&
+			deliveries[0].JobID == "job-1" &&
+			deliveries[0].Attempts == 0 &&
+			!deliveries[0].NextAttemptAt.IsZero() &&
+			string(deliveries[0].Body) == `{"type":"completed","job_id":"job-1","sol":1000,"img_src":"http://example.com/largest.jpg","size":2048,"created_at":"0001-01-01T00:00:00Z"}`
+	})).Return(nil).Once()
+	webhookService := NewWebhookService(mockRepo, http.DefaultClient, zerolog.Nop())
+	picture := domain.NewPicture(domain.NewPictureData{Sol: 1000, Url: "http://example.com/largest.jpg", Size: 2048})
+
+	// When
+	webhookService.Notify(context.Background(), domain.JobEvent{Type: domain.JobEventCompleted, JobID: "job-1", Sol: 1000, Picture: &picture})
+
+	// Then
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	testCases := []struct {
+		name             string
+		attempts         int
+		responseStatus   int
+		expectedDelivery domain.WebhookDelivery
+		// expectedBackoff is zero when the delivery is finished instead of rescheduled
+		expectedBackoff time.Duration
+	}{
+		{
+			name:             "Should deliver signed payload",
+			responseStatus:   http.StatusOK,
+			expectedDelivery: domain.WebhookDelivery{Attempt: 1, StatusCode: http.StatusOK, Succeeded: true},
+		},
+		{
+			name:             "Should schedule retry of server error with backoff",
+			responseStatus:   http.StatusInternalServerError,
+			expectedDelivery: domain.WebhookDelivery{Attempt: 1, StatusCode: http.StatusInternalServerError},
+			expectedBackoff:  time.Second,
+		},
+		{
+			name:             "Should double backoff with every attempt",
+			attempts:         2,
+			responseStatus:   http.StatusServiceUnavailable,
+			expectedDelivery: domain.WebhookDelivery{Attempt: 3, StatusCode: http.StatusServiceUnavailable},
+			expectedBackoff:  4 * time.Second,
+		},
+		{
+			name:             "Should not retry client errors",
+			responseStatus:   http.StatusBadRequest,
+			expectedDelivery: domain.WebhookDelivery{Attempt: 1, StatusCode: http.StatusBadRequest},
+		},
+		{
+			name:             "Should give up after max attempts",
+			attempts:         4,
+			responseStatus:   http.StatusBadGateway,
+			expectedDelivery: domain.WebhookDelivery{Attempt: 5, StatusCode: http.StatusBadGateway},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			const secret = "0123456789abcdef"
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				expectedSignature := "sha256=" + SignWebhook(secret, r.Header.Get(WebhookTimestampHeader), body)
+				if r.Header.Get(WebhookSignatureHeader) != expectedSignature || string(body) != `{"sol":1000}` {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(tc.responseStatus)
+			}))
+			defer receiver.Close()
+
+			pending := domain.PendingWebhookDelivery{
+				ID: 7,
+				Subscription: domain.NewWebhookSubscription(domain.NewWebhookSubscriptionData{
+					ID:     "sub-1",
+					Url:    receiver.URL,
+					Secret: secret,
+				}),
+				EventType: domain.JobEventCompleted,
+				JobID:     "job-1",
+				Body:      []byte(`{"sol":1000}`),
+				Attempts:  tc.attempts,
+			}
+			mockRepo := mocks.NewWebhookRepository(t)
+			mockRepo.On("SaveDelivery", mock.Anything, mock.MatchedBy(func(delivery domain.WebhookDelivery) bool {
+				return delivery.SubscriptionID == "sub-1" &&
+					delivery.JobID == "job-1" &&
+					delivery.Attempt == tc.expectedDelivery.Attempt &&
+					delivery.StatusCode == tc.expectedDelivery.StatusCode &&
+					delivery.Succeeded == tc.expectedDelivery.Succeeded
+			})).Return(nil).Once()
+			if tc.expectedBackoff == 0 {
+				mockRepo.On("FinishDelivery", mock.Anything, int64(7)).Return(nil).Once()
+			} else {
+				mockRepo.On("RescheduleDelivery", mock.Anything, int64(7), tc.expectedDelivery.Attempt, mock.MatchedBy(func(nextAttemptAt time.Time) bool {
+					backoff := time.Until(nextAttemptAt)
+					return backoff > tc.expectedBackoff-time.Second && backoff <= tc.expectedBackoff
+				})).Return(nil).Once()
+			}
+			webhookService := NewWebhookService(mockRepo, receiver.Client(), zerolog.Nop())
+
+			// When
+			webhookService.deliver(context.Background(), pending)
+
+			// Then
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookService_Start(t *testing.T) {
+	// Given
+	received := make(chan string, 2)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+	}))
+	defer receiver.Close()
+	pendingTo := func(id int64, path string) domain.PendingWebhookDelivery {
+		return domain.PendingWebhookDelivery{
+			ID:           id,
+			Subscription: domain.NewWebhookSubscription(domain.NewWebhookSubscriptionData{ID: path, Url: receiver.URL + "/" + path}),
+			EventType:    domain.JobEventFailed,
+			Body:         []byte(`{}`),
+		}
+	}
+
+	mockRepo := mocks.NewWebhookRepository(t)
+	mockRepo.On("ClaimDueDelivery", mock.Anything, mock.Anything, mock.Anything).Return(pendingTo(1, "first"), nil).Once()
+	mockRepo.On("ClaimDueDelivery", mock.Anything, mock.Anything, mock.Anything).Return(pendingTo(2, "second"), nil).Once()
+	mockRepo.On("ClaimDueDelivery", mock.Anything, mock.Anything, mock.Anything).Return(domain.PendingWebhookDelivery{}, domain.ErrNotFound)
+	mockRepo.On("SaveDelivery", mock.Anything, mock.Anything).Return(nil).Twice()
+	finished := make(chan int64, 2)
+	mockRepo.On("FinishDelivery", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { finished <- args.Get(1).(int64) }).
+		Return(nil).Twice()
+	webhookService := NewWebhookService(mockRepo, receiver.Client(), zerolog.Nop()).WithPollInterval(time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// When
+	webhookService.Start(ctx, 1)
+
+	// Then
+	var finishedIDs []int64
+	for range 2 {
+		select {
+		case id := <-finished:
+			finishedIDs = append(finishedIDs, id)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "delivery was not finished")
+		}
+	}
+	cancel()
+	require.NoError(t, webhookService.Stop(context.Background()))
+	require.Equal(t, []int64{1, 2}, finishedIDs)
+	require.Equal(t, "/first", <-received)
+	require.Equal(t, "/second", <-received)
+}
+
+func TestWebhookService_NotifyRepositoryError(t *testing.T) {
+	// Given
+	mockRepo := mocks.NewWebhookRepository(t)
+	mockRepo.On("FindSubscriptionsByEventType", mock.Anything, domain.JobEventFailed).
+		Return(nil, errors.New("db is down")).Once()
+	webhookService := NewWebhookService(mockRepo, http.DefaultClient, zerolog.Nop())
+
+	// When
+	webhookService.Notify(context.Background(), domain.JobEvent{Type: domain.JobEventFailed, Sol: 1})
+
+	// Then
+	mockRepo.AssertNotCalled(t, "EnqueueDeliveries", mock.Anything, mock.Anything)
+}
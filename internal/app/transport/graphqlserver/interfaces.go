@@ -0,0 +1,27 @@
+//go:generate mockery
+
+package graphqlserver
+
+import (
+	"context"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// PictureService reads computed pictures and jobs and submits commands
+type PictureService interface {
+	GetPictureBySol(ctx context.Context, sol int) (domain.Picture, error)
+	GetLeaderboard(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error)
+	GetJob(ctx context.Context, id string) (domain.Job, error)
+	PublishCommand(ctx context.Context, sol int) (domain.Job, error)
+}
+
+// CameraCounter breaks computed sols down by camera
+type CameraCounter interface {
+	CountByCamera(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.CameraCount, error)
+}
+
+// QuotaConsumer counts submitted commands against the quota of the API key that sent them
+type QuotaConsumer interface {
+	ConsumeQuota(ctx context.Context, key domain.APIKey, kind domain.QuotaKind) error
+}
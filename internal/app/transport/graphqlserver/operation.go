@@ -0,0 +1,64 @@
+package graphqlserver
+
+// IsMutation reports whether the GraphQL document query defines a mutation. It only scans for
+// the mutation keyword outside of selection sets, strings and comments, so a document it can't
+// make sense of is still rejected by the schema when executed. An operation or fragment named
+// mutation counts as one too, which errs on the side of requiring the submit scope.
+func IsMutation(query string) bool {
+	depth := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			i = skipString(query, i)
+		case c == '{':
+			depth++
+		case c == '}':
+			depth = max(0, depth-1)
+		case isNameStart(c):
+			start := i
+			for i+1 < len(query) && isNameContinue(query[i+1]) {
+				i++
+			}
+			if depth == 0 && query[start:i+1] == "mutation" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skipString returns the index of the closing quote of the string or block string starting at start
+func skipString(query string, start int) int {
+	if len(query) >= start+3 && query[start:start+3] == `"""` {
+		for i := start + 3; i < len(query); i++ {
+			switch {
+			case query[i] == '\\' && len(query) >= i+4 && query[i+1:i+4] == `"""`:
+				i += 3
+			case len(query) >= i+3 && query[i:i+3] == `"""`:
+				return i + 2
+			}
+		}
+		return len(query)
+	}
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case '"', '\n', '\r':
+			return i
+		}
+	}
+	return len(query)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,342 @@
+package graphqlserver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+const (
+	// lookupCost is charged for every resolver reading a single row
+	lookupCost = 1
+	// submitCommandCost is charged for every submitted command, so aliasing submitCommand
+	// enqueues only a handful of commands per request
+	submitCommandCost = 100
+)
+
+type rootResolver struct {
+	service PictureService
+	cameras CameraCounter
+	quota   QuotaConsumer
+}
+
+func (r *rootResolver) Sol(ctx context.Context, args struct{ Sol int32 }) (*solResolver, error) {
+	if args.Sol < 0 {
+		return nil, Error{Slug: "invalid-command", Message: "sol must not be negative"}
+	}
+	return &solResolver{root: r, sol: args.Sol}, nil
+}
+
+func (r *rootResolver) Pictures(ctx context.Context, args struct {
+	First  int32
+	Rover  *string
+	Camera *string
+	From   *string
+	To     *string
+}) ([]*pictureResolver, error) {
+	filter, err := toLeaderboardFilter(args.First, args.Rover, args.Camera, args.From, args.To)
+	if err != nil {
+		return nil, err
+	}
+	return r.leaderboard(ctx, filter)
+}
+
+func (r *rootResolver) Cameras(ctx context.Context, args struct {
+	Rover *string
+	From  *string
+	To    *string
+}) ([]*cameraResolver, error) {
+	filter, err := toLeaderboardFilter(domain.DefaultLeaderboardLimit, args.Rover, nil, args.From, args.To)
+	if err != nil {
+		return nil, err
+	}
+	return r.cameraBreakdown(ctx, filter)
+}
+
+func (r *rootResolver) Rover(args struct{ Name string }) *roverResolver {
+	return &roverResolver{root: r, name: strings.ToLower(args.Name)}
+}
+
+func (r *rootResolver) Job(ctx context.Context, args struct{ ID graphql.ID }) (*jobResolver, error) {
+	if err := charge(ctx, lookupCost); err != nil {
+		return nil, err
+	}
+	job, err := r.service.GetJob(ctx, string(args.ID))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, Error{Slug: "could-not-get-job", Message: "job could not be read"}
+	}
+	return &jobResolver{job: job}, nil
+}
+
+func (r *rootResolver) SubmitCommand(ctx context.Context, args struct{ Sol int32 }) (*jobResolver, error) {
+	if args.Sol <= 0 {
+		return nil, Error{Slug: "invalid-command", Message: "sol must be positive"}
+	}
+	if err := charge(ctx, submitCommandCost); err != nil {
+		return nil, err
+	}
+	if err := r.authorizeCommand(ctx); err != nil {
+		return nil, err
+	}
+	job, err := r.service.PublishCommand(ctx, int(args.Sol))
+	if err != nil {
+		return nil, Error{Slug: "could-not-publish-command", Message: "command could not be published"}
+	}
+	return &jobResolver{job: job}, nil
+}
+
+// authorizeCommand checks the submit scope and enqueue quota of the API key, requests pass when authentication is disabled
+func (r *rootResolver) authorizeCommand(ctx context.Context) error {
+	key, ok := server.APIKeyFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if !key.HasScope(domain.APIKeyScopeSubmit) {
+		return Error{Slug: "insufficient-scope", Message: "api key lacks the submit scope"}
+	}
+	if r.quota == nil {
+		return nil
+	}
+	err := r.quota.ConsumeQuota(ctx, key, domain.QuotaEnqueues)
+	var quotaErr *domain.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return Error{
+			Slug:       "enqueue-quota-exceeded",
+			Message:    "enqueue quota exceeded",
+			RetryAfter: int(math.Ceil(quotaErr.RetryAfter.Seconds())),
+		}
+	}
+	if err != nil {
+		return Error{Slug: "could-not-check-quota", Message: "quota could not be checked"}
+	}
+	return nil
+}
+
+// leaderboard charges one item per requested picture before reading them
+func (r *rootResolver) leaderboard(ctx context.Context, filter domain.LeaderboardFilter) ([]*pictureResolver, error) {
+	if err := charge(ctx, filter.Limit); err != nil {
+		return nil, err
+	}
+	pictures, err := r.service.GetLeaderboard(ctx, filter)
+	if err != nil {
+		return nil, Error{Slug: "could-not-get-leaderboard", Message: "leaderboard could not be read"}
+	}
+	result := make([]*pictureResolver, 0, len(pictures))
+	for _, picture := range pictures {
+		result = append(result, &pictureResolver{root: r, picture: picture})
+	}
+	return result, nil
+}
+
+func (r *rootResolver) cameraBreakdown(ctx context.Context, filter domain.LeaderboardFilter) ([]*cameraResolver, error) {
+	if err := charge(ctx, lookupCost); err != nil {
+		return nil, err
+	}
+	counts, err := r.cameras.CountByCamera(ctx, filter)
+	if err != nil {
+		return nil, Error{Slug: "could-not-count-cameras", Message: "camera breakdown could not be read"}
+	}
+	result := make([]*cameraResolver, 0, len(counts))
+	for _, count := range counts {
+		pictures := count.Pictures
+		result = append(result, &cameraResolver{root: r, name: count.Camera, rover: filter.Rover, pictures: &pictures})
+	}
+	return result, nil
+}
+
+type solResolver struct {
+	root *rootResolver
+	sol  int32
+}
+
+func (r *solResolver) Sol() int32 {
+	return r.sol
+}
+
+func (r *solResolver) Winner(ctx context.Context) (*pictureResolver, error) {
+	if err := charge(ctx, lookupCost); err != nil {
+		return nil, err
+	}
+	picture, err := r.root.service.GetPictureBySol(ctx, int(r.sol))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, Error{Slug: "could-not-get-picture", Message: "picture could not be read"}
+	}
+	return &pictureResolver{root: r.root, picture: picture}, nil
+}
+
+type roverResolver struct {
+	root *rootResolver
+	name string
+}
+
+func (r *roverResolver) Name() string {
+	return r.name
+}
+
+func (r *roverResolver) Pictures(ctx context.Context, args struct {
+	First  int32
+	Camera *string
+}) ([]*pictureResolver, error) {
+	filter, err := toLeaderboardFilter(args.First, &r.name, args.Camera, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return r.root.leaderboard(ctx, filter)
+}
+
+func (r *roverResolver) Cameras(ctx context.Context) ([]*cameraResolver, error) {
+	return r.root.cameraBreakdown(ctx, domain.LeaderboardFilter{Rover: r.name})
+}
+
+type pictureResolver struct {
+	root    *rootResolver
+	picture domain.Picture
+}
+
+func (r *pictureResolver) Sol() int32 {
+	return int32(r.picture.GetSol())
+}
+
+func (r *pictureResolver) ImgSrc() string {
+	return r.picture.GetUrl()
+}
+
+func (r *pictureResolver) Size() int32 {
+	return int32(r.picture.GetSize())
+}
+
+func (r *pictureResolver) Rover() *roverResolver {
+	return &roverResolver{root: r.root, name: r.picture.GetRover()}
+}
+
+func (r *pictureResolver) Camera() *cameraResolver {
+	return &cameraResolver{root: r.root, name: r.picture.GetCamera(), rover: r.picture.GetRover()}
+}
+
+func (r *pictureResolver) EarthDate() *string {
+	if r.picture.GetEarthDate().IsZero() {
+		return nil
+	}
+	earthDate := r.picture.GetEarthDate().Format(time.DateOnly)
+	return &earthDate
+}
+
+type cameraResolver struct {
+	root  *rootResolver
+	name  string
+	rover string
+	// pictures is known when the camera comes from a breakdown, otherwise it is counted on demand
+	pictures *int
+}
+
+func (r *cameraResolver) Name() string {
+	return r.name
+}
+
+func (r *cameraResolver) PictureCount(ctx context.Context) (int32, error) {
+	if r.pictures != nil {
+		return int32(*r.pictures), nil
+	}
+	counts, err := r.root.cameraBreakdown(ctx, domain.LeaderboardFilter{Rover: r.rover, Camera: r.name})
+	if err != nil {
+		return 0, err
+	}
+	for _, count := range counts {
+		if count.name == r.name {
+			return int32(*count.pictures), nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *cameraResolver) Largest(ctx context.Context) (*pictureResolver, error) {
+	pictures, err := r.root.leaderboard(ctx, domain.LeaderboardFilter{Limit: 1, Rover: r.rover, Camera: r.name})
+	if err != nil || len(pictures) == 0 {
+		return nil, err
+	}
+	return pictures[0], nil
+}
+
+type jobResolver struct {
+	job domain.Job
+}
+
+func (r *jobResolver) ID() graphql.ID {
+	return graphql.ID(r.job.GetID())
+}
+
+func (r *jobResolver) Sol() int32 {
+	return int32(r.job.GetSol())
+}
+
+func (r *jobResolver) Status() string {
+	return strings.ToUpper(string(r.job.GetStatus()))
+}
+
+func (r *jobResolver) PhotosSized() int32 {
+	return int32(r.job.GetPhotosSized())
+}
+
+func (r *jobResolver) PhotosTotal() int32 {
+	return int32(r.job.GetPhotosTotal())
+}
+
+func (r *jobResolver) ErrorSlug() *string {
+	if r.job.GetErrorSlug() == "" {
+		return nil
+	}
+	errorSlug := r.job.GetErrorSlug()
+	return &errorSlug
+}
+
+func (r *jobResolver) CreatedAt() string {
+	return r.job.GetCreatedAt().Format(time.RFC3339)
+}
+
+func (r *jobResolver) UpdatedAt() string {
+	return r.job.GetUpdatedAt().Format(time.RFC3339)
+}
+
+// toLeaderboardFilter validates list arguments the same way the HTTP leaderboard does
+func toLeaderboardFilter(first int32, rover, camera, from, to *string) (domain.LeaderboardFilter, error) {
+	invalid := func(message string) error {
+		return Error{Slug: "invalid-leaderboard-query", Message: message}
+	}
+	if first <= 0 || first > domain.MaxLeaderboardLimit {
+		return domain.LeaderboardFilter{}, invalid("first must be between 1 and 100")
+	}
+	filter := domain.LeaderboardFilter{Limit: int(first)}
+	if rover != nil {
+		filter.Rover = strings.ToLower(*rover)
+	}
+	if camera != nil {
+		filter.Camera = strings.ToUpper(*camera)
+	}
+	var err error
+	if from != nil {
+		if filter.From, err = time.Parse(time.DateOnly, *from); err != nil {
+			return domain.LeaderboardFilter{}, invalid("from must be formatted as YYYY-MM-DD")
+		}
+	}
+	if to != nil {
+		if filter.To, err = time.Parse(time.DateOnly, *to); err != nil {
+			return domain.LeaderboardFilter{}, invalid("to must be formatted as YYYY-MM-DD")
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return domain.LeaderboardFilter{}, invalid("from must not be after to")
+	}
+	return filter, nil
+}
@@ -0,0 +1,119 @@
+package graphqlserver
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+)
+
+const (
+	// DefaultMaxDepth bounds how deeply selections may be nested
+	DefaultMaxDepth = 6
+	// DefaultMaxComplexity is the cost budget of one request, see complexityBudget
+	DefaultMaxComplexity = 500
+	// MaxRequestBytes bounds the JSON body of one request
+	MaxRequestBytes = 64 << 10
+)
+
+//go:embed schema.graphql
+var schemaDefinition string
+
+// GraphqlServer answers GraphQL queries over the picture service and repository
+type GraphqlServer struct {
+	root          *rootResolver
+	schema        *graphql.Schema
+	maxComplexity int64
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func NewGraphqlServer(service PictureService, cameras CameraCounter, maxDepth int, maxComplexity int) (GraphqlServer, error) {
+	root := &rootResolver{service: service, cameras: cameras}
+	schema, err := graphql.ParseSchema(
+		schemaDefinition,
+		root,
+		graphql.MaxDepth(maxDepth),
+		graphql.UseStringDescriptions(),
+	)
+	if err != nil {
+		return GraphqlServer{}, fmt.Errorf("failed to parse graphql schema: %w", err)
+	}
+	return GraphqlServer{root: root, schema: schema, maxComplexity: int64(maxComplexity)}, nil
+}
+
+// WithEnqueueQuota makes submitCommand count against the enqueue quota of the authenticated API key
+func (s GraphqlServer) WithEnqueueQuota(quota QuotaConsumer) GraphqlServer {
+	s.root.quota = quota
+	return s
+}
+
+// ServeHTTP executes a GraphQL request posted as JSON. Query errors are reported in the
+// errors list with their slug in extensions, like every GraphQL server the status is 200.
+func (s GraphqlServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request graphqlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBytes)).Decode(&request); err != nil {
+		server.BadRequest("invalid-graphql-request", err, w, r)
+		return
+	}
+	ctx := withComplexityBudget(r.Context(), s.maxComplexity)
+	response := s.schema.Exec(ctx, request.Query, request.OperationName, request.Variables)
+	server.RespondJSON(response, w)
+}
+
+// Error is a resolver error carrying the same slug as the HTTP API
+type Error struct {
+	Slug    string
+	Message string
+	// RetryAfter tells in seconds when a quota resets
+	RetryAfter int
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+// Extensions exposes the slug to clients
+func (e Error) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"slug": e.Slug}
+	if e.RetryAfter > 0 {
+		extensions["retry_after"] = e.RetryAfter
+	}
+	return extensions
+}
+
+type complexityBudgetKey struct{}
+
+// complexityBudget is spent by resolvers that read data: a list costs the number of items
+// it asks for, a single lookup costs one and a submitted command costs submitCommandCost. Nested lists are charged for every parent,
+// so a request fanning out over the whole table runs out of budget.
+type complexityBudget struct {
+	remaining atomic.Int64
+}
+
+func withComplexityBudget(ctx context.Context, limit int64) context.Context {
+	budget := &complexityBudget{}
+	budget.remaining.Store(limit)
+	return context.WithValue(ctx, complexityBudgetKey{}, budget)
+}
+
+// charge spends cost from the request budget and fails once it is exhausted
+func charge(ctx context.Context, cost int) error {
+	budget, ok := ctx.Value(complexityBudgetKey{}).(*complexityBudget)
+	if !ok {
+		return nil
+	}
+	if budget.remaining.Add(-int64(cost)) < 0 {
+		return Error{Slug: "query-too-complex", Message: "query exceeds the complexity limit"}
+	}
+	return nil
+}
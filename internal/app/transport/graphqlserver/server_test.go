@@ -0,0 +1,399 @@
+package graphqlserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/graphqlserver/mocks"
+)
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postQuery(t *testing.T, server GraphqlServer, body string) (int, graphqlResponse) {
+	t.Helper()
+	return postQueryWithContext(t, context.Background(), server, body)
+}
+
+func postQueryWithContext(t *testing.T, ctx context.Context, server GraphqlServer, body string) (int, graphqlResponse) {
+	t.Helper()
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/graphql", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+
+	var response graphqlResponse
+	if rr.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	}
+	return rr.Code, response
+}
+
+func query(t *testing.T, q string, variables map[string]interface{}) string {
+	t.Helper()
+	body, err := json.Marshal(graphqlRequest{Query: q, Variables: variables})
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestGraphqlServer_ServeHTTP(t *testing.T) {
+	earthDate := time.Date(2015, 6, 3, 0, 0, 0, 0, time.UTC)
+	picture := domain.NewPicture(domain.NewPictureData{
+		Size: 2048, Sol: 1000, Url: "https://mars.nasa.gov/1000.jpg", Rover: "curiosity", Camera: "MAST", EarthDate: earthDate,
+	})
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	job := domain.NewJob(domain.NewJobData{
+		ID: "job-1", Sol: 1000, Status: domain.JobStatusRunning, PhotosSized: 3, PhotosTotal: 10, CreatedAt: createdAt, UpdatedAt: createdAt,
+	})
+
+	testCases := []struct {
+		name          string
+		body          string
+		mockSetup     func(service *mocks.PictureService, cameras *mocks.CameraCounter)
+		expectedCode  int
+		expectedData  string
+		expectedSlugs []string
+	}{
+		{
+			name: "Should return winner of sol",
+			body: query(t, `{ sol(sol: 1000) { sol winner { imgSrc size earthDate rover { name } camera { name } } } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("GetPictureBySol", mock.Anything, 1000).Return(picture, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"sol":{"sol":1000,"winner":{"imgSrc":"https://mars.nasa.gov/1000.jpg","size":2048,"earthDate":"2015-06-03","rover":{"name":"curiosity"},"camera":{"name":"MAST"}}}}`,
+		},
+		{
+			name: "Should return null winner when sol is not computed",
+			body: query(t, `{ sol(sol: 7) { sol winner { size } } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("GetPictureBySol", mock.Anything, 7).Return(domain.Picture{}, domain.ErrNotFound)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"sol":{"sol":7,"winner":null}}`,
+		},
+		{
+			name: "Should report slug when picture could not be read",
+			body: query(t, `{ sol(sol: 7) { winner { size } } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("GetPictureBySol", mock.Anything, 7).Return(domain.Picture{}, errors.New("db is down"))
+			},
+			expectedCode:  http.StatusOK,
+			expectedSlugs: []string{"could-not-get-picture"},
+		},
+		{
+			name: "Should list pictures with filters",
+			body: query(t, `query($first: Int) { pictures(first: $first, rover: "Curiosity", camera: "mast", from: "2015-01-01") { sol } }`,
+				map[string]interface{}{"first": 5}),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("GetLeaderboard", mock.Anything, domain.LeaderboardFilter{
+					Limit: 5, Rover: "curiosity", Camera: "MAST", From: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+				}).Return([]domain.Picture{picture}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"pictures":[{"sol":1000}]}`,
+		},
+		{
+			name:          "Should reject too many pictures",
+			body:          query(t, `{ pictures(first: 101) { sol } }`, nil),
+			mockSetup:     func(service *mocks.PictureService, cameras *mocks.CameraCounter) {},
+			expectedCode:  http.StatusOK,
+			expectedSlugs: []string{"invalid-leaderboard-query"},
+		},
+		{
+			name:          "Should reject malformed date",
+			body:          query(t, `{ pictures(to: "06/03/2015") { sol } }`, nil),
+			mockSetup:     func(service *mocks.PictureService, cameras *mocks.CameraCounter) {},
+			expectedCode:  http.StatusOK,
+			expectedSlugs: []string{"invalid-leaderboard-query"},
+		},
+		{
+			name: "Should break rover down by camera",
+			body: query(t, `{ rover(name: "Curiosity") { name cameras { name pictureCount } } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				cameras.On("CountByCamera", mock.Anything, domain.LeaderboardFilter{Rover: "curiosity"}).Return(
+					[]domain.CameraCount{{Camera: "MAST", Pictures: 4}, {Camera: "NAVCAM", Pictures: 1}}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"rover":{"name":"curiosity","cameras":[{"name":"MAST","pictureCount":4},{"name":"NAVCAM","pictureCount":1}]}}`,
+		},
+		{
+			name: "Should count pictures of camera reached through picture",
+			body: query(t, `{ sol(sol: 1000) { winner { camera { name pictureCount } } } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("GetPictureBySol", mock.Anything, 1000).Return(picture, nil)
+				cameras.On("CountByCamera", mock.Anything, domain.LeaderboardFilter{Rover: "curiosity", Camera: "MAST"}).Return(
+					[]domain.CameraCount{{Camera: "MAST", Pictures: 4}}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"sol":{"winner":{"camera":{"name":"MAST","pictureCount":4}}}}`,
+		},
+		{
+			name: "Should return job",
+			body: query(t, `{ job(id: "job-1") { id sol status photosSized photosTotal errorSlug createdAt } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("GetJob", mock.Anything, "job-1").Return(job, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"job":{"id":"job-1","sol":1000,"status":"RUNNING","photosSized":3,"photosTotal":10,"errorSlug":null,"createdAt":"2024-01-02T03:04:05Z"}}`,
+		},
+		{
+			name: "Should return null when job does not exist",
+			body: query(t, `{ job(id: "missing") { id } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("GetJob", mock.Anything, "missing").Return(domain.Job{}, domain.ErrNotFound)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"job":null}`,
+		},
+		{
+			name: "Should submit command",
+			body: query(t, `mutation { submitCommand(sol: 1000) { id status } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("PublishCommand", mock.Anything, 1000).Return(
+					domain.NewJob(domain.NewJobData{ID: "job-2", Sol: 1000, Status: domain.JobStatusQueued}), nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedData: `{"submitCommand":{"id":"job-2","status":"QUEUED"}}`,
+		},
+		{
+			name:          "Should reject non positive sol in command",
+			body:          query(t, `mutation { submitCommand(sol: 0) { id } }`, nil),
+			mockSetup:     func(service *mocks.PictureService, cameras *mocks.CameraCounter) {},
+			expectedCode:  http.StatusOK,
+			expectedSlugs: []string{"invalid-command"},
+		},
+		{
+			name: "Should report slug when command could not be published",
+			body: query(t, `mutation { submitCommand(sol: 5) { id } }`, nil),
+			mockSetup: func(service *mocks.PictureService, cameras *mocks.CameraCounter) {
+				service.On("PublishCommand", mock.Anything, 5).Return(domain.Job{}, errors.New("broker is down"))
+			},
+			expectedCode:  http.StatusOK,
+			expectedSlugs: []string{"could-not-publish-command"},
+		},
+		{
+			name:         "Should reject malformed request body",
+			body:         `{"query":`,
+			mockSetup:    func(service *mocks.PictureService, cameras *mocks.CameraCounter) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			service := mocks.NewPictureService(t)
+			cameras := mocks.NewCameraCounter(t)
+			tc.mockSetup(service, cameras)
+			server, err := NewGraphqlServer(service, cameras, DefaultMaxDepth, DefaultMaxComplexity)
+			require.NoError(t, err)
+
+			// When
+			code, response := postQuery(t, server, tc.body)
+
+			// Then
+			require.Equal(t, tc.expectedCode, code)
+			if tc.expectedData != "" {
+				require.Empty(t, response.Errors)
+				require.JSONEq(t, tc.expectedData, string(response.Data))
+			}
+			var slugs []string
+			for _, e := range response.Errors {
+				slugs = append(slugs, e.Extensions["slug"].(string))
+			}
+			require.Equal(t, tc.expectedSlugs, slugs)
+		})
+	}
+}
+
+func TestGraphqlServer_Limits(t *testing.T) {
+	pictures := make([]domain.Picture, 0, 100)
+	for sol := 1; sol <= 100; sol++ {
+		pictures = append(pictures, domain.NewPicture(domain.NewPictureData{Size: sol, Sol: sol, Rover: "curiosity", Camera: "MAST"}))
+	}
+
+	testCases := []struct {
+		name          string
+		maxDepth      int
+		maxComplexity int
+		body          string
+		mockSetup     func(service *mocks.PictureService)
+		expectedSlug  string
+		expectedError string
+	}{
+		{
+			name:          "Should reject query nested deeper than limit",
+			maxDepth:      3,
+			maxComplexity: DefaultMaxComplexity,
+			body:          query(t, `{ sol(sol: 1) { winner { rover { pictures { sol } } } } }`, nil),
+			mockSetup:     func(service *mocks.PictureService) {},
+			expectedError: "exceeds max depth",
+		},
+		{
+			name:          "Should reject query spending more than complexity budget",
+			maxDepth:      DefaultMaxDepth,
+			maxComplexity: 150,
+			body:          query(t, `{ pictures(first: 100) { rover { pictures(first: 100) { sol } } } }`, nil),
+			mockSetup: func(service *mocks.PictureService) {
+				service.On("GetLeaderboard", mock.Anything, domain.LeaderboardFilter{Limit: 100}).Return(pictures, nil)
+			},
+			expectedSlug: "query-too-complex",
+		},
+		{
+			name:          "Should reject aliased commands spending more than complexity budget",
+			maxDepth:      DefaultMaxDepth,
+			maxComplexity: 2 * submitCommandCost,
+			body:          query(t, `mutation { a: submitCommand(sol: 1) { id } b: submitCommand(sol: 2) { id } c: submitCommand(sol: 3) { id } }`, nil),
+			mockSetup: func(service *mocks.PictureService) {
+				service.On("PublishCommand", mock.Anything, mock.Anything).
+					Return(domain.NewQueuedJob(1, time.Now()), nil).Twice()
+			},
+			expectedSlug: "query-too-complex",
+		},
+		{
+			name:          "Should allow query within complexity budget",
+			maxDepth:      DefaultMaxDepth,
+			maxComplexity: 150,
+			body:          query(t, `{ pictures(first: 100) { sol } }`, nil),
+			mockSetup: func(service *mocks.PictureService) {
+				service.On("GetLeaderboard", mock.Anything, domain.LeaderboardFilter{Limit: 100}).Return(pictures, nil)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			service := mocks.NewPictureService(t)
+			tc.mockSetup(service)
+			server, err := NewGraphqlServer(service, mocks.NewCameraCounter(t), tc.maxDepth, tc.maxComplexity)
+			require.NoError(t, err)
+
+			// When
+			code, response := postQuery(t, server, tc.body)
+
+			// Then
+			require.Equal(t, http.StatusOK, code)
+			switch {
+			case tc.expectedError != "":
+				require.NotEmpty(t, response.Errors)
+				require.Contains(t, response.Errors[0].Message, tc.expectedError)
+			case tc.expectedSlug != "":
+				require.NotEmpty(t, response.Errors)
+				for _, e := range response.Errors {
+					require.Equal(t, tc.expectedSlug, e.Extensions["slug"])
+				}
+			default:
+				require.Empty(t, response.Errors)
+			}
+		})
+	}
+}
+
+func TestGraphqlServer_SubmitCommandWithAPIKey(t *testing.T) {
+	readKey := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-read", Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}})
+	submitKey := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-submit", Scopes: domain.APIKeyScopes})
+
+	testCases := []struct {
+		name               string
+		key                domain.APIKey
+		mockSetup          func(service *mocks.PictureService, quota *mocks.QuotaConsumer)
+		expectedSlug       string
+		expectedRetryAfter float64
+	}{
+		{
+			name: "Should submit command within enqueue quota",
+			key:  submitKey,
+			mockSetup: func(service *mocks.PictureService, quota *mocks.QuotaConsumer) {
+				quota.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaEnqueues).Return(nil)
+				service.On("PublishCommand", mock.Anything, 1000).Return(domain.NewJob(domain.NewJobData{ID: "job-1"}), nil)
+			},
+		},
+		{
+			name:         "Should reject key without submit scope",
+			key:          readKey,
+			mockSetup:    func(service *mocks.PictureService, quota *mocks.QuotaConsumer) {},
+			expectedSlug: "insufficient-scope",
+		},
+		{
+			name: "Should reject command over enqueue quota",
+			key:  submitKey,
+			mockSetup: func(service *mocks.PictureService, quota *mocks.QuotaConsumer) {
+				quota.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaEnqueues).Return(
+					&domain.QuotaExceededError{Kind: domain.QuotaEnqueues, RetryAfter: 90 * time.Second})
+			},
+			expectedSlug:       "enqueue-quota-exceeded",
+			expectedRetryAfter: 90,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			service := mocks.NewPictureService(t)
+			quota := mocks.NewQuotaConsumer(t)
+			tc.mockSetup(service, quota)
+			graphqlServer, err := NewGraphqlServer(service, mocks.NewCameraCounter(t), DefaultMaxDepth, DefaultMaxComplexity)
+			require.NoError(t, err)
+			graphqlServer = graphqlServer.WithEnqueueQuota(quota)
+			ctx := server.WithAPIKey(context.Background(), tc.key)
+
+			// When
+			code, response := postQueryWithContext(t, ctx, graphqlServer, query(t, `mutation { submitCommand(sol: 1000) { id } }`, nil))
+
+			// Then
+			require.Equal(t, http.StatusOK, code)
+			if tc.expectedSlug == "" {
+				require.Empty(t, response.Errors)
+				require.JSONEq(t, `{"submitCommand":{"id":"job-1"}}`, string(response.Data))
+				return
+			}
+			require.Len(t, response.Errors, 1)
+			require.Equal(t, tc.expectedSlug, response.Errors[0].Extensions["slug"])
+			if tc.expectedRetryAfter > 0 {
+				require.Equal(t, tc.expectedRetryAfter, response.Errors[0].Extensions["retry_after"])
+			}
+		})
+	}
+}
+
+func TestIsMutation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected bool
+	}{
+		{name: "Should detect mutation", query: `mutation { submitCommand(sol: 1) { id } }`, expected: true},
+		{name: "Should detect named mutation after a query", query: "query A { job(id: \"1\") { id } }\nmutation B($sol: Int!) { submitCommand(sol: $sol) { id } }", expected: true},
+		{name: "Should not treat shorthand query as mutation", query: `{ sol(sol: 1) { winner { sol } } }`},
+		{name: "Should not treat field named mutation as mutation", query: `query { mutation: job(id: "1") { id } }`},
+		{name: "Should ignore keyword in comments and strings", query: "# mutation\n" + `query Q($name: String = """\""" mutation""", $id: ID = "\" mutation") { job(id: $id) { id } }`},
+		{name: "Should ignore keyword as part of a name", query: `query mutations { job(id: "1") { id } }`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// When
+			actual := IsMutation(tc.query)
+
+			// Then
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
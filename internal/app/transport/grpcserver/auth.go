@@ -0,0 +1,115 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/grpcserver/marspicturesv1"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata carries the API key, "authorization: Bearer <key>" is accepted as well
+const apiKeyMetadata = "x-api-key"
+
+// apiKeyAuth applies the rules of the HTTP auth middleware to MarsPictures calls:
+// SubmitCommand needs the submit scope and counts against the enqueue quota, other calls need read.
+// Health checking and reflection stay public.
+type apiKeyAuth struct {
+	authenticator httpserver.APIKeyAuthenticator
+}
+
+func (a apiKeyAuth) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a apiKeyAuth) stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+func (a apiKeyAuth) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	if !strings.HasPrefix(fullMethod, "/"+marspicturesv1.MarsPictures_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+	key, err := a.authenticator.Authenticate(ctx, apiKeySecret(ctx))
+	if errors.Is(err, domain.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "could-not-authenticate")
+	}
+	submit := fullMethod == marspicturesv1.MarsPictures_SubmitCommand_FullMethodName
+	scope := domain.APIKeyScopeRead
+	if submit {
+		scope = domain.APIKeyScopeSubmit
+	}
+	if !key.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "insufficient-scope")
+	}
+	if err := a.consumeQuota(ctx, key, domain.QuotaRequests); err != nil {
+		return nil, err
+	}
+	if submit {
+		if err := a.consumeQuota(ctx, key, domain.QuotaEnqueues); err != nil {
+			return nil, err
+		}
+	}
+	return server.WithAPIKey(ctx, key), nil
+}
+
+// consumeQuota returns ResourceExhausted with a retry-after header once the quota is spent
+func (a apiKeyAuth) consumeQuota(ctx context.Context, key domain.APIKey, kind domain.QuotaKind) error {
+	err := a.authenticator.ConsumeQuota(ctx, key, kind)
+	var quotaErr *domain.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		seconds := max(1, int64(math.Ceil(quotaErr.RetryAfter.Seconds())))
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+		if kind == domain.QuotaEnqueues {
+			return status.Error(codes.ResourceExhausted, "enqueue-quota-exceeded")
+		}
+		return status.Error(codes.ResourceExhausted, "request-quota-exceeded")
+	}
+	if err != nil {
+		return status.Error(codes.Internal, "could-not-check-quota")
+	}
+	return nil
+}
+
+func apiKeySecret(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(apiKeyMetadata); len(values) > 0 {
+		return values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, token, found := strings.Cut(values[0], " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,111 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/grpcserver/marspicturesv1"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGrpcServer_APIKeyAuth(t *testing.T) {
+	readKey := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-read", Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}})
+	submitKey := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-submit", Scopes: domain.APIKeyScopes})
+
+	testCases := []struct {
+		name               string
+		metadata           metadata.MD
+		mockSetup          func(auth *mocks.ApikeyAuthenticator, service *mocks.MarsApiLargestPictureService)
+		expectedCode       codes.Code
+		expectedSlug       string
+		expectedRetryAfter string
+	}{
+		{
+			name:     "Should submit command with submit key",
+			metadata: metadata.Pairs("x-api-key", "secret"),
+			mockSetup: func(auth *mocks.ApikeyAuthenticator, service *mocks.MarsApiLargestPictureService) {
+				auth.On("Authenticate", mock.Anything, "secret").Return(submitKey, nil)
+				auth.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaRequests).Return(nil).Once()
+				auth.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaEnqueues).Return(nil).Once()
+				service.On("PublishCommand", mock.Anything, 123).Return(domain.NewJob(domain.NewJobData{ID: "job-123", Sol: 123}), nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "Should reject call without key",
+			mockSetup: func(auth *mocks.ApikeyAuthenticator, service *mocks.MarsApiLargestPictureService) {
+				auth.On("Authenticate", mock.Anything, "").Return(domain.APIKey{}, domain.ErrUnauthorized)
+			},
+			expectedCode: codes.Unauthenticated,
+			expectedSlug: "unauthorized",
+		},
+		{
+			name:     "Should deny read key",
+			metadata: metadata.Pairs("authorization", "Bearer secret"),
+			mockSetup: func(auth *mocks.ApikeyAuthenticator, service *mocks.MarsApiLargestPictureService) {
+				auth.On("Authenticate", mock.Anything, "secret").Return(readKey, nil)
+			},
+			expectedCode: codes.PermissionDenied,
+			expectedSlug: "insufficient-scope",
+		},
+		{
+			name:     "Should reject command over enqueue quota",
+			metadata: metadata.Pairs("x-api-key", "secret"),
+			mockSetup: func(auth *mocks.ApikeyAuthenticator, service *mocks.MarsApiLargestPictureService) {
+				auth.On("Authenticate", mock.Anything, "secret").Return(submitKey, nil)
+				auth.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaRequests).Return(nil)
+				auth.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaEnqueues).Return(
+					&domain.QuotaExceededError{Kind: domain.QuotaEnqueues, RetryAfter: time.Minute})
+			},
+			expectedCode:       codes.ResourceExhausted,
+			expectedSlug:       "enqueue-quota-exceeded",
+			expectedRetryAfter: "60",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			authMock := mocks.NewApikeyAuthenticator(t)
+			serviceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.mockSetup(authMock, serviceMock)
+			_, conn := startServerWithAuth(t, serviceMock, authMock)
+			ctx := metadata.NewOutgoingContext(context.Background(), tc.metadata)
+			var header metadata.MD
+
+			// When
+			_, err := marspicturesv1.NewMarsPicturesClient(conn).SubmitCommand(
+				ctx, &marspicturesv1.SubmitCommandRequest{Sol: 123}, grpc.Header(&header))
+
+			// Then
+			if tc.expectedCode == codes.OK {
+				require.NoError(t, err)
+				return
+			}
+			requireStatus(t, err, tc.expectedCode, tc.expectedSlug)
+			if tc.expectedRetryAfter != "" {
+				require.Equal(t, []string{tc.expectedRetryAfter}, header.Get("retry-after"))
+			}
+		})
+	}
+
+	t.Run("Should serve health checks without key", func(t *testing.T) {
+		// Given
+		_, conn := startServerWithAuth(t, mocks.NewMarsApiLargestPictureService(t), mocks.NewApikeyAuthenticator(t))
+
+		// When
+		response, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, response.GetStatus())
+	})
+}
@@ -0,0 +1,293 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: marspictures/v1/mars_pictures.proto
+
+package marspicturesv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MarsPictures_SubmitCommand_FullMethodName     = "/marspictures.v1.MarsPictures/SubmitCommand"
+	MarsPictures_GetLargestPicture_FullMethodName = "/marspictures.v1.MarsPictures/GetLargestPicture"
+	MarsPictures_GetJob_FullMethodName            = "/marspictures.v1.MarsPictures/GetJob"
+	MarsPictures_ListPictures_FullMethodName      = "/marspictures.v1.MarsPictures/ListPictures"
+	MarsPictures_WatchJobs_FullMethodName         = "/marspictures.v1.MarsPictures/WatchJobs"
+)
+
+// MarsPicturesClient is the client API for MarsPictures service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MarsPictures exposes the same operations as the HTTP API.
+// Errors carry the HTTP API slug as status message.
+type MarsPicturesClient interface {
+	// SubmitCommand starts computing the largest picture of a sol
+	SubmitCommand(ctx context.Context, in *SubmitCommandRequest, opts ...grpc.CallOption) (*SubmitCommandResponse, error)
+	// GetLargestPicture returns the largest picture of a computed sol
+	GetLargestPicture(ctx context.Context, in *GetLargestPictureRequest, opts ...grpc.CallOption) (*Picture, error)
+	// GetJob returns the state of a job started by SubmitCommand
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error)
+	// ListPictures returns the largest pictures across computed sols ordered by size
+	ListPictures(ctx context.Context, in *ListPicturesRequest, opts ...grpc.CallOption) (*ListPicturesResponse, error)
+	// WatchJobs streams job lifecycle events until the client cancels the call
+	WatchJobs(ctx context.Context, in *WatchJobsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error)
+}
+
+type marsPicturesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMarsPicturesClient(cc grpc.ClientConnInterface) MarsPicturesClient {
+	return &marsPicturesClient{cc}
+}
+
+func (c *marsPicturesClient) SubmitCommand(ctx context.Context, in *SubmitCommandRequest, opts ...grpc.CallOption) (*SubmitCommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitCommandResponse)
+	err := c.cc.Invoke(ctx, MarsPictures_SubmitCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marsPicturesClient) GetLargestPicture(ctx context.Context, in *GetLargestPictureRequest, opts ...grpc.CallOption) (*Picture, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Picture)
+	err := c.cc.Invoke(ctx, MarsPictures_GetLargestPicture_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marsPicturesClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, MarsPictures_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marsPicturesClient) ListPictures(ctx context.Context, in *ListPicturesRequest, opts ...grpc.CallOption) (*ListPicturesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPicturesResponse)
+	err := c.cc.Invoke(ctx, MarsPictures_ListPictures_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *marsPicturesClient) WatchJobs(ctx context.Context, in *WatchJobsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MarsPictures_ServiceDesc.Streams[0], MarsPictures_WatchJobs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobsRequest, JobEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarsPictures_WatchJobsClient = grpc.ServerStreamingClient[JobEvent]
+
+// MarsPicturesServer is the server API for MarsPictures service.
+// All implementations must embed UnimplementedMarsPicturesServer
+// for forward compatibility.
+//
+// MarsPictures exposes the same operations as the HTTP API.
+// Errors carry the HTTP API slug as status message.
+type MarsPicturesServer interface {
+	// SubmitCommand starts computing the largest picture of a sol
+	SubmitCommand(context.Context, *SubmitCommandRequest) (*SubmitCommandResponse, error)
+	// GetLargestPicture returns the largest picture of a computed sol
+	GetLargestPicture(context.Context, *GetLargestPictureRequest) (*Picture, error)
+	// GetJob returns the state of a job started by SubmitCommand
+	GetJob(context.Context, *GetJobRequest) (*Job, error)
+	// ListPictures returns the largest pictures across computed sols ordered by size
+	ListPictures(context.Context, *ListPicturesRequest) (*ListPicturesResponse, error)
+	// WatchJobs streams job lifecycle events until the client cancels the call
+	WatchJobs(*WatchJobsRequest, grpc.ServerStreamingServer[JobEvent]) error
+	mustEmbedUnimplementedMarsPicturesServer()
+}
+
+// UnimplementedMarsPicturesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMarsPicturesServer struct{}
+
+func (UnimplementedMarsPicturesServer) SubmitCommand(context.Context, *SubmitCommandRequest) (*SubmitCommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitCommand not implemented")
+}
+func (UnimplementedMarsPicturesServer) GetLargestPicture(context.Context, *GetLargestPictureRequest) (*Picture, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLargestPicture not implemented")
+}
+func (UnimplementedMarsPicturesServer) GetJob(context.Context, *GetJobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedMarsPicturesServer) ListPictures(context.Context, *ListPicturesRequest) (*ListPicturesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPictures not implemented")
+}
+func (UnimplementedMarsPicturesServer) WatchJobs(*WatchJobsRequest, grpc.ServerStreamingServer[JobEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchJobs not implemented")
+}
+func (UnimplementedMarsPicturesServer) mustEmbedUnimplementedMarsPicturesServer() {}
+func (UnimplementedMarsPicturesServer) testEmbeddedByValue()                      {}
+
+// UnsafeMarsPicturesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MarsPicturesServer will
+// result in compilation errors.
+type UnsafeMarsPicturesServer interface {
+	mustEmbedUnimplementedMarsPicturesServer()
+}
+
+func RegisterMarsPicturesServer(s grpc.ServiceRegistrar, srv MarsPicturesServer) {
+	// If the following call pancis, it indicates UnimplementedMarsPicturesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MarsPictures_ServiceDesc, srv)
+}
+
+func _MarsPictures_SubmitCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarsPicturesServer).SubmitCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarsPictures_SubmitCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarsPicturesServer).SubmitCommand(ctx, req.(*SubmitCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarsPictures_GetLargestPicture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLargestPictureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarsPicturesServer).GetLargestPicture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarsPictures_GetLargestPicture_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarsPicturesServer).GetLargestPicture(ctx, req.(*GetLargestPictureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarsPictures_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarsPicturesServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarsPictures_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarsPicturesServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarsPictures_ListPictures_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPicturesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MarsPicturesServer).ListPictures(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MarsPictures_ListPictures_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MarsPicturesServer).ListPictures(ctx, req.(*ListPicturesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MarsPictures_WatchJobs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MarsPicturesServer).WatchJobs(m, &grpc.GenericServerStream[WatchJobsRequest, JobEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MarsPictures_WatchJobsServer = grpc.ServerStreamingServer[JobEvent]
+
+// MarsPictures_ServiceDesc is the grpc.ServiceDesc for MarsPictures service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MarsPictures_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "marspictures.v1.MarsPictures",
+	HandlerType: (*MarsPicturesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitCommand",
+			Handler:    _MarsPictures_SubmitCommand_Handler,
+		},
+		{
+			MethodName: "GetLargestPicture",
+			Handler:    _MarsPictures_GetLargestPicture_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _MarsPictures_GetJob_Handler,
+		},
+		{
+			MethodName: "ListPictures",
+			Handler:    _MarsPictures_ListPictures_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchJobs",
+			Handler:       _MarsPictures_WatchJobs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "marspictures/v1/mars_pictures.proto",
+}
@@ -0,0 +1,74 @@
+package grpcserver
+
+import (
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/grpcserver/marspicturesv1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var jobStatuses = map[domain.JobStatus]marspicturesv1.JobStatus{
+	domain.JobStatusQueued:    marspicturesv1.JobStatus_JOB_STATUS_QUEUED,
+	domain.JobStatusRunning:   marspicturesv1.JobStatus_JOB_STATUS_RUNNING,
+	domain.JobStatusCompleted: marspicturesv1.JobStatus_JOB_STATUS_COMPLETED,
+	domain.JobStatusFailed:    marspicturesv1.JobStatus_JOB_STATUS_FAILED,
+}
+
+var jobEventTypes = map[domain.JobEventType]marspicturesv1.JobEventType{
+	domain.JobEventQueued:    marspicturesv1.JobEventType_JOB_EVENT_TYPE_QUEUED,
+	domain.JobEventProgress:  marspicturesv1.JobEventType_JOB_EVENT_TYPE_PROGRESS,
+	domain.JobEventCompleted: marspicturesv1.JobEventType_JOB_EVENT_TYPE_COMPLETED,
+	domain.JobEventFailed:    marspicturesv1.JobEventType_JOB_EVENT_TYPE_FAILED,
+}
+
+func toPictureMessage(picture domain.Picture) *marspicturesv1.Picture {
+	message := &marspicturesv1.Picture{
+		Sol:    int32(picture.GetSol()),
+		ImgSrc: picture.GetUrl(),
+		Size:   int64(picture.GetSize()),
+		Rover:  picture.GetRover(),
+		Camera: picture.GetCamera(),
+	}
+	if !picture.GetEarthDate().IsZero() {
+		message.EarthDate = picture.GetEarthDate().Format(time.DateOnly)
+	}
+	return message
+}
+
+func toJobMessage(job domain.Job) *marspicturesv1.Job {
+	return &marspicturesv1.Job{
+		Id:          job.GetID(),
+		Sol:         int32(job.GetSol()),
+		Status:      jobStatuses[job.GetStatus()],
+		PhotosSized: int32(job.GetPhotosSized()),
+		PhotosTotal: int32(job.GetPhotosTotal()),
+		ErrorSlug:   job.GetErrorSlug(),
+		CreatedAt:   toTimestamp(job.GetCreatedAt()),
+		UpdatedAt:   toTimestamp(job.GetUpdatedAt()),
+	}
+}
+
+func toJobEventMessage(event domain.JobEvent) *marspicturesv1.JobEvent {
+	message := &marspicturesv1.JobEvent{
+		Id:          event.ID,
+		Type:        jobEventTypes[event.Type],
+		JobId:       event.JobID,
+		Sol:         int32(event.Sol),
+		PhotosSized: int32(event.PhotosSized),
+		PhotosTotal: int32(event.PhotosTotal),
+		Slug:        event.Slug,
+		CreatedAt:   toTimestamp(event.CreatedAt),
+	}
+	if event.Picture != nil {
+		message.Picture = toPictureMessage(*event.Picture)
+	}
+	return message
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,194 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/grpcserver/marspicturesv1"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcServer serves the MarsPictures API with the service behind the HTTP API,
+// together with gRPC health checking and server reflection
+type GrpcServer struct {
+	marspicturesv1.UnimplementedMarsPicturesServer
+
+	largestPictureService httpserver.MarsApiLargestPictureService
+	server                *grpc.Server
+	health                *health.Server
+	// shutdown is closed by Stop to end WatchJobs streams
+	shutdown chan struct{}
+	stopOnce sync.Once
+}
+
+// NewGrpcServer creates the server, calls require an API key unless authenticator is nil
+func NewGrpcServer(largestPictureService httpserver.MarsApiLargestPictureService, authenticator httpserver.APIKeyAuthenticator) *GrpcServer {
+	var options []grpc.ServerOption
+	if authenticator != nil {
+		auth := apiKeyAuth{authenticator: authenticator}
+		options = append(options, grpc.UnaryInterceptor(auth.unary), grpc.StreamInterceptor(auth.stream))
+	}
+	s := &GrpcServer{
+		largestPictureService: largestPictureService,
+		server:                grpc.NewServer(options...),
+		health:                health.NewServer(),
+		shutdown:              make(chan struct{}),
+	}
+	marspicturesv1.RegisterMarsPicturesServer(s.server, s)
+	healthpb.RegisterHealthServer(s.server, s.health)
+	reflection.Register(s.server)
+	s.health.SetServingStatus(marspicturesv1.MarsPictures_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	return s
+}
+
+// Serve accepts connections on lis until Stop is called
+func (s *GrpcServer) Serve(lis net.Listener) error {
+	err := s.server.Serve(lis)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Stop reports NOT_SERVING, ends event streams and waits for in-flight calls.
+// Calls still running when ctx is done are cancelled.
+func (s *GrpcServer) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		s.health.Shutdown()
+		close(s.shutdown)
+	})
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+func (s *GrpcServer) SubmitCommand(ctx context.Context, request *marspicturesv1.SubmitCommandRequest) (*marspicturesv1.SubmitCommandResponse, error) {
+	if request.GetSol() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid-command")
+	}
+	job, err := s.largestPictureService.PublishCommand(ctx, int(request.GetSol()))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "could-not-publish-command")
+	}
+	return &marspicturesv1.SubmitCommandResponse{Job: toJobMessage(job)}, nil
+}
+
+func (s *GrpcServer) GetLargestPicture(ctx context.Context, request *marspicturesv1.GetLargestPictureRequest) (*marspicturesv1.Picture, error) {
+	if request.GetSol() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid-command")
+	}
+	picture, err := s.largestPictureService.GetPictureBySol(ctx, int(request.GetSol()))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "not-found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "could-not-get-picture")
+	}
+	return toPictureMessage(picture), nil
+}
+
+func (s *GrpcServer) GetJob(ctx context.Context, request *marspicturesv1.GetJobRequest) (*marspicturesv1.Job, error) {
+	if request.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid-job-id")
+	}
+	job, err := s.largestPictureService.GetJob(ctx, request.GetId())
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "not-found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "could-not-get-job")
+	}
+	return toJobMessage(job), nil
+}
+
+func (s *GrpcServer) ListPictures(ctx context.Context, request *marspicturesv1.ListPicturesRequest) (*marspicturesv1.ListPicturesResponse, error) {
+	filter, err := toLeaderboardFilter(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid-leaderboard-query")
+	}
+	pictures, err := s.largestPictureService.GetLeaderboard(ctx, filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "could-not-get-leaderboard")
+	}
+	response := &marspicturesv1.ListPicturesResponse{Pictures: make([]*marspicturesv1.Picture, 0, len(pictures))}
+	for _, picture := range pictures {
+		response.Pictures = append(response.Pictures, toPictureMessage(picture))
+	}
+	return response, nil
+}
+
+func (s *GrpcServer) WatchJobs(request *marspicturesv1.WatchJobsRequest, stream grpc.ServerStreamingServer[marspicturesv1.JobEvent]) error {
+	if request.GetSol() < 0 {
+		return status.Error(codes.InvalidArgument, "invalid-events-query")
+	}
+	filter := domain.JobEventFilter{JobID: request.GetJobId(), Sol: int(request.GetSol())}
+	events := s.largestPictureService.SubscribeJobEvents(stream.Context(), filter, request.GetLastEventId())
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.shutdown:
+			return status.Error(codes.Unavailable, "server-shutting-down")
+		case event, ok := <-events:
+			if !ok {
+				// Subscription was dropped, client resumes with last_event_id
+				return status.Error(codes.Unavailable, "subscription-dropped")
+			}
+			if err := stream.Send(toJobEventMessage(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toLeaderboardFilter(request *marspicturesv1.ListPicturesRequest) (domain.LeaderboardFilter, error) {
+	filter := domain.LeaderboardFilter{
+		Limit:  int(request.GetLimit()),
+		Rover:  strings.ToLower(request.GetRover()),
+		Camera: strings.ToUpper(request.GetCamera()),
+	}
+	if filter.Limit == 0 {
+		filter.Limit = domain.DefaultLeaderboardLimit
+	}
+	if filter.Limit < 0 || filter.Limit > domain.MaxLeaderboardLimit {
+		return domain.LeaderboardFilter{}, errors.New("limit is out of range")
+	}
+	var err error
+	if filter.From, err = parseDate(request.GetFrom()); err != nil {
+		return domain.LeaderboardFilter{}, err
+	}
+	if filter.To, err = parseDate(request.GetTo()); err != nil {
+		return domain.LeaderboardFilter{}, err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return domain.LeaderboardFilter{}, errors.New("from date must not be after to date")
+	}
+	return filter, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,406 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/grpcserver/marspicturesv1"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startServer serves a GrpcServer on an in-process listener and returns a client connection to it
+func startServer(t *testing.T, service *mocks.MarsApiLargestPictureService) (*GrpcServer, *grpc.ClientConn) {
+	t.Helper()
+	return startServerWithAuth(t, service, nil)
+}
+
+// startServerWithAuth is startServer requiring API keys checked by authenticator
+func startServerWithAuth(
+	t *testing.T,
+	service *mocks.MarsApiLargestPictureService,
+	authenticator httpserver.APIKeyAuthenticator,
+) (*GrpcServer, *grpc.ClientConn) {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := NewGrpcServer(service, authenticator)
+	go func() { _ = server.Serve(lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = server.Stop(ctx)
+	})
+	return server, conn
+}
+
+func requireStatus(t *testing.T, err error, code codes.Code, slug string) {
+	t.Helper()
+	require.Error(t, err)
+	require.Equal(t, code, status.Code(err))
+	require.Equal(t, slug, status.Convert(err).Message())
+}
+
+func TestGrpcServer_SubmitCommand(t *testing.T) {
+	testCases := []struct {
+		name         string
+		sol          int32
+		mockSetup    func(m *mocks.MarsApiLargestPictureService)
+		expectedCode codes.Code
+		expectedSlug string
+	}{
+		{
+			name: "Should return queued job",
+			sol:  123,
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(
+					domain.NewJob(domain.NewJobData{ID: "job-123", Sol: 123, Status: domain.JobStatusQueued}), nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Should reject non positive sol",
+			sol:          0,
+			mockSetup:    func(m *mocks.MarsApiLargestPictureService) {},
+			expectedCode: codes.InvalidArgument,
+			expectedSlug: "invalid-command",
+		},
+		{
+			name: "Should return unavailable when command could not be published",
+			sol:  123,
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(domain.Job{}, errors.New("broker is down"))
+			},
+			expectedCode: codes.Unavailable,
+			expectedSlug: "could-not-publish-command",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			serviceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.mockSetup(serviceMock)
+			_, conn := startServer(t, serviceMock)
+			client := marspicturesv1.NewMarsPicturesClient(conn)
+
+			// When
+			response, err := client.SubmitCommand(context.Background(), &marspicturesv1.SubmitCommandRequest{Sol: tc.sol})
+
+			// Then
+			if tc.expectedCode != codes.OK {
+				requireStatus(t, err, tc.expectedCode, tc.expectedSlug)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "job-123", response.GetJob().GetId())
+			require.Equal(t, marspicturesv1.JobStatus_JOB_STATUS_QUEUED, response.GetJob().GetStatus())
+		})
+	}
+}
+
+func TestGrpcServer_GetLargestPicture(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mockSetup    func(m *mocks.MarsApiLargestPictureService)
+		expectedCode codes.Code
+		expectedSlug string
+	}{
+		{
+			name: "Should return largest picture",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetPictureBySol", mock.Anything, 123).Return(domain.NewPicture(domain.NewPictureData{
+					Sol:       123,
+					Url:       "http://example.com/largest.jpg",
+					Size:      1048576,
+					Rover:     "curiosity",
+					Camera:    "MAST",
+					EarthDate: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC),
+				}), nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "Should return not found when sol is not computed",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetPictureBySol", mock.Anything, 123).Return(domain.Picture{}, domain.ErrNotFound)
+			},
+			expectedCode: codes.NotFound,
+			expectedSlug: "not-found",
+		},
+		{
+			name: "Should return internal error when picture could not be read",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetPictureBySol", mock.Anything, 123).Return(domain.Picture{}, domain.ErrCalculationLargestPicture)
+			},
+			expectedCode: codes.Internal,
+			expectedSlug: "could-not-get-picture",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			serviceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.mockSetup(serviceMock)
+			_, conn := startServer(t, serviceMock)
+			client := marspicturesv1.NewMarsPicturesClient(conn)
+
+			// When
+			picture, err := client.GetLargestPicture(context.Background(), &marspicturesv1.GetLargestPictureRequest{Sol: 123})
+
+			// Then
+			if tc.expectedCode != codes.OK {
+				requireStatus(t, err, tc.expectedCode, tc.expectedSlug)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "http://example.com/largest.jpg", picture.GetImgSrc())
+			require.Equal(t, int64(1048576), picture.GetSize())
+			require.Equal(t, "2013-01-01", picture.GetEarthDate())
+		})
+	}
+}
+
+func TestGrpcServer_GetJob(t *testing.T) {
+	testCases := []struct {
+		name         string
+		id           string
+		mockSetup    func(m *mocks.MarsApiLargestPictureService)
+		expectedCode codes.Code
+		expectedSlug string
+	}{
+		{
+			name: "Should return job",
+			id:   "job-123",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetJob", mock.Anything, "job-123").Return(domain.NewJob(domain.NewJobData{
+					ID:          "job-123",
+					Sol:         123,
+					Status:      domain.JobStatusRunning,
+					PhotosSized: 5,
+					PhotosTotal: 10,
+					CreatedAt:   time.Now(),
+				}), nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Should reject empty id",
+			mockSetup:    func(m *mocks.MarsApiLargestPictureService) {},
+			expectedCode: codes.InvalidArgument,
+			expectedSlug: "invalid-job-id",
+		},
+		{
+			name: "Should return not found for unknown job",
+			id:   "job-404",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetJob", mock.Anything, "job-404").Return(domain.Job{}, domain.ErrNotFound)
+			},
+			expectedCode: codes.NotFound,
+			expectedSlug: "not-found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			serviceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.mockSetup(serviceMock)
+			_, conn := startServer(t, serviceMock)
+			client := marspicturesv1.NewMarsPicturesClient(conn)
+
+			// When
+			job, err := client.GetJob(context.Background(), &marspicturesv1.GetJobRequest{Id: tc.id})
+
+			// Then
+			if tc.expectedCode != codes.OK {
+				requireStatus(t, err, tc.expectedCode, tc.expectedSlug)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, marspicturesv1.JobStatus_JOB_STATUS_RUNNING, job.GetStatus())
+			require.Equal(t, int32(5), job.GetPhotosSized())
+			require.NotNil(t, job.GetCreatedAt())
+		})
+	}
+}
+
+func TestGrpcServer_ListPictures(t *testing.T) {
+	testCases := []struct {
+		name         string
+		request      *marspicturesv1.ListPicturesRequest
+		mockSetup    func(m *mocks.MarsApiLargestPictureService)
+		expectedCode codes.Code
+		expectedSols []int32
+	}{
+		{
+			name:    "Should list pictures with default limit and normalized filter",
+			request: &marspicturesv1.ListPicturesRequest{Rover: "Curiosity", Camera: "mast", From: "2012-08-06"},
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetLeaderboard", mock.Anything, domain.LeaderboardFilter{
+					Limit:  domain.DefaultLeaderboardLimit,
+					Rover:  "curiosity",
+					Camera: "MAST",
+					From:   time.Date(2012, 8, 6, 0, 0, 0, 0, time.UTC),
+				}).Return([]domain.Picture{
+					domain.NewPicture(domain.NewPictureData{Sol: 2, Size: 300}),
+					domain.NewPicture(domain.NewPictureData{Sol: 1, Size: 100}),
+				}, nil)
+			},
+			expectedCode: codes.OK,
+			expectedSols: []int32{2, 1},
+		},
+		{
+			name:         "Should reject limit above maximum",
+			request:      &marspicturesv1.ListPicturesRequest{Limit: domain.MaxLeaderboardLimit + 1},
+			mockSetup:    func(m *mocks.MarsApiLargestPictureService) {},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Should reject malformed date",
+			request:      &marspicturesv1.ListPicturesRequest{To: "yesterday"},
+			mockSetup:    func(m *mocks.MarsApiLargestPictureService) {},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			serviceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.mockSetup(serviceMock)
+			_, conn := startServer(t, serviceMock)
+			client := marspicturesv1.NewMarsPicturesClient(conn)
+
+			// When
+			response, err := client.ListPictures(context.Background(), tc.request)
+
+			// Then
+			if tc.expectedCode != codes.OK {
+				requireStatus(t, err, tc.expectedCode, "invalid-leaderboard-query")
+				return
+			}
+			require.NoError(t, err)
+			sols := make([]int32, 0, len(response.GetPictures()))
+			for _, picture := range response.GetPictures() {
+				sols = append(sols, picture.GetSol())
+			}
+			require.Equal(t, tc.expectedSols, sols)
+		})
+	}
+}
+
+func TestGrpcServer_WatchJobs(t *testing.T) {
+	t.Run("Should stream job events matching the filter", func(t *testing.T) {
+		// Given
+		events := make(chan domain.JobEvent, 2)
+		events <- domain.JobEvent{ID: 42, Type: domain.JobEventProgress, JobID: "job-123", Sol: 123, PhotosSized: 1, PhotosTotal: 2}
+		picture := domain.NewPicture(domain.NewPictureData{Sol: 123, Url: "http://example.com/largest.jpg", Size: 2048})
+		events <- domain.JobEvent{ID: 43, Type: domain.JobEventCompleted, JobID: "job-123", Sol: 123, Picture: &picture}
+		serviceMock := mocks.NewMarsApiLargestPictureService(t)
+		serviceMock.On("SubscribeJobEvents", mock.Anything, domain.JobEventFilter{Sol: 123}, int64(41)).
+			Return((<-chan domain.JobEvent)(events))
+		_, conn := startServer(t, serviceMock)
+		client := marspicturesv1.NewMarsPicturesClient(conn)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// When
+		stream, err := client.WatchJobs(ctx, &marspicturesv1.WatchJobsRequest{Sol: 123, LastEventId: 41})
+		require.NoError(t, err)
+		progress, err := stream.Recv()
+		require.NoError(t, err)
+		completed, err := stream.Recv()
+		require.NoError(t, err)
+
+		// Then
+		require.Equal(t, int64(42), progress.GetId())
+		require.Equal(t, marspicturesv1.JobEventType_JOB_EVENT_TYPE_PROGRESS, progress.GetType())
+		require.Equal(t, int32(1), progress.GetPhotosSized())
+		require.Equal(t, marspicturesv1.JobEventType_JOB_EVENT_TYPE_COMPLETED, completed.GetType())
+		require.Equal(t, "http://example.com/largest.jpg", completed.GetPicture().GetImgSrc())
+	})
+
+	t.Run("Should end streams when server stops", func(t *testing.T) {
+		// Given
+		subscribed := make(chan struct{})
+		serviceMock := mocks.NewMarsApiLargestPictureService(t)
+		serviceMock.On("SubscribeJobEvents", mock.Anything, domain.JobEventFilter{}, int64(0)).
+			Run(func(mock.Arguments) { close(subscribed) }).
+			Return((<-chan domain.JobEvent)(make(chan domain.JobEvent)))
+		server, conn := startServer(t, serviceMock)
+		client := marspicturesv1.NewMarsPicturesClient(conn)
+		stream, err := client.WatchJobs(context.Background(), &marspicturesv1.WatchJobsRequest{})
+		require.NoError(t, err)
+		<-subscribed
+
+		// When
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, server.Stop(ctx))
+
+		// Then
+		_, err = stream.Recv()
+		requireStatus(t, err, codes.Unavailable, "server-shutting-down")
+	})
+}
+
+func TestGrpcServer_Health(t *testing.T) {
+	// Given
+	server, conn := startServer(t, mocks.NewMarsApiLargestPictureService(t))
+	client := healthpb.NewHealthClient(conn)
+	request := &healthpb.HealthCheckRequest{Service: marspicturesv1.MarsPictures_ServiceDesc.ServiceName}
+
+	// When
+	serving, err := client.Check(context.Background(), request)
+	require.NoError(t, err)
+	server.health.Shutdown()
+	notServing, err := client.Check(context.Background(), request)
+	require.NoError(t, err)
+
+	// Then
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, serving.GetStatus())
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, notServing.GetStatus())
+}
+
+func TestGrpcServer_Reflection(t *testing.T) {
+	// Given
+	_, conn := startServer(t, mocks.NewMarsApiLargestPictureService(t))
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+
+	// When
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	response, err := stream.Recv()
+	require.NoError(t, err)
+
+	// Then
+	services := make([]string, 0)
+	for _, service := range response.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	require.Contains(t, services, marspicturesv1.MarsPictures_ServiceDesc.ServiceName)
+	require.Contains(t, services, "grpc.health.v1.Health")
+}
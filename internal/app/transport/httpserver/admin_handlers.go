@@ -0,0 +1,123 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// AdminServer manages API keys, it is guarded by a shared admin token instead of an API key
+type AdminServer struct {
+	apiKeyManager APIKeyManager
+	token         string
+}
+
+func NewAdminServer(apiKeyManager APIKeyManager, token string) AdminServer {
+	return AdminServer{
+		apiKeyManager: apiKeyManager,
+		token:         token,
+	}
+}
+
+// RegisterRoutes registers the admin routes on router
+func (a AdminServer) RegisterRoutes(router *mux.Router) {
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(a.requireAdminToken)
+	admin.HandleFunc("/api-keys", a.CreateAPIKeyHandler).Methods("POST")
+	admin.HandleFunc("/api-keys", a.ListAPIKeysHandler).Methods("GET")
+	admin.HandleFunc("/api-keys/{id}", a.RevokeAPIKeyHandler).Methods("DELETE")
+}
+
+func (a AdminServer) requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := apiKeySecret(r)
+		if a.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			server.Unauthorized("unauthorized", errors.New("missing or wrong admin token"), w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a AdminServer) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var request APIKeyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		server.BadRequest("invalid-api-key", err, w, r)
+		return
+	}
+
+	scopes := make([]domain.APIKeyScope, 0, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		scopes = append(scopes, domain.APIKeyScope(scope))
+	}
+	key, secret, err := a.apiKeyManager.CreateAPIKey(r.Context(), domain.NewAPIKeyData{
+		Name:         request.Name,
+		Scopes:       scopes,
+		RequestQuota: request.RequestQuota,
+		EnqueueQuota: request.EnqueueQuota,
+	})
+	if err != nil && errors.Is(err, domain.ErrInvalidAPIKey) {
+		server.BadRequest("invalid-api-key", err, w, r)
+		return
+	}
+	if err != nil {
+		server.InternalError("could-not-create-api-key", err, w, r)
+		return
+	}
+	response := toAPIKeyResponse(key)
+	response.Secret = secret
+	server.RespondCreated(response, w)
+}
+
+func (a AdminServer) ListAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := a.apiKeyManager.ListAPIKeys(r.Context())
+	if err != nil {
+		server.InternalError("could-not-list-api-keys", err, w, r)
+		return
+	}
+	response := APIKeysResponse{APIKeys: make([]APIKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		response.APIKeys = append(response.APIKeys, toAPIKeyResponse(key))
+	}
+	server.RespondJSON(response, w)
+}
+
+func (a AdminServer) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	err := a.apiKeyManager.RevokeAPIKey(r.Context(), mux.Vars(r)["id"])
+	if err != nil && errors.Is(err, domain.ErrNotFound) {
+		server.NotFound("not-found", domain.ErrNotFound, w, r)
+		return
+	}
+	if err != nil {
+		server.InternalError("could-not-revoke-api-key", err, w, r)
+		return
+	}
+	server.RespondNoContent(w)
+}
+
+func toAPIKeyResponse(key domain.APIKey) APIKeyResponse {
+	scopes := make([]string, 0, len(key.GetScopes()))
+	for _, scope := range key.GetScopes() {
+		scopes = append(scopes, string(scope))
+	}
+	response := APIKeyResponse{
+		ID:           key.GetID(),
+		Name:         key.GetName(),
+		Prefix:       key.GetPrefix(),
+		Scopes:       scopes,
+		RequestQuota: key.GetRequestQuota(),
+		EnqueueQuota: key.GetEnqueueQuota(),
+		CreatedAt:    key.GetCreatedAt(),
+	}
+	if key.IsRevoked() {
+		revokedAt := key.GetRevokedAt()
+		response.RevokedAt = &revokedAt
+	}
+	return response
+}
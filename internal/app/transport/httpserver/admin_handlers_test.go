@@ -0,0 +1,155 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+)
+
+const adminToken = "admin-token"
+
+func TestAdminServer(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	key := domain.NewAPIKey(domain.NewAPIKeyData{
+		ID: "key-1", Name: "ci", Prefix: "mpk_01234567", Scopes: domain.APIKeyScopes, RequestQuota: 60, CreatedAt: createdAt,
+	})
+	revokedKey := domain.NewAPIKey(domain.NewAPIKeyData{
+		ID: "key-2", Name: "old", Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}, CreatedAt: createdAt, RevokedAt: createdAt,
+	})
+
+	testCases := []struct {
+		name                       string
+		method                     string
+		target                     string
+		token                      string
+		requestBody                string
+		mockSetup                  func(m *mocks.ApikeyManager)
+		expectedStatusCode         int
+		expectedResponseBodyShould func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name:        "Should create key and return its secret once",
+			method:      http.MethodPost,
+			target:      "/admin/api-keys",
+			token:       adminToken,
+			requestBody: `{"name": "ci", "scopes": ["read", "submit"], "request_quota": 60}`,
+			mockSetup: func(m *mocks.ApikeyManager) {
+				m.On("CreateAPIKey", mock.Anything, domain.NewAPIKeyData{
+					Name: "ci", Scopes: domain.APIKeyScopes, RequestQuota: 60,
+				}).Return(key, "mpk_0123456789", nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "key-1", body["id"])
+				require.Equal(t, "mpk_0123456789", body["secret"])
+				require.Equal(t, []interface{}{"read", "submit"}, body["scopes"])
+			},
+		},
+		{
+			name:               "Should reject invalid key",
+			method:             http.MethodPost,
+			target:             "/admin/api-keys",
+			token:              adminToken,
+			requestBody:        `{"name": "ci", "scopes": ["admin"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			mockSetup: func(m *mocks.ApikeyManager) {
+				m.On("CreateAPIKey", mock.Anything, mock.Anything).Return(domain.APIKey{}, "", domain.ErrInvalidAPIKey)
+			},
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "invalid-api-key", body["slug"])
+			},
+		},
+		{
+			name:   "Should list keys without secrets",
+			method: http.MethodGet,
+			target: "/admin/api-keys",
+			token:  adminToken,
+			mockSetup: func(m *mocks.ApikeyManager) {
+				m.On("ListAPIKeys", mock.Anything).Return([]domain.APIKey{key, revokedKey}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				keys := body["api_keys"].([]interface{})
+				require.Len(t, keys, 2)
+				require.NotContains(t, keys[0], "secret")
+				require.NotContains(t, keys[0], "revoked_at")
+				require.Equal(t, "2024-01-02T03:04:05Z", keys[1].(map[string]interface{})["revoked_at"])
+			},
+		},
+		{
+			name:               "Should revoke key",
+			method:             http.MethodDelete,
+			target:             "/admin/api-keys/key-1",
+			token:              adminToken,
+			mockSetup:          func(m *mocks.ApikeyManager) { m.On("RevokeAPIKey", mock.Anything, "key-1").Return(nil) },
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:   "Should return not found for unknown key",
+			method: http.MethodDelete,
+			target: "/admin/api-keys/key-1",
+			token:  adminToken,
+			mockSetup: func(m *mocks.ApikeyManager) {
+				m.On("RevokeAPIKey", mock.Anything, "key-1").Return(domain.ErrNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "Should return internal error when keys could not be listed",
+			method: http.MethodGet,
+			target: "/admin/api-keys",
+			token:  adminToken,
+			mockSetup: func(m *mocks.ApikeyManager) {
+				m.On("ListAPIKeys", mock.Anything).Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "Should reject wrong admin token",
+			method:             http.MethodGet,
+			target:             "/admin/api-keys",
+			token:              "wrong",
+			mockSetup:          func(m *mocks.ApikeyManager) {},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "unauthorized", body["slug"])
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			apiKeyManagerMock := mocks.NewApikeyManager(t)
+			tc.mockSetup(apiKeyManagerMock)
+
+			router := mux.NewRouter()
+			NewAdminServer(apiKeyManagerMock, adminToken).RegisterRoutes(router)
+
+			req := httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.requestBody))
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+
+			// when
+			router.ServeHTTP(w, req)
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, w.Code)
+			if tc.expectedResponseBodyShould != nil {
+				var responseBody map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&responseBody))
+				tc.expectedResponseBodyShould(t, responseBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/graphqlserver"
+)
+
+// APIKeyHeader carries the API key, "Authorization: Bearer <key>" is accepted as well
+const APIKeyHeader = "X-API-Key"
+
+// PublicPaths are route templates served without an API key, admin routes check their own token
+var PublicPaths = []string{"/", "/openapi.json", "/docs", "/healthz", "/readyz", "/metrics", "/admin/api-keys", "/admin/api-keys/{id}"}
+
+const (
+	commandPath = "/mars/pictures/largest/command"
+	graphqlPath = "/graphql"
+)
+
+// NewAuthMiddleware requires an API key on every route except publicPaths. Reads and GraphQL queries
+// need the read scope, everything else including GraphQL mutations needs submit. Quotas are spent by
+// NewQuotaMiddleware, so that requests turned away by the rate limiter in between don't spend them.
+func NewAuthMiddleware(authenticator APIKeyAuthenticator, publicPaths []string) mux.MiddlewareFunc {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := routePath(r)
+			if public[path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := authenticator.Authenticate(r.Context(), apiKeySecret(r))
+			if errors.Is(err, domain.ErrUnauthorized) {
+				server.Unauthorized("unauthorized", err, w, r)
+				return
+			}
+			if err != nil {
+				server.InternalError("could-not-authenticate", err, w, r)
+				return
+			}
+			if !key.HasScope(requestScope(r, path)) {
+				server.Forbidden("insufficient-scope", domain.ErrForbidden, w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(server.WithAPIKey(r.Context(), key)))
+		})
+	}
+}
+
+// NewQuotaMiddleware counts each request authenticated by NewAuthMiddleware against the request quota
+// of its key and each command submitted over HTTP against its enqueue quota. GraphQL mutations spend
+// the enqueue quota per submitted command themselves.
+func NewQuotaMiddleware(authenticator APIKeyAuthenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := server.APIKeyFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !consumeQuota(authenticator, key, domain.QuotaRequests, w, r) {
+				return
+			}
+			if r.Method == http.MethodPost && routePath(r) == commandPath && !consumeQuota(authenticator, key, domain.QuotaEnqueues, w, r) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// consumeQuota writes 429 with Retry-After and returns false once the quota is spent
+func consumeQuota(authenticator APIKeyAuthenticator, key domain.APIKey, kind domain.QuotaKind, w http.ResponseWriter, r *http.Request) bool {
+	err := authenticator.ConsumeQuota(r.Context(), key, kind)
+	var quotaErr *domain.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		server.TooManyRequests(quotaSlug(kind), err, quotaErr.RetryAfter, w, r)
+		return false
+	}
+	if err != nil {
+		server.InternalError("could-not-check-quota", err, w, r)
+		return false
+	}
+	return true
+}
+
+func quotaSlug(kind domain.QuotaKind) string {
+	if kind == domain.QuotaEnqueues {
+		return "enqueue-quota-exceeded"
+	}
+	return "request-quota-exceeded"
+}
+
+// requestScope is the scope needed for r to path, GraphQL requests need submit only for mutations
+func requestScope(r *http.Request, path string) domain.APIKeyScope {
+	if path == graphqlPath {
+		if isGraphqlMutation(r) {
+			return domain.APIKeyScopeSubmit
+		}
+		return domain.APIKeyScopeRead
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return domain.APIKeyScopeRead
+	}
+	return domain.APIKeyScopeSubmit
+}
+
+// isGraphqlMutation peeks at the GraphQL document posted in r and puts the body back for the handler.
+// Bodies the GraphQL server rejects anyway count as queries.
+func isGraphqlMutation(r *http.Request) bool {
+	if r.Body == nil {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, graphqlserver.MaxRequestBytes+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return false
+	}
+	var request struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return false
+	}
+	return graphqlserver.IsMutation(request.Query)
+}
+
+func routePath(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return r.URL.Path
+	}
+	return template
+}
+
+func apiKeySecret(r *http.Request) string {
+	if secret := r.Header.Get(APIKeyHeader); secret != "" {
+		return secret
+	}
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
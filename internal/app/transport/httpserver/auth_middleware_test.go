@@ -0,0 +1,238 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	readKey := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-read", Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}})
+	submitKey := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-submit", Scopes: domain.APIKeyScopes})
+
+	testCases := []struct {
+		name               string
+		method             string
+		target             string
+		body               string
+		headers            map[string]string
+		mockSetup          func(m *mocks.ApikeyAuthenticator)
+		expectedStatusCode int
+		expectedSlug       string
+		expectedRetryAfter string
+	}{
+		{
+			name:               "Should serve public route without key",
+			method:             http.MethodGet,
+			target:             "/readyz",
+			mockSetup:          func(m *mocks.ApikeyAuthenticator) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "Should reject request without key",
+			method: http.MethodGet,
+			target: "/mars/pictures/leaderboard",
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "").Return(domain.APIKey{}, domain.ErrUnauthorized)
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedSlug:       "unauthorized",
+		},
+		{
+			name:    "Should serve read with read key from header",
+			method:  http.MethodGet,
+			target:  "/mars/pictures/leaderboard",
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(readKey, nil)
+				m.On("ConsumeQuota", mock.Anything, readKey, domain.QuotaRequests).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "Should accept bearer token",
+			method:  http.MethodGet,
+			target:  "/mars/pictures/leaderboard",
+			headers: map[string]string{"Authorization": "Bearer secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(readKey, nil)
+				m.On("ConsumeQuota", mock.Anything, readKey, domain.QuotaRequests).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "Should forbid submitting command with read key",
+			method:  http.MethodPost,
+			target:  "/mars/pictures/largest/command",
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(readKey, nil)
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedSlug:       "insufficient-scope",
+		},
+		{
+			name:    "Should serve GraphQL query with read key",
+			method:  http.MethodPost,
+			target:  "/graphql",
+			body:    `{"query":"{ job(id: \"1\") { id } }"}`,
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(readKey, nil)
+				m.On("ConsumeQuota", mock.Anything, readKey, domain.QuotaRequests).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "Should forbid GraphQL mutation with read key",
+			method:  http.MethodPost,
+			target:  "/graphql",
+			body:    `{"query":"mutation { submitCommand(sol: 1) { id } }"}`,
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(readKey, nil)
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedSlug:       "insufficient-scope",
+		},
+		{
+			name:    "Should count command against request and enqueue quotas",
+			method:  http.MethodPost,
+			target:  "/mars/pictures/largest/command",
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(submitKey, nil)
+				m.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaRequests).Return(nil).Once()
+				m.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaEnqueues).Return(nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "Should reject request over request quota",
+			method:  http.MethodGet,
+			target:  "/mars/pictures/leaderboard",
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(readKey, nil)
+				m.On("ConsumeQuota", mock.Anything, readKey, domain.QuotaRequests).Return(
+					&domain.QuotaExceededError{Kind: domain.QuotaRequests, RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedSlug:       "request-quota-exceeded",
+			expectedRetryAfter: "2",
+		},
+		{
+			name:    "Should reject command over enqueue quota",
+			method:  http.MethodPost,
+			target:  "/mars/pictures/largest/command",
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(submitKey, nil)
+				m.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaRequests).Return(nil)
+				m.On("ConsumeQuota", mock.Anything, submitKey, domain.QuotaEnqueues).Return(
+					&domain.QuotaExceededError{Kind: domain.QuotaEnqueues, RetryAfter: 30 * time.Minute})
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedSlug:       "enqueue-quota-exceeded",
+			expectedRetryAfter: "1800",
+		},
+		{
+			name:    "Should return internal error when key could not be read",
+			method:  http.MethodGet,
+			target:  "/mars/pictures/leaderboard",
+			headers: map[string]string{APIKeyHeader: "secret"},
+			mockSetup: func(m *mocks.ApikeyAuthenticator) {
+				m.On("Authenticate", mock.Anything, "secret").Return(domain.APIKey{}, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedSlug:       "could-not-authenticate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			authenticatorMock := mocks.NewApikeyAuthenticator(t)
+			tc.mockSetup(authenticatorMock)
+
+			var authenticatedKey domain.APIKey
+			var receivedBody string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				authenticatedKey, _ = server.APIKeyFromContext(r.Context())
+				body, _ := io.ReadAll(r.Body)
+				receivedBody = string(body)
+				w.WriteHeader(http.StatusOK)
+			}
+			router := mux.NewRouter()
+			router.Use(NewAuthMiddleware(authenticatorMock, PublicPaths))
+			router.Use(NewQuotaMiddleware(authenticatorMock))
+			router.HandleFunc("/readyz", handler).Methods(http.MethodGet)
+			router.HandleFunc("/mars/pictures/leaderboard", handler).Methods(http.MethodGet)
+			router.HandleFunc("/mars/pictures/largest/command", handler).Methods(http.MethodPost)
+			router.HandleFunc("/graphql", handler).Methods(http.MethodPost)
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			// when
+			router.ServeHTTP(w, req)
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, w.Code)
+			require.Equal(t, tc.expectedRetryAfter, w.Header().Get("Retry-After"))
+			if tc.expectedSlug != "" {
+				var body map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				require.Equal(t, tc.expectedSlug, body["slug"])
+				return
+			}
+			if len(tc.headers) > 0 {
+				require.NotEmpty(t, authenticatedKey.GetID())
+			}
+			require.Equal(t, tc.body, receivedBody)
+		})
+	}
+}
+
+func TestQuotaMiddleware_RateLimitedRequest(t *testing.T) {
+	// given
+	key := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-read", Scopes: []domain.APIKeyScope{domain.APIKeyScopeRead}})
+	authenticatorMock := mocks.NewApikeyAuthenticator(t)
+	authenticatorMock.On("Authenticate", mock.Anything, "secret").Return(key, nil).Once()
+	storeMock := mocks.NewRateLimitStore(t)
+	storeMock.On("Take", mock.Anything, "key:key-read:read", mock.Anything, mock.Anything).Return(
+		domain.RateLimitDecision{Limit: 10, RetryAfter: time.Second}, nil).Once()
+	limits := RateLimits{Read: domain.NewRateLimit(600, 100), Submit: domain.NewRateLimit(60, 10)}
+
+	router := mux.NewRouter()
+	router.Use(NewAuthMiddleware(authenticatorMock, PublicPaths))
+	router.Use(NewRateLimitMiddleware(storeMock, limits, PublicPaths, "", zerolog.Nop()))
+	router.Use(NewQuotaMiddleware(authenticatorMock))
+	router.HandleFunc("/mars/pictures/leaderboard", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	req := httptest.NewRequest(http.MethodGet, "/mars/pictures/leaderboard", nil)
+	req.Header.Set(APIKeyHeader, "secret")
+	w := httptest.NewRecorder()
+
+	// when
+	router.ServeHTTP(w, req)
+
+	// then
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	authenticatorMock.AssertNotCalled(t, "ConsumeQuota", mock.Anything, mock.Anything, mock.Anything)
+}
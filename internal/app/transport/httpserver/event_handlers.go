@@ -0,0 +1,117 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetryMillis       = 3000
+)
+
+// JobEventsHandler streams job lifecycle events as Server-Sent Events.
+// Clients may narrow the stream with sol or job_id and resume with Last-Event-ID.
+func (h HttpServer) JobEventsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseJobEventFilter(r.URL.Query())
+	if err != nil {
+		server.BadRequest("invalid-events-query", err, w, r)
+		return
+	}
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		server.BadRequest("invalid-last-event-id", err, w, r)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		server.InternalError("streaming-unsupported", errors.New("response writer does not support flushing"), w, r)
+		return
+	}
+
+	events := h.largestPictureService.SubscribeJobEvents(r.Context(), filter, lastEventID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				// Subscription was dropped, client reconnects with Last-Event-ID
+				return
+			}
+			if err := writeJobEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJobEvent(w io.Writer, event domain.JobEvent) error {
+	response := JobEventResponse{
+		JobID:       event.JobID,
+		Sol:         event.Sol,
+		PhotosSized: event.PhotosSized,
+		PhotosTotal: event.PhotosTotal,
+		Slug:        event.Slug,
+		CreatedAt:   event.CreatedAt,
+	}
+	if event.Picture != nil {
+		picture := toPictureResponse(*event.Picture)
+		response.Picture = &picture
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+func parseJobEventFilter(query url.Values) (domain.JobEventFilter, error) {
+	filter := domain.JobEventFilter{JobID: query.Get("job_id")}
+	if solStr := query.Get("sol"); solStr != "" {
+		sol, err := strconv.Atoi(solStr)
+		if err != nil || sol <= 0 {
+			return domain.JobEventFilter{}, errors.New("sol must be positive")
+		}
+		filter.Sol = sol
+	}
+	return filter, nil
+}
+
+// parseLastEventID reads Last-Event-ID header, falling back to query parameter
+// for clients that can't set headers on EventSource
+func parseLastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
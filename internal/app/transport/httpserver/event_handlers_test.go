@@ -0,0 +1,121 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+)
+
+const jobEventsEndpoint = "/mars/pictures/largest/events"
+
+func TestHttpServer_JobEventsHandler(t *testing.T) {
+	picture := domain.NewPicture(domain.NewPictureData{Sol: 1000, Url: "http://example.com/largest.jpg", Size: 2048})
+
+	testCases := []struct {
+		name               string
+		query              string
+		lastEventID        string
+		mockSetup          func(m *mocks.MarsApiLargestPictureService)
+		expectedStatusCode int
+		expectedBodyShould func(t *testing.T, body string)
+	}{
+		{
+			name:        "Should stream events filtered by sol and resume from Last-Event-ID",
+			query:       "?sol=1000",
+			lastEventID: "41",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				events := make(chan domain.JobEvent, 2)
+				events <- domain.JobEvent{ID: 42, Type: domain.JobEventProgress, JobID: "job-1", Sol: 1000, PhotosSized: 1, PhotosTotal: 2}
+				events <- domain.JobEvent{ID: 43, Type: domain.JobEventCompleted, JobID: "job-1", Sol: 1000, Picture: &picture}
+				close(events)
+				m.On("SubscribeJobEvents", mock.Anything, domain.JobEventFilter{Sol: 1000}, int64(41)).
+					Return((<-chan domain.JobEvent)(events))
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyShould: func(t *testing.T, body string) {
+				frames := strings.Split(strings.TrimSpace(body), "\n\n")
+				require.Len(t, frames, 3)
+				require.Equal(t, "retry: 3000", frames[0])
+
+				require.True(t, strings.HasPrefix(frames[1], "id: 42\nevent: progress\ndata: "))
+				require.True(t, strings.HasPrefix(frames[2], "id: 43\nevent: completed\ndata: "))
+
+				var completed map[string]interface{}
+				err := json.Unmarshal([]byte(strings.TrimPrefix(frames[2], "id: 43\nevent: completed\ndata: ")), &completed)
+				require.NoError(t, err)
+				require.Equal(t, "job-1", completed["job_id"])
+				require.Equal(t, 2048.0, completed["picture"].(map[string]interface{})["size"])
+			},
+		},
+		{
+			name:  "Should stream failed events with slug filtered by job id",
+			query: "?job_id=job-2",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				events := make(chan domain.JobEvent, 1)
+				events <- domain.JobEvent{ID: 7, Type: domain.JobEventFailed, JobID: "job-2", Sol: 5, Slug: "could-not-find-nasa-photos", CreatedAt: time.Now()}
+				close(events)
+				m.On("SubscribeJobEvents", mock.Anything, domain.JobEventFilter{JobID: "job-2"}, int64(0)).
+					Return((<-chan domain.JobEvent)(events))
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyShould: func(t *testing.T, body string) {
+				require.Contains(t, body, "event: failed\n")
+				require.Contains(t, body, `"slug":"could-not-find-nasa-photos"`)
+			},
+		},
+		{
+			name:               "Should return bad request for invalid sol filter",
+			query:              "?sol=abc",
+			mockSetup:          func(m *mocks.MarsApiLargestPictureService) {}, // No mock needed
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBodyShould: func(t *testing.T, body string) {
+				require.Contains(t, body, "invalid-events-query")
+			},
+		},
+		{
+			name:               "Should return bad request for invalid Last-Event-ID",
+			lastEventID:        "abc",
+			mockSetup:          func(m *mocks.MarsApiLargestPictureService) {}, // No mock needed
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBodyShould: func(t *testing.T, body string) {
+				require.Contains(t, body, "invalid-last-event-id")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			largestPictureServiceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.mockSetup(largestPictureServiceMock)
+
+			httpServer := NewHttpServer(largestPictureServiceMock, nil)
+
+			req := httptest.NewRequest(http.MethodGet, jobEventsEndpoint+tc.query, nil)
+			if tc.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+			w := httptest.NewRecorder()
+
+			// when
+			httpServer.JobEventsHandler(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			tc.expectedBodyShould(t, w.Body.String())
+
+			largestPictureServiceMock.AssertExpectations(t)
+		})
+	}
+}
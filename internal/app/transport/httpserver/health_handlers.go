@@ -0,0 +1,101 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+)
+
+// Health statuses of HealthResponse and of every CheckResult
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// healthCheckTimeout bounds a single check, probes of orchestrators time out after a few seconds
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck reports whether a dependency is able to serve traffic
+type HealthCheck func(ctx context.Context) error
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// CheckResult is the outcome of one HealthCheck
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// NewHealthHandler runs every check concurrently, each bounded by its own timeout, and responds 200
+// when all of them pass and 503 otherwise. It serves both /healthz and /readyz with different checks.
+func NewHealthHandler(checks map[string]HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := HealthResponse{Status: HealthStatusOK, Checks: make(map[string]CheckResult, len(checks))}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := runHealthCheck(r.Context(), check)
+				mu.Lock()
+				defer mu.Unlock()
+				response.Checks[name] = result
+				if result.Status != HealthStatusOK {
+					response.Status = HealthStatusUnavailable
+				}
+			}()
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		if response.Status != HealthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		server.RespondWithStatus(response, status, w)
+	}
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	started := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:    HealthStatusOK,
+		LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// CachedHealthCheck runs check at most once per ttl and reports its last outcome in between,
+// for dependencies that are slow or billed per request. Concurrent probes share one run.
+func CachedHealthCheck(check HealthCheck, ttl time.Duration) HealthCheck {
+	var (
+		mu        sync.Mutex
+		checkedAt time.Time
+		lastErr   error
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !checkedAt.IsZero() && time.Since(checkedAt) < ttl {
+			return lastErr
+		}
+		lastErr = check(ctx)
+		checkedAt = time.Now()
+		return lastErr
+	}
+}
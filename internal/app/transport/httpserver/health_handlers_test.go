@@ -0,0 +1,116 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHealthHandler(t *testing.T) {
+	testCases := []struct {
+		name               string
+		checks             map[string]HealthCheck
+		expectedStatusCode int
+		expectedStatus     string
+		expectedChecks     map[string]CheckResult
+	}{
+		{
+			name: "Should be ready when all dependencies are ready",
+			checks: map[string]HealthCheck{
+				"rabbitmq": func(ctx context.Context) error { return nil },
+				"postgres": func(ctx context.Context) error { return nil },
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     HealthStatusOK,
+			expectedChecks: map[string]CheckResult{
+				"rabbitmq": {Status: HealthStatusOK},
+				"postgres": {Status: HealthStatusOK},
+			},
+		},
+		{
+			name: "Should be unavailable when broker is reconnecting",
+			checks: map[string]HealthCheck{
+				"rabbitmq": func(ctx context.Context) error { return errors.New("rabbitmq is not connected") },
+				"postgres": func(ctx context.Context) error { return nil },
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     HealthStatusUnavailable,
+			expectedChecks: map[string]CheckResult{
+				"rabbitmq": {Status: HealthStatusUnavailable, Error: "rabbitmq is not connected"},
+				"postgres": {Status: HealthStatusOK},
+			},
+		},
+		{
+			name: "Should time out hanging dependency",
+			checks: map[string]HealthCheck{
+				"postgres": func(ctx context.Context) error {
+					_, hasDeadline := ctx.Deadline()
+					require.True(t, hasDeadline)
+					return context.DeadlineExceeded
+				},
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     HealthStatusUnavailable,
+			expectedChecks: map[string]CheckResult{
+				"postgres": {Status: HealthStatusUnavailable, Error: context.DeadlineExceeded.Error()},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			handler := NewHealthHandler(tc.checks)
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			w := httptest.NewRecorder()
+
+			// when
+			handler(w, req)
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, w.Result().StatusCode)
+			require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			var response HealthResponse
+			require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&response))
+			require.Equal(t, tc.expectedStatus, response.Status)
+			require.Len(t, response.Checks, len(tc.expectedChecks))
+			for name, expected := range tc.expectedChecks {
+				actual := response.Checks[name]
+				require.GreaterOrEqual(t, actual.LatencyMs, float64(0))
+				actual.LatencyMs = 0
+				require.Equal(t, expected, actual, name)
+			}
+		})
+	}
+}
+
+func TestCachedHealthCheck(t *testing.T) {
+	// given
+	calls := 0
+	failure := errors.New("nasa is unreachable")
+	check := CachedHealthCheck(func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return failure
+		}
+		return nil
+	}, 20*time.Millisecond)
+
+	// when
+	first := check(context.Background())
+	cached := check(context.Background())
+	time.Sleep(30 * time.Millisecond)
+	refreshed := check(context.Background())
+
+	// then
+	require.ErrorIs(t, first, failure)
+	require.ErrorIs(t, cached, failure)
+	require.NoError(t, refreshed)
+	require.Equal(t, 2, calls)
+}
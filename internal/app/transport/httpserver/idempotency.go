@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+const (
+	// IdempotencyKeyHeader lets a client retry a request without it taking effect twice
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyReplayedHeader marks a response replayed from an earlier request
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+	// DefaultIdempotencyTTL is how long a response is replayed to repeats of its request
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// IdempotencyLease is how long a request holds its key before a repeat may take it over.
+	// Commands are answered within seconds, a reservation older than this belongs to a crashed process.
+	IdempotencyLease = time.Minute
+
+	maxIdempotentBodyBytes = 64 << 10
+)
+
+// idempotent replays the stored response of a request repeated with the same Idempotency-Key.
+// A key reused for a different request is rejected with 422, a repeat arriving while the first
+// request still runs with 409. Only successful responses are stored, a failed or panicking request
+// can be retried right away and one that never finished after IdempotencyLease.
+func (h HttpServer) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	if h.idempotencyStore == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > domain.MaxIdempotencyKeyLength {
+			server.BadRequest("invalid-idempotency-key", domain.ErrInvalidIdempotencyKey, w, r)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			server.BadRequest("invalid-command", err, w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now().UTC()
+		record := domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+			Key:         idempotencyScope(r) + key,
+			RequestHash: domain.HashIdempotentRequest(r.Method, r.URL.Path, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(h.idempotencyTTL),
+		})
+		existing, reserved, err := h.idempotencyStore.Reserve(r.Context(), record, now.Add(-IdempotencyLease))
+		if err != nil {
+			server.InternalError("could-not-check-idempotency-key", err, w, r)
+			return
+		}
+		if !reserved {
+			replay(existing, record, w, r)
+			return
+		}
+
+		// The response is stored even when the client went away, its retry gets it replayed
+		ctx := context.WithoutCancel(r.Context())
+		defer func() {
+			if p := recover(); p != nil {
+				h.releaseIdempotencyKey(ctx, record.GetKey())
+				panic(p)
+			}
+		}()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		if recorder.status < 200 || recorder.status >= 300 {
+			h.releaseIdempotencyKey(ctx, record.GetKey())
+			return
+		}
+		if err := h.idempotencyStore.Complete(ctx, record.GetKey(), recorder.status, recorder.body.Bytes()); err != nil {
+			logging.From(ctx, h.logger).Error().Err(err).Msg("failed to store idempotent response")
+		}
+	}
+}
+
+func (h HttpServer) releaseIdempotencyKey(ctx context.Context, key string) {
+	if err := h.idempotencyStore.Release(ctx, key); err != nil {
+		logging.From(ctx, h.logger).Error().Err(err).Msg("failed to release idempotency key")
+	}
+}
+
+func replay(existing, record domain.IdempotencyRecord, w http.ResponseWriter, r *http.Request) {
+	if existing.GetRequestHash() != record.GetRequestHash() {
+		server.UnprocessableEntity("idempotency-key-reused", domain.ErrIdempotencyKeyReused, w, r)
+		return
+	}
+	if !existing.IsCompleted() {
+		w.Header().Set("Retry-After", "1")
+		server.Conflict("idempotency-key-in-progress", domain.ErrIdempotencyKeyInProgress, w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set(IdempotencyReplayedHeader, "true")
+	w.WriteHeader(existing.GetStatusCode())
+	_, _ = w.Write(existing.GetBody())
+}
+
+// idempotencyScope keeps keys of different API keys apart
+func idempotencyScope(r *http.Request) string {
+	if key, ok := server.APIKeyFromContext(r.Context()); ok {
+		return "key:" + key.GetID() + ":"
+	}
+	return ":"
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,197 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+)
+
+func TestHttpServer_IdempotentCommand(t *testing.T) {
+	body := []byte(`{"sol": 123}`)
+	requestHash := domain.HashIdempotentRequest(http.MethodPost, commandEndpoint, body)
+	job := domain.NewJob(domain.NewJobData{ID: "job-123", Sol: 123, Status: domain.JobStatusQueued})
+	completed := domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+		Key:         ":key-1",
+		RequestHash: requestHash,
+		StatusCode:  http.StatusOK,
+		Body:        []byte(`{"sol":123,"job_id":"job-123"}`),
+	})
+	matchesRecord := mock.MatchedBy(func(record domain.IdempotencyRecord) bool {
+		return record.GetKey() == ":key-1" && record.GetRequestHash() == requestHash &&
+			record.GetExpiresAt().Sub(record.GetCreatedAt()) == time.Hour
+	})
+	afterLease := mock.MatchedBy(func(staleBefore time.Time) bool {
+		age := time.Since(staleBefore)
+		return age >= IdempotencyLease && age < IdempotencyLease+time.Minute
+	})
+
+	testCases := []struct {
+		name               string
+		idempotencyKey     string
+		serviceMockSetup   func(m *mocks.MarsApiLargestPictureService)
+		storeMockSetup     func(m *mocks.IdempotencyStore)
+		expectedStatusCode int
+		expectedSlug       string
+		expectedReplayed   bool
+	}{
+		{
+			name: "Should publish command without idempotency key",
+			serviceMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(job, nil).Once()
+			},
+			storeMockSetup:     func(m *mocks.IdempotencyStore) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Should reject too long idempotency key",
+			idempotencyKey:     strings.Repeat("k", domain.MaxIdempotencyKeyLength+1),
+			serviceMockSetup:   func(m *mocks.MarsApiLargestPictureService) {},
+			storeMockSetup:     func(m *mocks.IdempotencyStore) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSlug:       "invalid-idempotency-key",
+		},
+		{
+			name:           "Should store response of first request",
+			idempotencyKey: "key-1",
+			serviceMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(job, nil).Once()
+			},
+			storeMockSetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, matchesRecord, afterLease).Return(domain.IdempotencyRecord{}, true, nil).Once()
+				m.On("Complete", mock.Anything, ":key-1", http.StatusOK, mock.MatchedBy(func(body []byte) bool {
+					return bytes.Contains(body, []byte(`"job_id":"job-123"`))
+				})).Return(nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Should release key when command could not be published",
+			idempotencyKey: "key-1",
+			serviceMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(domain.Job{}, errors.New("db is down")).Once()
+			},
+			storeMockSetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, matchesRecord, afterLease).Return(domain.IdempotencyRecord{}, true, nil).Once()
+				m.On("Release", mock.Anything, ":key-1").Return(nil).Once()
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedSlug:       "could-not-publish-command",
+		},
+		{
+			name:             "Should replay response of repeated request",
+			idempotencyKey:   "key-1",
+			serviceMockSetup: func(m *mocks.MarsApiLargestPictureService) {},
+			storeMockSetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, matchesRecord, afterLease).Return(completed, false, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedReplayed:   true,
+		},
+		{
+			name:             "Should reject key reused with a different body",
+			idempotencyKey:   "key-1",
+			serviceMockSetup: func(m *mocks.MarsApiLargestPictureService) {},
+			storeMockSetup: func(m *mocks.IdempotencyStore) {
+				other := domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+					Key: ":key-1", RequestHash: "other", StatusCode: http.StatusOK,
+				})
+				m.On("Reserve", mock.Anything, matchesRecord, afterLease).Return(other, false, nil).Once()
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedSlug:       "idempotency-key-reused",
+		},
+		{
+			name:             "Should reject repeat while first request is in progress",
+			idempotencyKey:   "key-1",
+			serviceMockSetup: func(m *mocks.MarsApiLargestPictureService) {},
+			storeMockSetup: func(m *mocks.IdempotencyStore) {
+				inProgress := domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{Key: ":key-1", RequestHash: requestHash})
+				m.On("Reserve", mock.Anything, matchesRecord, afterLease).Return(inProgress, false, nil).Once()
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedSlug:       "idempotency-key-in-progress",
+		},
+		{
+			name:             "Should return internal error when store fails",
+			idempotencyKey:   "key-1",
+			serviceMockSetup: func(m *mocks.MarsApiLargestPictureService) {},
+			storeMockSetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, matchesRecord, afterLease).Return(domain.IdempotencyRecord{}, false, errors.New("db is down")).Once()
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedSlug:       "could-not-check-idempotency-key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			serviceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.serviceMockSetup(serviceMock)
+			storeMock := mocks.NewIdempotencyStore(t)
+			tc.storeMockSetup(storeMock)
+
+			router := mux.NewRouter()
+			NewHttpServer(serviceMock, nil).WithIdempotency(storeMock, time.Hour, zerolog.Nop()).RegisterRoutes(router)
+
+			req := httptest.NewRequest(http.MethodPost, commandEndpoint, bytes.NewReader(body))
+			if tc.idempotencyKey != "" {
+				req.Header.Set(IdempotencyKeyHeader, tc.idempotencyKey)
+			}
+			w := httptest.NewRecorder()
+
+			// when
+			router.ServeHTTP(w, req)
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, w.Code)
+			var responseBody map[string]interface{}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&responseBody))
+			if tc.expectedSlug != "" {
+				require.Equal(t, tc.expectedSlug, responseBody["slug"])
+			} else {
+				require.Equal(t, "job-123", responseBody["job_id"])
+			}
+			if tc.expectedReplayed {
+				require.Equal(t, "true", w.Header().Get(IdempotencyReplayedHeader))
+			} else {
+				require.Empty(t, w.Header().Get(IdempotencyReplayedHeader))
+			}
+		})
+	}
+}
+
+func TestHttpServer_IdempotentCommandReleasesKeyOnPanic(t *testing.T) {
+	// given
+	serviceMock := mocks.NewMarsApiLargestPictureService(t)
+	serviceMock.On("PublishCommand", mock.Anything, 123).Run(func(args mock.Arguments) {
+		panic("worker pool is gone")
+	}).Once()
+	storeMock := mocks.NewIdempotencyStore(t)
+	storeMock.On("Reserve", mock.Anything, mock.Anything, mock.Anything).Return(domain.IdempotencyRecord{}, true, nil).Once()
+	storeMock.On("Release", mock.Anything, ":key-1").Return(nil).Once()
+
+	router := mux.NewRouter()
+	NewHttpServer(serviceMock, nil).WithIdempotency(storeMock, time.Hour, zerolog.Nop()).RegisterRoutes(router)
+	req := httptest.NewRequest(http.MethodPost, commandEndpoint, strings.NewReader(`{"sol": 123}`))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+
+	// when
+	serve := func() { router.ServeHTTP(httptest.NewRecorder(), req) }
+
+	// then
+	require.PanicsWithValue(t, "worker pool is gone", serve)
+	storeMock.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ package httpserver
 
 import (
 	"context"
+	"time"
 
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
 )
@@ -11,7 +12,42 @@ import (
 // MarsApiLargestPictureService is picture service
 type MarsApiLargestPictureService interface {
 	GetPictureBySol(ctx context.Context, sol int) (domain.Picture, error)
+	GetLeaderboard(ctx context.Context, filter domain.LeaderboardFilter) ([]domain.Picture, error)
 	CheckIfPictureExistsSaveIfNecessary(ctx context.Context, sol int) error
-	PublishCommand(ctx context.Context, sol int) error
-	StartListeningSolCommands(ctx context.Context)
+	PublishCommand(ctx context.Context, sol int) (domain.Job, error)
+	GetJob(ctx context.Context, id string) (domain.Job, error)
+	SubscribeJobEvents(ctx context.Context, filter domain.JobEventFilter, lastEventID int64) <-chan domain.JobEvent
+}
+
+// WebhookManager manages webhook subscriptions and exposes their delivery log
+type WebhookManager interface {
+	CreateSubscription(ctx context.Context, data domain.NewWebhookSubscriptionData) (domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]domain.WebhookDelivery, error)
+}
+
+// APIKeyAuthenticator resolves the key of a request and enforces its quotas
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, secret string) (domain.APIKey, error)
+	ConsumeQuota(ctx context.Context, key domain.APIKey, kind domain.QuotaKind) error
+}
+
+// APIKeyManager issues and revokes API keys
+type APIKeyManager interface {
+	CreateAPIKey(ctx context.Context, data domain.NewAPIKeyData) (domain.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+}
+
+// RateLimitStore keeps a token bucket per client
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitDecision, error)
+}
+
+// IdempotencyStore remembers requests sent with an Idempotency-Key and their responses
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record domain.IdempotencyRecord, staleBefore time.Time) (domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, statusCode int, body []byte) error
+	Release(ctx context.Context, key string) error
 }
@@ -0,0 +1,55 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+)
+
+// RequestIDHeader carries the ID correlating the log lines of a request, it is echoed in the response
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID accepts request IDs set by proxies and clients, anything else is replaced
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// NewLoggingMiddleware gives every request an ID, taken from X-Request-ID when the client sent a valid one,
+// and puts a logger carrying it in the request context for handlers and services. Each request is logged
+// once it is served. It should come right after the tracing middleware, so log lines carry the trace ID.
+func NewLoggingMiddleware(logger zerolog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID.MatchString(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+			ctx := logging.WithRequestID(r.Context(), requestID)
+			requestLogger := logging.From(ctx, logger)
+			r = r.WithContext(requestLogger.WithContext(ctx))
+
+			writer, recorder := recordStatus(w)
+			next.ServeHTTP(writer, r)
+			requestLogger.Info().
+				Str("method", r.Method).
+				Str("route", metricsRoute(r)).
+				Str("path", r.URL.Path).
+				Int("status", recorder.status).
+				Dur("duration", time.Since(started)).
+				Msg("request served")
+		})
+	}
+}
+
+// newRequestID returns a random 64-bit hex encoded identifier
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
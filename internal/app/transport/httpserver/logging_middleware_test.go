@@ -0,0 +1,80 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	testCases := []struct {
+		name              string
+		requestID         string
+		expectedRequestID string
+	}{
+		{
+			name:              "Should keep request ID sent by client",
+			requestID:         "client-id-1",
+			expectedRequestID: "client-id-1",
+		},
+		{
+			name: "Should generate request ID when client sent none",
+		},
+		{
+			name:      "Should replace malformed request ID",
+			requestID: "bad id\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			var out bytes.Buffer
+			router := mux.NewRouter()
+			router.Use(NewLoggingMiddleware(zerolog.New(&out)))
+			var handlerRequestID string
+			router.HandleFunc("/logging-test/{sol}", func(w http.ResponseWriter, r *http.Request) {
+				handlerRequestID = logging.RequestID(r.Context())
+				zerolog.Ctx(r.Context()).Warn().Msg("from handler")
+				w.WriteHeader(http.StatusTeapot)
+			}).Methods(http.MethodGet)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/logging-test/123", nil)
+			if tc.requestID != "" {
+				req.Header.Set(RequestIDHeader, tc.requestID)
+			}
+
+			// when
+			router.ServeHTTP(w, req)
+
+			// then
+			requestID := w.Header().Get(RequestIDHeader)
+			require.NotEmpty(t, requestID)
+			if tc.expectedRequestID != "" {
+				require.Equal(t, tc.expectedRequestID, requestID)
+			} else {
+				require.NotEqual(t, tc.requestID, requestID)
+			}
+			require.Equal(t, requestID, handlerRequestID)
+
+			lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+			require.Len(t, lines, 2)
+			var handlerLine, requestLine map[string]any
+			require.NoError(t, json.Unmarshal(lines[0], &handlerLine))
+			require.NoError(t, json.Unmarshal(lines[1], &requestLine))
+			require.Equal(t, "from handler", handlerLine["message"])
+			require.Equal(t, requestID, handlerLine[logging.RequestIDField])
+			require.Equal(t, "request served", requestLine["message"])
+			require.Equal(t, requestID, requestLine[logging.RequestIDField])
+			require.Equal(t, "/logging-test/{sol}", requestLine["route"])
+			require.Equal(t, float64(http.StatusTeapot), requestLine["status"])
+		})
+	}
+}
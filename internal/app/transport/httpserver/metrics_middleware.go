@@ -0,0 +1,78 @@
+package httpserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+)
+
+// NewMetricsMiddleware records the count and latency of every request by method, route template and status.
+// It should be the first middleware, so requests rejected by authentication or rate limits are counted too.
+func NewMetricsMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			writer, recorder := recordStatus(w)
+			next.ServeHTTP(writer, r)
+			metrics.ObserveHTTPRequest(r.Method, metricsRoute(r), recorder.status, time.Since(started))
+		})
+	}
+}
+
+// metricsRoute is the route template of r, raw paths would give every sol a series of its own
+func metricsRoute(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return metrics.RouteUnmatched
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return metrics.RouteUnmatched
+	}
+	return template
+}
+
+// recordStatus wraps w in a statusRecorder that is still a http.Flusher when w is one
+func recordStatus(w http.ResponseWriter) (http.ResponseWriter, *statusRecorder) {
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	if _, ok := w.(http.Flusher); ok {
+		// Event streams need to flush, so the recorder must not hide it
+		return flushingStatusRecorder{recorder}, recorder
+	}
+	return recorder, recorder
+}
+
+// statusRecorder remembers the status code written to the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+type flushingStatusRecorder struct {
+	*statusRecorder
+}
+
+func (r flushingStatusRecorder) Flush() {
+	r.wroteHeader = true
+	r.ResponseWriter.(http.Flusher).Flush()
+}
@@ -0,0 +1,63 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	testCases := []struct {
+		name           string
+		target         string
+		cannotStream   bool
+		expectedSeries string
+		expectFlusher  bool
+	}{
+		{
+			name:           "Should record route template and status",
+			target:         "/metrics-test/123",
+			expectedSeries: `largest_picture_http_requests_total{method="GET",route="/metrics-test/{sol}",status="418"}`,
+			expectFlusher:  true,
+		},
+		{
+			name:           "Should not offer flushing when server cannot stream",
+			target:         "/metrics-test/456",
+			cannotStream:   true,
+			expectedSeries: `largest_picture_http_requests_total{method="GET",route="/metrics-test/{sol}",status="418"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			var canFlush bool
+			router := mux.NewRouter()
+			router.Use(NewMetricsMiddleware())
+			router.HandleFunc("/metrics-test/{sol}", func(w http.ResponseWriter, r *http.Request) {
+				_, canFlush = w.(http.Flusher)
+				w.WriteHeader(http.StatusTeapot)
+			}).Methods(http.MethodGet)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+
+			// when
+			if tc.cannotStream {
+				router.ServeHTTP(nonFlushingRecorder{recorder: w}, req)
+			} else {
+				router.ServeHTTP(w, req)
+			}
+
+			// then
+			require.Equal(t, http.StatusTeapot, w.Code)
+			require.Equal(t, tc.expectFlusher, canFlush)
+			exposition := httptest.NewRecorder()
+			metrics.Handler().ServeHTTP(exposition, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			require.Contains(t, exposition.Body.String(), tc.expectedSeries)
+		})
+	}
+}
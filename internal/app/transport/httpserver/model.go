@@ -1,5 +1,87 @@
 package httpserver
 
+import "time"
+
 type PictureCommand struct {
 	Sol int `json:"sol"`
 }
+
+type PictureResponse struct {
+	Sol       int    `json:"sol"`
+	ImgSrc    string `json:"img_src"`
+	Size      int    `json:"size"`
+	Rover     string `json:"rover,omitempty"`
+	Camera    string `json:"camera,omitempty"`
+	EarthDate string `json:"earth_date,omitempty"`
+}
+
+type LeaderboardResponse struct {
+	Pictures []PictureResponse `json:"pictures"`
+}
+
+type JobEventResponse struct {
+	JobID       string           `json:"job_id"`
+	Sol         int              `json:"sol"`
+	PhotosSized int              `json:"photos_sized,omitempty"`
+	PhotosTotal int              `json:"photos_total,omitempty"`
+	Picture     *PictureResponse `json:"picture,omitempty"`
+	Slug        string           `json:"slug,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+type WebhookSubscriptionRequest struct {
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookSubscriptionResponse struct {
+	ID         string    `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookSubscriptionsResponse struct {
+	Subscriptions []WebhookSubscriptionResponse `json:"subscriptions"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID         int64     `json:"id"`
+	EventType  string    `json:"event_type"`
+	JobID      string    `json:"job_id,omitempty"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Succeeded  bool      `json:"succeeded"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+type APIKeyRequest struct {
+	Name         string   `json:"name"`
+	Scopes       []string `json:"scopes"`
+	RequestQuota int      `json:"request_quota"`
+	EnqueueQuota int      `json:"enqueue_quota"`
+}
+
+type APIKeyResponse struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Scopes       []string   `json:"scopes"`
+	RequestQuota int        `json:"request_quota"`
+	EnqueueQuota int        `json:"enqueue_quota"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	// Secret is returned only when the key is created
+	Secret string `json:"secret,omitempty"`
+}
+
+type APIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
@@ -0,0 +1,540 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/graphqlserver"
+	graphqlmocks "github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/graphqlserver/mocks"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+)
+
+// nonFlushingRecorder hides http.Flusher of the recorder to simulate servers that cannot stream
+type nonFlushingRecorder struct {
+	recorder *httptest.ResponseRecorder
+}
+
+func (w nonFlushingRecorder) Header() http.Header         { return w.recorder.Header() }
+func (w nonFlushingRecorder) Write(b []byte) (int, error) { return w.recorder.Write(b) }
+func (w nonFlushingRecorder) WriteHeader(statusCode int)  { w.recorder.WriteHeader(statusCode) }
+
+func TestLoadOpenAPI(t *testing.T) {
+	// When
+	doc, err := LoadOpenAPI()
+
+	// Then
+	require.NoError(t, err)
+	require.NotEmpty(t, doc.Paths.Map())
+}
+
+// TestOpenAPI_ResponsesMatchDocument sends requests through the registered routes and the request validator
+// and checks every response against the OpenAPI document. Every documented response must be exercised.
+func TestOpenAPI_ResponsesMatchDocument(t *testing.T) {
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/event-stream", openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder("text/plain", openapi3filter.FileBodyDecoder)
+
+	picture := domain.NewPicture(domain.NewPictureData{
+		Sol:       123,
+		Url:       "http://example.com/largest.jpg",
+		Size:      1048576,
+		Rover:     "curiosity",
+		Camera:    "MAST",
+		EarthDate: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	})
+	subscription := domain.NewWebhookSubscription(domain.NewWebhookSubscriptionData{
+		ID:         "sub-1",
+		Url:        "https://example.com/hook",
+		Secret:     "0123456789abcdef",
+		EventTypes: []domain.JobEventType{domain.JobEventCompleted},
+		CreatedAt:  time.Now(),
+	})
+	apiKey := domain.NewAPIKey(domain.NewAPIKeyData{
+		ID:        "key-1",
+		Name:      "ci",
+		Prefix:    "mpk_01234567",
+		Scopes:    []domain.APIKeyScope{domain.APIKeyScopeRead},
+		CreatedAt: time.Now(),
+	})
+	webhookRequest := `{"url": "https://example.com/hook", "secret": "0123456789abcdef", "event_types": ["completed"]}`
+	closedEvents := make(chan domain.JobEvent)
+	close(closedEvents)
+
+	testCases := []struct {
+		name               string
+		method             string
+		target             string
+		body               string
+		headers            map[string]string
+		notReady           bool
+		cannotStream       bool
+		picturesMockSetup  func(m *mocks.MarsApiLargestPictureService)
+		webhooksMockSetup  func(m *mocks.WebhookManager)
+		apiKeysMockSetup   func(m *mocks.ApikeyManager)
+		idempotencySetup   func(m *mocks.IdempotencyStore)
+		expectedStatusCode int
+	}{
+		{name: "banner", method: http.MethodGet, target: "/", expectedStatusCode: http.StatusOK},
+		{name: "openapi document", method: http.MethodGet, target: "/openapi.json", expectedStatusCode: http.StatusOK},
+		{name: "docs page", method: http.MethodGet, target: "/docs", expectedStatusCode: http.StatusOK},
+		{name: "ready", method: http.MethodGet, target: "/readyz", expectedStatusCode: http.StatusOK},
+		{name: "metrics", method: http.MethodGet, target: "/metrics", expectedStatusCode: http.StatusOK},
+		{name: "not ready", method: http.MethodGet, target: "/readyz", notReady: true, expectedStatusCode: http.StatusServiceUnavailable},
+		{name: "alive", method: http.MethodGet, target: "/healthz", expectedStatusCode: http.StatusOK},
+		{name: "not alive", method: http.MethodGet, target: "/healthz", notReady: true, expectedStatusCode: http.StatusServiceUnavailable},
+		{
+			name:   "command accepted",
+			method: http.MethodPost, target: "/mars/pictures/largest/command", body: `{"sol": 123}`,
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(
+					domain.NewJob(domain.NewJobData{ID: "job-123", Sol: 123, Status: domain.JobStatusQueued}), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "command rejected by validator",
+			method: http.MethodPost, target: "/mars/pictures/largest/command", body: `{"sol": -1}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "command not published",
+			method: http.MethodPost, target: "/mars/pictures/largest/command", body: `{"sol": 123}`,
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(domain.Job{}, errors.New("broker is down"))
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "command replayed",
+			method: http.MethodPost, target: "/mars/pictures/largest/command", body: `{"sol": 123}`,
+			headers: map[string]string{IdempotencyKeyHeader: "key-1"},
+			idempotencySetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, mock.Anything, mock.Anything).Return(domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+					RequestHash: domain.HashIdempotentRequest(http.MethodPost, "/mars/pictures/largest/command", []byte(`{"sol": 123}`)),
+					StatusCode:  http.StatusOK,
+					Body:        []byte(`{"sol":123,"job_id":"job-123","message":"Command accepted."}`),
+				}), false, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "command with idempotency key in progress",
+			method: http.MethodPost, target: "/mars/pictures/largest/command", body: `{"sol": 123}`,
+			headers: map[string]string{IdempotencyKeyHeader: "key-1"},
+			idempotencySetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, mock.Anything, mock.Anything).Return(domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+					RequestHash: domain.HashIdempotentRequest(http.MethodPost, "/mars/pictures/largest/command", []byte(`{"sol": 123}`)),
+				}), false, nil)
+			},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:   "command with reused idempotency key",
+			method: http.MethodPost, target: "/mars/pictures/largest/command", body: `{"sol": 124}`,
+			headers: map[string]string{IdempotencyKeyHeader: "key-1"},
+			idempotencySetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, mock.Anything, mock.Anything).Return(domain.NewIdempotencyRecord(domain.NewIdempotencyRecordData{
+					RequestHash: "other", StatusCode: http.StatusOK,
+				}), false, nil)
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "command idempotency key not checked",
+			method: http.MethodPost, target: "/mars/pictures/largest/command", body: `{"sol": 123}`,
+			headers: map[string]string{IdempotencyKeyHeader: "key-1"},
+			idempotencySetup: func(m *mocks.IdempotencyStore) {
+				m.On("Reserve", mock.Anything, mock.Anything, mock.Anything).Return(domain.IdempotencyRecord{}, false, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "largest picture",
+			method: http.MethodGet, target: "/mars/pictures/largest/command/123",
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetPictureBySol", mock.Anything, 123).Return(picture, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "largest picture not modified",
+			method: http.MethodGet, target: "/mars/pictures/largest/command/123", headers: map[string]string{"If-None-Match": "*"},
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetPictureBySol", mock.Anything, 123).Return(picture, nil)
+			},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:   "largest picture of invalid sol",
+			method: http.MethodGet, target: "/mars/pictures/largest/command/abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "largest picture not computed",
+			method: http.MethodGet, target: "/mars/pictures/largest/command/123",
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetPictureBySol", mock.Anything, 123).Return(domain.Picture{}, domain.ErrNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "largest picture could not be read",
+			method: http.MethodGet, target: "/mars/pictures/largest/command/123",
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetPictureBySol", mock.Anything, 123).Return(domain.Picture{}, domain.ErrCalculationLargestPicture)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "job events",
+			method: http.MethodGet, target: "/mars/pictures/largest/events?sol=123",
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("SubscribeJobEvents", mock.Anything, domain.JobEventFilter{Sol: 123}, int64(0)).
+					Return((<-chan domain.JobEvent)(closedEvents))
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "job events with invalid sol",
+			method: http.MethodGet, target: "/mars/pictures/largest/events?sol=abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "job events without streaming",
+			method: http.MethodGet, target: "/mars/pictures/largest/events", cannotStream: true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "leaderboard",
+			method: http.MethodGet, target: "/mars/pictures/leaderboard?limit=5&from=2012-08-06",
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetLeaderboard", mock.Anything, mock.Anything).Return([]domain.Picture{picture}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "leaderboard with invalid limit",
+			method: http.MethodGet, target: "/mars/pictures/leaderboard?limit=1000",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "leaderboard could not be read",
+			method: http.MethodGet, target: "/mars/pictures/leaderboard",
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetLeaderboard", mock.Anything, mock.Anything).Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "webhook created",
+			method: http.MethodPost, target: "/webhooks", body: webhookRequest,
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("CreateSubscription", mock.Anything, mock.Anything).Return(subscription, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:   "webhook with unknown event type",
+			method: http.MethodPost, target: "/webhooks",
+			body:               `{"url": "https://example.com/hook", "secret": "0123456789abcdef", "event_types": ["queued"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "webhook could not be created",
+			method: http.MethodPost, target: "/webhooks", body: webhookRequest,
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("CreateSubscription", mock.Anything, mock.Anything).Return(domain.WebhookSubscription{}, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "webhooks",
+			method: http.MethodGet, target: "/webhooks",
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("ListSubscriptions", mock.Anything).Return([]domain.WebhookSubscription{subscription}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "webhooks could not be read",
+			method: http.MethodGet, target: "/webhooks",
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("ListSubscriptions", mock.Anything).Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "webhook deleted",
+			method: http.MethodDelete, target: "/webhooks/sub-1",
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("DeleteSubscription", mock.Anything, "sub-1").Return(nil)
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:   "webhook to delete not found",
+			method: http.MethodDelete, target: "/webhooks/sub-1",
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("DeleteSubscription", mock.Anything, "sub-1").Return(domain.ErrNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "webhook could not be deleted",
+			method: http.MethodDelete, target: "/webhooks/sub-1",
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("DeleteSubscription", mock.Anything, "sub-1").Return(errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "webhook deliveries",
+			method: http.MethodGet, target: "/webhooks/sub-1/deliveries?limit=10",
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("ListDeliveries", mock.Anything, "sub-1", 10).Return([]domain.WebhookDelivery{{
+					ID:             1,
+					SubscriptionID: "sub-1",
+					EventType:      domain.JobEventCompleted,
+					JobID:          "job-123",
+					Attempt:        1,
+					StatusCode:     http.StatusOK,
+					Succeeded:      true,
+					Duration:       15 * time.Millisecond,
+					CreatedAt:      time.Now(),
+				}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "webhook deliveries with invalid limit",
+			method: http.MethodGet, target: "/webhooks/sub-1/deliveries?limit=0",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "webhook deliveries with limit over maximum",
+			method: http.MethodGet, target: "/webhooks/sub-1/deliveries?limit=1000",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "webhook deliveries could not be read",
+			method: http.MethodGet, target: "/webhooks/sub-1/deliveries",
+			webhooksMockSetup: func(m *mocks.WebhookManager) {
+				m.On("ListDeliveries", mock.Anything, "sub-1", domain.DefaultWebhookDeliveriesLimit).Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "graphql query",
+			method: http.MethodPost, target: "/graphql", body: `{"query": "{ job(id: \"job-123\") { id status } }"}`,
+			picturesMockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetJob", mock.Anything, "job-123").Return(
+					domain.NewJob(domain.NewJobData{ID: "job-123", Sol: 123, Status: domain.JobStatusQueued}), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "graphql request rejected by validator",
+			method: http.MethodPost, target: "/graphql", body: `{"variables": {}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "api key created",
+			method: http.MethodPost, target: "/admin/api-keys", body: `{"name": "ci", "scopes": ["read"]}`,
+			headers: map[string]string{"Authorization": "Bearer " + adminToken},
+			apiKeysMockSetup: func(m *mocks.ApikeyManager) {
+				m.On("CreateAPIKey", mock.Anything, mock.Anything).Return(apiKey, "mpk_0123456789", nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:   "api key rejected by validator",
+			method: http.MethodPost, target: "/admin/api-keys", body: `{"name": "ci", "scopes": []}`,
+			headers:            map[string]string{"Authorization": "Bearer " + adminToken},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "api key without admin token",
+			method: http.MethodPost, target: "/admin/api-keys", body: `{"name": "ci", "scopes": ["read"]}`,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "api key not saved",
+			method: http.MethodPost, target: "/admin/api-keys", body: `{"name": "ci", "scopes": ["read"]}`,
+			headers: map[string]string{"Authorization": "Bearer " + adminToken},
+			apiKeysMockSetup: func(m *mocks.ApikeyManager) {
+				m.On("CreateAPIKey", mock.Anything, mock.Anything).Return(domain.APIKey{}, "", errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "api keys listed",
+			method: http.MethodGet, target: "/admin/api-keys",
+			headers: map[string]string{"Authorization": "Bearer " + adminToken},
+			apiKeysMockSetup: func(m *mocks.ApikeyManager) {
+				m.On("ListAPIKeys", mock.Anything).Return([]domain.APIKey{apiKey}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "api keys without admin token",
+			method: http.MethodGet, target: "/admin/api-keys",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "api keys not listed",
+			method: http.MethodGet, target: "/admin/api-keys",
+			headers: map[string]string{"Authorization": "Bearer " + adminToken},
+			apiKeysMockSetup: func(m *mocks.ApikeyManager) {
+				m.On("ListAPIKeys", mock.Anything).Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "api key revoked",
+			method: http.MethodDelete, target: "/admin/api-keys/key-1",
+			headers:            map[string]string{"Authorization": "Bearer " + adminToken},
+			apiKeysMockSetup:   func(m *mocks.ApikeyManager) { m.On("RevokeAPIKey", mock.Anything, "key-1").Return(nil) },
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:   "api key revoked without admin token",
+			method: http.MethodDelete, target: "/admin/api-keys/key-1",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "api key to revoke not found",
+			method: http.MethodDelete, target: "/admin/api-keys/key-1",
+			headers: map[string]string{"Authorization": "Bearer " + adminToken},
+			apiKeysMockSetup: func(m *mocks.ApikeyManager) {
+				m.On("RevokeAPIKey", mock.Anything, "key-1").Return(domain.ErrNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "api key not revoked",
+			method: http.MethodDelete, target: "/admin/api-keys/key-1",
+			headers: map[string]string{"Authorization": "Bearer " + adminToken},
+			apiKeysMockSetup: func(m *mocks.ApikeyManager) {
+				m.On("RevokeAPIKey", mock.Anything, "key-1").Return(errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	doc, err := LoadOpenAPI()
+	require.NoError(t, err)
+	docRouter, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+	validator, err := NewRequestValidator(doc)
+	require.NoError(t, err)
+
+	exercised := make(map[string]bool)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			picturesMock := mocks.NewMarsApiLargestPictureService(t)
+			if tc.picturesMockSetup != nil {
+				tc.picturesMockSetup(picturesMock)
+			}
+			webhooksMock := mocks.NewWebhookManager(t)
+			if tc.webhooksMockSetup != nil {
+				tc.webhooksMockSetup(webhooksMock)
+			}
+			router := mux.NewRouter()
+			router.Use(NewMetricsMiddleware())
+			router.Use(validator)
+			router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+			healthHandler := NewHealthHandler(map[string]HealthCheck{
+				"queue": func(ctx context.Context) error {
+					if tc.notReady {
+						return errors.New("connection closed")
+					}
+					return nil
+				},
+			})
+			router.HandleFunc("/healthz", healthHandler).Methods(http.MethodGet)
+			router.HandleFunc("/readyz", healthHandler).Methods(http.MethodGet)
+			idempotencyMock := mocks.NewIdempotencyStore(t)
+			if tc.idempotencySetup != nil {
+				tc.idempotencySetup(idempotencyMock)
+			}
+			NewHttpServer(picturesMock, webhooksMock).WithIdempotency(idempotencyMock, time.Hour, zerolog.Nop()).RegisterRoutes(router)
+			apiKeysMock := mocks.NewApikeyManager(t)
+			if tc.apiKeysMockSetup != nil {
+				tc.apiKeysMockSetup(apiKeysMock)
+			}
+			NewAdminServer(apiKeysMock, adminToken).RegisterRoutes(router)
+			graphqlServer, err := graphqlserver.NewGraphqlServer(
+				picturesMock, graphqlmocks.NewCameraCounter(t), graphqlserver.DefaultMaxDepth, graphqlserver.DefaultMaxComplexity)
+			require.NoError(t, err)
+			router.Handle("/graphql", graphqlServer).Methods(http.MethodPost)
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			// When
+			if tc.cannotStream {
+				router.ServeHTTP(nonFlushingRecorder{recorder: w}, req)
+			} else {
+				router.ServeHTTP(w, req)
+			}
+
+			// Then
+			require.Equal(t, tc.expectedStatusCode, w.Code, w.Body.String())
+
+			documented := httptest.NewRequest(tc.method, tc.target, nil)
+			route, pathParams, err := docRouter.FindRoute(documented)
+			require.NoError(t, err, "route is missing from openapi document")
+			require.NotNil(t, route.Operation.Responses.Status(w.Code), "status %d is not documented", w.Code)
+			exercised[fmt.Sprintf("%s %s %d", tc.method, route.Path, w.Code)] = true
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    documented,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status:  w.Code,
+				Header:  w.Header(),
+				Body:    io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+				Options: &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			require.NoError(t, err)
+		})
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method, operation := range item.Operations() {
+			for status := range operation.Responses.Map() {
+				code, err := strconv.Atoi(status)
+				require.NoError(t, err)
+				key := fmt.Sprintf("%s %s %d", method, path, code)
+				require.True(t, exercised[key], "documented response %s is not exercised", key)
+			}
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package httpserver
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
@@ -25,17 +28,26 @@ func (h HttpServer) PostCommandHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.largestPictureService.PublishCommand(r.Context(), request.Sol)
+	job, err := h.largestPictureService.PublishCommand(r.Context(), request.Sol)
 
 	if err != nil {
 		server.BadRequest("could-not-publish-command", err, w, r)
 		return
 	}
 	server.RespondOK(server.SuccessResponse{
+		Sol:     job.GetSol(),
+		JobID:   job.GetID(),
 		Message: "Command accepted. Largest picture calculation has started.",
 	}, w)
 }
 
+const (
+	// completedSolCacheControl lets clients and proxies keep a computed sol for a day,
+	// a recomputed one is picked up through ETag revalidation afterwards
+	completedSolCacheControl = "public, max-age=86400"
+	pendingSolCacheControl   = "no-store"
+)
+
 func (h HttpServer) GetLargestPictureHandler(w http.ResponseWriter, r *http.Request) {
 	solStr := mux.Vars(r)["sol"] // Extract path variable
 	sol, err := strconv.Atoi(solStr)
@@ -43,8 +55,10 @@ func (h HttpServer) GetLargestPictureHandler(w http.ResponseWriter, r *http.Requ
 		server.BadRequest("invalid-command", errors.New("invalid sol"), w, r)
 		return
 	}
-	picture, err := h.largestPictureService.GetPictureBySol(context.Background(), sol)
+	picture, err := h.largestPictureService.GetPictureBySol(r.Context(), sol)
 	if err != nil && errors.Is(err, domain.ErrNotFound) {
+		// The sol may be computing right now, clients must not keep the miss
+		w.Header().Set("Cache-Control", pendingSolCacheControl)
 		server.NotFound("not-found", domain.ErrNotFound, w, r)
 		return
 	}
@@ -52,9 +66,74 @@ func (h HttpServer) GetLargestPictureHandler(w http.ResponseWriter, r *http.Requ
 		server.InternalError("could-not-get-picture", err, w, r)
 		return
 	}
-	server.RespondOK(server.SuccessResponse{
+	server.RespondCacheable(server.SuccessResponse{
 		Sol:    picture.GetSol(),
 		ImgSrc: picture.GetUrl(),
 		Size:   picture.GetSize(),
-	}, w)
+	}, picture.GetUpdatedAt(), completedSolCacheControl, w, r)
+}
+
+func (h HttpServer) GetLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLeaderboardFilter(r.URL.Query())
+	if err != nil {
+		server.BadRequest("invalid-leaderboard-query", err, w, r)
+		return
+	}
+	pictures, err := h.largestPictureService.GetLeaderboard(r.Context(), filter)
+	if err != nil {
+		server.InternalError("could-not-get-leaderboard", err, w, r)
+		return
+	}
+	response := LeaderboardResponse{Pictures: make([]PictureResponse, 0, len(pictures))}
+	for _, picture := range pictures {
+		response.Pictures = append(response.Pictures, toPictureResponse(picture))
+	}
+	server.RespondJSON(response, w)
+}
+
+func parseLeaderboardFilter(query url.Values) (domain.LeaderboardFilter, error) {
+	filter := domain.LeaderboardFilter{
+		Limit:  domain.DefaultLeaderboardLimit,
+		Rover:  strings.ToLower(query.Get("rover")),
+		Camera: strings.ToUpper(query.Get("camera")),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > domain.MaxLeaderboardLimit {
+			return domain.LeaderboardFilter{}, fmt.Errorf("limit must be between 1 and %d", domain.MaxLeaderboardLimit)
+		}
+		filter.Limit = limit
+	}
+	var err error
+	if filter.From, err = parseDate(query.Get("from")); err != nil {
+		return domain.LeaderboardFilter{}, fmt.Errorf("invalid from date: %w", err)
+	}
+	if filter.To, err = parseDate(query.Get("to")); err != nil {
+		return domain.LeaderboardFilter{}, fmt.Errorf("invalid to date: %w", err)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return domain.LeaderboardFilter{}, errors.New("from date must not be after to date")
+	}
+	return filter, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func toPictureResponse(picture domain.Picture) PictureResponse {
+	pictureResponse := PictureResponse{
+		Sol:    picture.GetSol(),
+		ImgSrc: picture.GetUrl(),
+		Size:   picture.GetSize(),
+		Rover:  picture.GetRover(),
+		Camera: picture.GetCamera(),
+	}
+	if !picture.GetEarthDate().IsZero() {
+		pictureResponse.EarthDate = picture.GetEarthDate().Format(time.DateOnly)
+	}
+	return pictureResponse
 }
@@ -3,19 +3,23 @@ package httpserver
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
 	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
 )
 
 const commandEndpoint = "/mars/pictures/largest/command"
 const getLargestPictureEndpoint = "/mars/pictures/largest/command/{sol}"
+const leaderboardEndpoint = "/mars/pictures/leaderboard"
 
 func TestHttpServer_PostCommandHandler(t *testing.T) {
 	testCases := []struct {
@@ -28,12 +32,27 @@ func TestHttpServer_PostCommandHandler(t *testing.T) {
 		{
 			name: "Should return successful response when request is valid",
 			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
-				m.On("PublishCommand", mock.Anything, 123).Return(nil)
+				m.On("PublishCommand", mock.Anything, 123).Return(
+					domain.NewJob(domain.NewJobData{ID: "job-123", Sol: 123, Status: domain.JobStatusQueued}),
+					nil,
+				)
 			},
 			requestBody:        []byte(`{"sol": 123}`),
 			expectedStatusCode: http.StatusOK,
 			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
 				require.Equal(t, "Command accepted. Largest picture calculation has started.", body["message"])
+				require.Equal(t, "job-123", body["job_id"])
+			},
+		},
+		{
+			name: "Should return bad request when command could not be published",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("PublishCommand", mock.Anything, 123).Return(domain.Job{}, errors.New("broker is down"))
+			},
+			requestBody:        []byte(`{"sol": 123}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "could-not-publish-command", body["slug"])
 			},
 		},
 		{
@@ -71,7 +90,7 @@ func TestHttpServer_PostCommandHandler(t *testing.T) {
 			largestPictureServiceMock := mocks.NewMarsApiLargestPictureService(t)
 			tc.mockSetup(largestPictureServiceMock)
 
-			httpServer := NewHttpServer(largestPictureServiceMock)
+			httpServer := NewHttpServer(largestPictureServiceMock, nil)
 
 			req := httptest.NewRequest(http.MethodPost, commandEndpoint, bytes.NewBuffer(tc.requestBody))
 			w := httptest.NewRecorder()
@@ -122,7 +141,8 @@ func TestHttpServer_GetLargestPictureHandler(t *testing.T) {
 				require.Equal(t, 123.0, body["sol"])
 				require.Equal(t, "http://example.com/largest.jpg", body["img_src"])
 				require.Equal(t, 1048576.0, body["size"])
-				require.Equal(t, "Command accepted. Largest picture calculation has started.", body["message"])
+				// The command message belongs to POST, a picture read carries none
+				require.NotContains(t, body, "message")
 			},
 		},
 		{
@@ -153,7 +173,7 @@ func TestHttpServer_GetLargestPictureHandler(t *testing.T) {
 			largestPictureServiceMock := mocks.NewMarsApiLargestPictureService(t)
 			tc.mockSetup(largestPictureServiceMock)
 
-			httpServer := NewHttpServer(largestPictureServiceMock)
+			httpServer := NewHttpServer(largestPictureServiceMock, nil)
 
 			router := mux.NewRouter()
 			router.HandleFunc(getLargestPictureEndpoint, httpServer.GetLargestPictureHandler).Methods(http.MethodGet)
@@ -183,3 +203,232 @@ func TestHttpServer_GetLargestPictureHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestHttpServer_GetLargestPictureHandler_HTTPCaching(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	picture := domain.NewPicture(domain.NewPictureData{
+		Sol:       123,
+		Url:       "http://example.com/largest.jpg",
+		Size:      1048576,
+		UpdatedAt: updatedAt,
+	})
+	// ETag of the body served for picture
+	etag := server.ETag([]byte(`{"sol":123,"img_src":"http://example.com/largest.jpg","size":1048576}` + "\n"))
+
+	testCases := []struct {
+		name                 string
+		headers              map[string]string
+		expectedStatusCode   int
+		expectedCacheControl string
+	}{
+		{
+			name:                 "Should return picture with validators when request is unconditional",
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: completedSolCacheControl,
+		},
+		{
+			name:                 "Should return not modified when etag matches",
+			headers:              map[string]string{"If-None-Match": etag},
+			expectedStatusCode:   http.StatusNotModified,
+			expectedCacheControl: completedSolCacheControl,
+		},
+		{
+			name:                 "Should return not modified when one of the etags matches",
+			headers:              map[string]string{"If-None-Match": `"stale", ` + etag},
+			expectedStatusCode:   http.StatusNotModified,
+			expectedCacheControl: completedSolCacheControl,
+		},
+		{
+			name:                 "Should return picture when etag does not match",
+			headers:              map[string]string{"If-None-Match": `"stale"`},
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: completedSolCacheControl,
+		},
+		{
+			name:                 "Should return not modified when picture was not saved since",
+			headers:              map[string]string{"If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			expectedStatusCode:   http.StatusNotModified,
+			expectedCacheControl: completedSolCacheControl,
+		},
+		{
+			name:                 "Should return picture when it was saved since",
+			headers:              map[string]string{"If-Modified-Since": updatedAt.Add(-time.Hour).Format(http.TimeFormat)},
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: completedSolCacheControl,
+		},
+		{
+			name: "Should ignore If-Modified-Since when If-None-Match is present",
+			headers: map[string]string{
+				"If-None-Match":     `"stale"`,
+				"If-Modified-Since": updatedAt.Format(http.TimeFormat),
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: completedSolCacheControl,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			largestPictureServiceMock := mocks.NewMarsApiLargestPictureService(t)
+			largestPictureServiceMock.On("GetPictureBySol", mock.Anything, 123).Return(picture, nil)
+			httpServer := NewHttpServer(largestPictureServiceMock, nil)
+			router := mux.NewRouter()
+			router.HandleFunc(getLargestPictureEndpoint, httpServer.GetLargestPictureHandler).Methods(http.MethodGet)
+
+			req := httptest.NewRequest(http.MethodGet, "/mars/pictures/largest/command/123", nil)
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			// When
+			router.ServeHTTP(w, req)
+
+			// Then
+			res := w.Result()
+			defer res.Body.Close()
+			require.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			require.Equal(t, etag, res.Header.Get("ETag"))
+			require.Equal(t, updatedAt.Format(http.TimeFormat), res.Header.Get("Last-Modified"))
+			require.Equal(t, tc.expectedCacheControl, res.Header.Get("Cache-Control"))
+			if tc.expectedStatusCode == http.StatusNotModified {
+				require.Zero(t, w.Body.Len())
+			}
+		})
+	}
+
+	t.Run("Should forbid caching when sol is not computed yet", func(t *testing.T) {
+		// Given
+		largestPictureServiceMock := mocks.NewMarsApiLargestPictureService(t)
+		largestPictureServiceMock.On("GetPictureBySol", mock.Anything, 123).Return(domain.Picture{}, domain.ErrNotFound)
+		httpServer := NewHttpServer(largestPictureServiceMock, nil)
+		router := mux.NewRouter()
+		router.HandleFunc(getLargestPictureEndpoint, httpServer.GetLargestPictureHandler).Methods(http.MethodGet)
+		w := httptest.NewRecorder()
+
+		// When
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mars/pictures/largest/command/123", nil))
+
+		// Then
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, pendingSolCacheControl, w.Header().Get("Cache-Control"))
+		require.Empty(t, w.Header().Get("ETag"))
+	})
+}
+
+func TestHttpServer_GetLeaderboardHandler(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		query                      string
+		mockSetup                  func(m *mocks.MarsApiLargestPictureService)
+		expectedStatusCode         int
+		expectedResponseBodyShould func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name:  "Should return leaderboard with filters applied",
+			query: "?limit=50&rover=Curiosity&camera=fhaz&from=2015-01-01&to=2015-12-31",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetLeaderboard", mock.Anything, domain.LeaderboardFilter{
+					Limit:  50,
+					Rover:  "curiosity",
+					Camera: "FHAZ",
+					From:   time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:     time.Date(2015, 12, 31, 0, 0, 0, 0, time.UTC),
+				}).Return([]domain.Picture{
+					domain.NewPicture(domain.NewPictureData{
+						Sol:       1000,
+						Url:       "http://example.com/largest.jpg",
+						Size:      2048,
+						Rover:     "curiosity",
+						Camera:    "FHAZ",
+						EarthDate: time.Date(2015, 5, 30, 0, 0, 0, 0, time.UTC),
+					}),
+					domain.NewPicture(domain.NewPictureData{Sol: 1001, Url: "http://example.com/second.jpg", Size: 1024}),
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				pictures := body["pictures"].([]interface{})
+				require.Len(t, pictures, 2)
+				first := pictures[0].(map[string]interface{})
+				require.Equal(t, 1000.0, first["sol"])
+				require.Equal(t, 2048.0, first["size"])
+				require.Equal(t, "FHAZ", first["camera"])
+				require.Equal(t, "2015-05-30", first["earth_date"])
+				require.NotContains(t, pictures[1], "earth_date")
+			},
+		},
+		{
+			name:  "Should use default limit when limit is not provided",
+			query: "",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetLeaderboard", mock.Anything, domain.LeaderboardFilter{Limit: domain.DefaultLeaderboardLimit}).
+					Return([]domain.Picture{}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Empty(t, body["pictures"])
+			},
+		},
+		{
+			name:               "Should return bad request when limit is out of range",
+			query:              "?limit=1000",
+			mockSetup:          func(m *mocks.MarsApiLargestPictureService) {}, // No mock needed
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "invalid-leaderboard-query", body["slug"])
+			},
+		},
+		{
+			name:               "Should return bad request when date range is inverted",
+			query:              "?from=2016-01-01&to=2015-01-01",
+			mockSetup:          func(m *mocks.MarsApiLargestPictureService) {}, // No mock needed
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "invalid-leaderboard-query", body["slug"])
+			},
+		},
+		{
+			name:  "Should return internal error when service fails",
+			query: "?limit=5",
+			mockSetup: func(m *mocks.MarsApiLargestPictureService) {
+				m.On("GetLeaderboard", mock.Anything, domain.LeaderboardFilter{Limit: 5}).Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "could-not-get-leaderboard", body["slug"])
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			largestPictureServiceMock := mocks.NewMarsApiLargestPictureService(t)
+			tc.mockSetup(largestPictureServiceMock)
+
+			httpServer := NewHttpServer(largestPictureServiceMock, nil)
+
+			req := httptest.NewRequest(http.MethodGet, leaderboardEndpoint+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			// when
+			httpServer.GetLeaderboardHandler(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, res.StatusCode)
+
+			var responseBody map[string]interface{}
+			err := json.NewDecoder(res.Body).Decode(&responseBody)
+			require.NoError(t, err)
+
+			tc.expectedResponseBodyShould(t, responseBody)
+
+			largestPictureServiceMock.AssertExpectations(t)
+		})
+	}
+}
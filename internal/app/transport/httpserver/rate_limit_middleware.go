@@ -0,0 +1,94 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/logging"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// RateLimits are the token buckets given to every client, reads and submits are limited separately
+type RateLimits struct {
+	Read   domain.RateLimit
+	Submit domain.RateLimit
+}
+
+// NewRateLimitMiddleware limits every route except publicPaths with a token bucket per client and scope.
+// Clients are told apart by API key when the auth middleware ran before, by IP otherwise. The IP is taken
+// from clientIPHeader when it is set, it must name a header set by a trusted reverse proxy.
+// Every response carries RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy,
+// requests over the limit get 429 with Retry-After. When the store fails requests are let through.
+func NewRateLimitMiddleware(store RateLimitStore, limits RateLimits, publicPaths []string, clientIPHeader string, logger zerolog.Logger) mux.MiddlewareFunc {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := routePath(r)
+			if public[path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scope := requestScope(r, path)
+			limit := limits.Read
+			if scope == domain.APIKeyScopeSubmit {
+				limit = limits.Submit
+			}
+			decision, err := store.Take(r.Context(), rateLimitKey(r, scope, clientIPHeader), limit, time.Now())
+			if err != nil {
+				logging.From(r.Context(), logger).Error().Err(err).Msg("rate limit store failed, letting request through")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(decision.Reset), 10))
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.RefillTime())))
+			if !decision.Allowed {
+				server.TooManyRequests("rate-limited", errors.New("rate limit exceeded"), decision.RetryAfter, w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitKey(r *http.Request, scope domain.APIKeyScope, clientIPHeader string) string {
+	if key, ok := server.APIKeyFromContext(r.Context()); ok {
+		return "key:" + key.GetID() + ":" + string(scope)
+	}
+	return "ip:" + clientIP(r, clientIPHeader) + ":" + string(scope)
+}
+
+// clientIP is the address the request came from. With header set it is the last address in it, the one
+// added by the proxy in front of the service for X-Forwarded-For, the peer address is used when it has none.
+func clientIP(r *http.Request, header string) string {
+	if values := r.Header.Values(header); header != "" && len(values) > 0 {
+		addresses := strings.Split(values[len(values)-1], ",")
+		if ip := net.ParseIP(strings.TrimSpace(addresses[len(addresses)-1])); ip != nil {
+			return ip.String()
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,171 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limits := RateLimits{Read: domain.NewRateLimit(600, 100), Submit: domain.NewRateLimit(60, 10)}
+	key := domain.NewAPIKey(domain.NewAPIKeyData{ID: "key-1"})
+
+	testCases := []struct {
+		name               string
+		method             string
+		target             string
+		body               string
+		headers            map[string]string
+		clientIPHeader     string
+		apiKey             *domain.APIKey
+		mockSetup          func(m *mocks.RateLimitStore)
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:               "Should not limit public route",
+			method:             http.MethodGet,
+			target:             "/readyz",
+			mockSetup:          func(m *mocks.RateLimitStore) {},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"RateLimit-Limit": ""},
+		},
+		{
+			name:   "Should limit reads by client IP",
+			method: http.MethodGet,
+			target: "/mars/pictures/leaderboard",
+			mockSetup: func(m *mocks.RateLimitStore) {
+				m.On("Take", mock.Anything, "ip:192.0.2.1:read", limits.Read, mock.Anything).Return(
+					domain.RateLimitDecision{Allowed: true, Limit: 100, Remaining: 99, Reset: 100 * time.Millisecond}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "100",
+				"RateLimit-Remaining": "99",
+				"RateLimit-Reset":     "1",
+				"RateLimit-Policy":    "100;w=10",
+			},
+		},
+		{
+			name:   "Should limit submits by API key",
+			method: http.MethodPost,
+			target: "/mars/pictures/largest/command",
+			apiKey: &key,
+			mockSetup: func(m *mocks.RateLimitStore) {
+				m.On("Take", mock.Anything, "key:key-1:submit", limits.Submit, mock.Anything).Return(
+					domain.RateLimitDecision{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"RateLimit-Remaining": "9", "RateLimit-Policy": "10;w=10"},
+		},
+		{
+			name:   "Should limit GraphQL mutations as submits",
+			method: http.MethodPost,
+			target: "/graphql",
+			body:   `{"query":"mutation { submitCommand(sol: 1) { id } }"}`,
+			mockSetup: func(m *mocks.RateLimitStore) {
+				m.On("Take", mock.Anything, "ip:192.0.2.1:submit", limits.Submit, mock.Anything).Return(
+					domain.RateLimitDecision{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"RateLimit-Policy": "10;w=10"},
+		},
+		{
+			name:           "Should limit client by address added by trusted proxy",
+			method:         http.MethodGet,
+			target:         "/mars/pictures/leaderboard",
+			headers:        map[string]string{"X-Forwarded-For": "203.0.113.7, 198.51.100.4"},
+			clientIPHeader: "X-Forwarded-For",
+			mockSetup: func(m *mocks.RateLimitStore) {
+				m.On("Take", mock.Anything, "ip:198.51.100.4:read", limits.Read, mock.Anything).Return(
+					domain.RateLimitDecision{Allowed: true, Limit: 100, Remaining: 99}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "Should ignore forwarded address without trusted proxy",
+			method:  http.MethodGet,
+			target:  "/mars/pictures/leaderboard",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			mockSetup: func(m *mocks.RateLimitStore) {
+				m.On("Take", mock.Anything, "ip:192.0.2.1:read", limits.Read, mock.Anything).Return(
+					domain.RateLimitDecision{Allowed: true, Limit: 100, Remaining: 99}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "Should reject request over limit",
+			method: http.MethodPost,
+			target: "/mars/pictures/largest/command",
+			mockSetup: func(m *mocks.RateLimitStore) {
+				m.On("Take", mock.Anything, "ip:192.0.2.1:submit", limits.Submit, mock.Anything).Return(
+					domain.RateLimitDecision{Limit: 10, Reset: 10 * time.Second, RetryAfter: 800 * time.Millisecond}, nil)
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedHeaders:    map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "10", "Retry-After": "1"},
+		},
+		{
+			name:   "Should let request through when store fails",
+			method: http.MethodGet,
+			target: "/mars/pictures/leaderboard",
+			mockSetup: func(m *mocks.RateLimitStore) {
+				m.On("Take", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+					domain.RateLimitDecision{}, errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"RateLimit-Limit": ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			storeMock := mocks.NewRateLimitStore(t)
+			tc.mockSetup(storeMock)
+
+			handler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+			router := mux.NewRouter()
+			router.Use(NewRateLimitMiddleware(storeMock, limits, PublicPaths, tc.clientIPHeader, zerolog.Nop()))
+			router.HandleFunc("/readyz", handler).Methods(http.MethodGet)
+			router.HandleFunc("/mars/pictures/leaderboard", handler).Methods(http.MethodGet)
+			router.HandleFunc("/mars/pictures/largest/command", handler).Methods(http.MethodPost)
+			router.HandleFunc("/graphql", handler).Methods(http.MethodPost)
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			req.RemoteAddr = "192.0.2.1:54321"
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			if tc.apiKey != nil {
+				req = req.WithContext(server.WithAPIKey(req.Context(), *tc.apiKey))
+			}
+			w := httptest.NewRecorder()
+
+			// when
+			router.ServeHTTP(w, req)
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, w.Code)
+			for header, value := range tc.expectedHeaders {
+				require.Equal(t, value, w.Header().Get(header), header)
+			}
+			if tc.expectedStatusCode == http.StatusTooManyRequests {
+				var body map[string]interface{}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+				require.Equal(t, "rate-limited", body["slug"])
+			}
+		})
+	}
+}
@@ -1,11 +1,51 @@
 package httpserver
 
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
 type HttpServer struct {
 	largestPictureService MarsApiLargestPictureService
+	webhookManager        WebhookManager
+	idempotencyStore      IdempotencyStore
+	idempotencyTTL        time.Duration
+	logger                zerolog.Logger
 }
 
-func NewHttpServer(largestPictureService MarsApiLargestPictureService) HttpServer {
+func NewHttpServer(largestPictureService MarsApiLargestPictureService, webhookManager WebhookManager) HttpServer {
 	return HttpServer{
 		largestPictureService: largestPictureService,
+		webhookManager:        webhookManager,
 	}
 }
+
+// WithIdempotency makes commands sent with an Idempotency-Key replay their response for ttl
+func (h HttpServer) WithIdempotency(store IdempotencyStore, ttl time.Duration, logger zerolog.Logger) HttpServer {
+	h.idempotencyStore = store
+	h.idempotencyTTL = ttl
+	h.logger = logger
+	return h
+}
+
+// RegisterRoutes registers every API route described by the OpenAPI document on router
+func (h HttpServer) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("NASA Largest Picture API 1.0V"))
+	}).Methods("GET")
+	router.HandleFunc("/openapi.json", OpenAPIHandler).Methods("GET")
+	router.HandleFunc("/docs", DocsHandler).Methods("GET")
+
+	router.HandleFunc("/mars/pictures/largest/command", h.idempotent(h.PostCommandHandler)).Methods("POST")
+	router.HandleFunc("/mars/pictures/largest/command/{sol}", h.GetLargestPictureHandler).Methods("GET")
+	router.HandleFunc("/mars/pictures/largest/events", h.JobEventsHandler).Methods("GET")
+	router.HandleFunc("/mars/pictures/leaderboard", h.GetLeaderboardHandler).Methods("GET")
+
+	router.HandleFunc("/webhooks", h.CreateWebhookHandler).Methods("POST")
+	router.HandleFunc("/webhooks", h.ListWebhooksHandler).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", h.DeleteWebhookHandler).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/deliveries", h.ListWebhookDeliveriesHandler).Methods("GET")
+}
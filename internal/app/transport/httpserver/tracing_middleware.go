@@ -0,0 +1,28 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracingMiddleware starts a server span named after the route template for every request,
+// continuing a trace sent by the client in traceparent. It should come first, so the span covers
+// authentication and rate limiting too.
+func NewTracingMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.route", metricsRoute(r)))
+				next.ServeHTTP(w, r)
+			}),
+			"http",
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return r.Method + " " + metricsRoute(r)
+			}),
+		)
+	}
+}
@@ -0,0 +1,112 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/server"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+func (h HttpServer) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var request WebhookSubscriptionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		server.BadRequest("invalid-webhook", err, w, r)
+		return
+	}
+
+	eventTypes := make([]domain.JobEventType, 0, len(request.EventTypes))
+	for _, eventType := range request.EventTypes {
+		eventTypes = append(eventTypes, domain.JobEventType(eventType))
+	}
+	subscription, err := h.webhookManager.CreateSubscription(r.Context(), domain.NewWebhookSubscriptionData{
+		Url:        request.Url,
+		Secret:     request.Secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil && errors.Is(err, domain.ErrInvalidWebhook) {
+		server.BadRequest("invalid-webhook", err, w, r)
+		return
+	}
+	if err != nil {
+		server.InternalError("could-not-create-webhook", err, w, r)
+		return
+	}
+	server.RespondCreated(toWebhookSubscriptionResponse(subscription), w)
+}
+
+func (h HttpServer) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhookManager.ListSubscriptions(r.Context())
+	if err != nil {
+		server.InternalError("could-not-list-webhooks", err, w, r)
+		return
+	}
+	response := WebhookSubscriptionsResponse{Subscriptions: make([]WebhookSubscriptionResponse, 0, len(subscriptions))}
+	for _, subscription := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, toWebhookSubscriptionResponse(subscription))
+	}
+	server.RespondJSON(response, w)
+}
+
+func (h HttpServer) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	err := h.webhookManager.DeleteSubscription(r.Context(), mux.Vars(r)["id"])
+	if err != nil && errors.Is(err, domain.ErrNotFound) {
+		server.NotFound("not-found", domain.ErrNotFound, w, r)
+		return
+	}
+	if err != nil {
+		server.InternalError("could-not-delete-webhook", err, w, r)
+		return
+	}
+	server.RespondNoContent(w)
+}
+
+func (h HttpServer) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	limit := domain.DefaultWebhookDeliveriesLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 || parsedLimit > domain.MaxWebhookDeliveriesLimit {
+			server.BadRequest("invalid-limit", fmt.Errorf("limit must be between 1 and %d", domain.MaxWebhookDeliveriesLimit), w, r)
+			return
+		}
+		limit = parsedLimit
+	}
+	deliveries, err := h.webhookManager.ListDeliveries(r.Context(), mux.Vars(r)["id"], limit)
+	if err != nil {
+		server.InternalError("could-not-list-webhook-deliveries", err, w, r)
+		return
+	}
+	response := WebhookDeliveriesResponse{Deliveries: make([]WebhookDeliveryResponse, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, WebhookDeliveryResponse{
+			ID:         delivery.ID,
+			EventType:  string(delivery.EventType),
+			JobID:      delivery.JobID,
+			Attempt:    delivery.Attempt,
+			StatusCode: delivery.StatusCode,
+			Error:      delivery.Error,
+			Succeeded:  delivery.Succeeded,
+			DurationMs: delivery.Duration.Milliseconds(),
+			CreatedAt:  delivery.CreatedAt,
+		})
+	}
+	server.RespondJSON(response, w)
+}
+
+func toWebhookSubscriptionResponse(subscription domain.WebhookSubscription) WebhookSubscriptionResponse {
+	eventTypes := make([]string, 0, len(subscription.GetEventTypes()))
+	for _, eventType := range subscription.GetEventTypes() {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	return WebhookSubscriptionResponse{
+		ID:         subscription.GetID(),
+		Url:        subscription.GetUrl(),
+		EventTypes: eventTypes,
+		CreatedAt:  subscription.GetCreatedAt(),
+	}
+}
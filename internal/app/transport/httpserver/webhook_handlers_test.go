@@ -0,0 +1,186 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/transport/httpserver/mocks"
+)
+
+const webhooksEndpoint = "/webhooks"
+
+func TestHttpServer_CreateWebhookHandler(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		requestBody                []byte
+		mockSetup                  func(m *mocks.WebhookManager)
+		expectedStatusCode         int
+		expectedResponseBodyShould func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name:        "Should create webhook subscription without echoing secret",
+			requestBody: []byte(`{"url": "https://example.com/hook", "secret": "0123456789abcdef", "event_types": ["completed"]}`),
+			mockSetup: func(m *mocks.WebhookManager) {
+				m.On("CreateSubscription", mock.Anything, domain.NewWebhookSubscriptionData{
+					Url:        "https://example.com/hook",
+					Secret:     "0123456789abcdef",
+					EventTypes: []domain.JobEventType{domain.JobEventCompleted},
+				}).Return(domain.NewWebhookSubscription(domain.NewWebhookSubscriptionData{
+					ID:         "sub-1",
+					Url:        "https://example.com/hook",
+					Secret:     "0123456789abcdef",
+					EventTypes: []domain.JobEventType{domain.JobEventCompleted},
+					CreatedAt:  time.Now(),
+				}), nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "sub-1", body["id"])
+				require.Equal(t, []interface{}{"completed"}, body["event_types"])
+				require.NotContains(t, body, "secret")
+			},
+		},
+		{
+			name:               "Should return bad request for malformed JSON",
+			requestBody:        []byte(`{url:`),
+			mockSetup:          func(m *mocks.WebhookManager) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "invalid-webhook", body["slug"])
+			},
+		},
+		{
+			name:        "Should return bad request when subscription is invalid",
+			requestBody: []byte(`{"url": "ftp://example.com", "secret": "0123456789abcdef"}`),
+			mockSetup: func(m *mocks.WebhookManager) {
+				m.On("CreateSubscription", mock.Anything, mock.Anything).Return(domain.WebhookSubscription{}, domain.ErrInvalidWebhook)
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBodyShould: func(t *testing.T, body map[string]interface{}) {
+				require.Equal(t, "invalid-webhook", body["slug"])
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			webhookManagerMock := mocks.NewWebhookManager(t)
+			tc.mockSetup(webhookManagerMock)
+
+			httpServer := NewHttpServer(nil, webhookManagerMock)
+
+			req := httptest.NewRequest(http.MethodPost, webhooksEndpoint, bytes.NewBuffer(tc.requestBody))
+			w := httptest.NewRecorder()
+
+			// when
+			httpServer.CreateWebhookHandler(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, res.StatusCode)
+
+			var responseBody map[string]interface{}
+			err := json.NewDecoder(res.Body).Decode(&responseBody)
+			require.NoError(t, err)
+
+			tc.expectedResponseBodyShould(t, responseBody)
+
+			webhookManagerMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHttpServer_DeleteWebhookHandler(t *testing.T) {
+	testCases := []struct {
+		name               string
+		mockSetup          func(m *mocks.WebhookManager)
+		expectedStatusCode int
+	}{
+		{
+			name: "Should delete webhook subscription",
+			mockSetup: func(m *mocks.WebhookManager) {
+				m.On("DeleteSubscription", mock.Anything, "sub-1").Return(nil)
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name: "Should return not found for unknown subscription",
+			mockSetup: func(m *mocks.WebhookManager) {
+				m.On("DeleteSubscription", mock.Anything, "sub-1").Return(domain.ErrNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "Should return internal error when repository fails",
+			mockSetup: func(m *mocks.WebhookManager) {
+				m.On("DeleteSubscription", mock.Anything, "sub-1").Return(errors.New("db is down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			webhookManagerMock := mocks.NewWebhookManager(t)
+			tc.mockSetup(webhookManagerMock)
+
+			httpServer := NewHttpServer(nil, webhookManagerMock)
+
+			router := mux.NewRouter()
+			router.HandleFunc(webhooksEndpoint+"/{id}", httpServer.DeleteWebhookHandler).Methods(http.MethodDelete)
+
+			req := httptest.NewRequest(http.MethodDelete, webhooksEndpoint+"/sub-1", nil)
+			w := httptest.NewRecorder()
+
+			// when
+			router.ServeHTTP(w, req)
+
+			// then
+			require.Equal(t, tc.expectedStatusCode, w.Result().StatusCode)
+			webhookManagerMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHttpServer_ListWebhookDeliveriesHandler(t *testing.T) {
+	// given
+	webhookManagerMock := mocks.NewWebhookManager(t)
+	webhookManagerMock.On("ListDeliveries", mock.Anything, "sub-1", 5).Return([]domain.WebhookDelivery{
+		{ID: 2, SubscriptionID: "sub-1", EventType: domain.JobEventCompleted, Attempt: 2, StatusCode: 200, Succeeded: true},
+		{ID: 1, SubscriptionID: "sub-1", EventType: domain.JobEventCompleted, Attempt: 1, StatusCode: 500, Error: "unexpected status code: 500"},
+	}, nil)
+
+	httpServer := NewHttpServer(nil, webhookManagerMock)
+
+	router := mux.NewRouter()
+	router.HandleFunc(webhooksEndpoint+"/{id}/deliveries", httpServer.ListWebhookDeliveriesHandler).Methods(http.MethodGet)
+
+	req := httptest.NewRequest(http.MethodGet, webhooksEndpoint+"/sub-1/deliveries?limit=5", nil)
+	w := httptest.NewRecorder()
+
+	// when
+	router.ServeHTTP(w, req)
+
+	// then
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	var responseBody WebhookDeliveriesResponse
+	err := json.NewDecoder(w.Result().Body).Decode(&responseBody)
+	require.NoError(t, err)
+	require.Len(t, responseBody.Deliveries, 2)
+	require.True(t, responseBody.Deliveries[0].Succeeded)
+	require.Equal(t, 500, responseBody.Deliveries[1].StatusCode)
+}
@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultStopTimeout bounds Component.Stop when the component does not set its own timeout
+const DefaultStopTimeout = 10 * time.Second
+
+// ErrShuttingDown is reported by Lifecycle.Ready once shutdown has started
+var ErrShuttingDown = errors.New("shutting down")
+
+// Component is a long running part of the application owned by Lifecycle.
+// Start and Stop are optional.
+type Component struct {
+	Name string
+	// Start launches the component and must not block. Background work should stop
+	// taking new work once ctx, the shared root context, is canceled.
+	Start func(ctx context.Context) error
+	// Stop drains in-flight work and releases resources before ctx is done
+	Stop func(ctx context.Context) error
+	// StopTimeout overrides DefaultStopTimeout
+	StopTimeout time.Duration
+}
+
+// Lifecycle starts components in the order they were added and stops them in reverse order,
+// so every component is stopped before the dependencies it was started after.
+type Lifecycle struct {
+	components []Component
+	logger     zerolog.Logger
+
+	abortOnce sync.Once
+	aborted   chan struct{}
+	abortErr  error
+
+	shuttingDown atomic.Bool
+}
+
+func NewLifecycle(logger zerolog.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger, aborted: make(chan struct{})}
+}
+
+// Add registers a component. Components must be added in dependency order.
+func (l *Lifecycle) Add(component Component) {
+	l.components = append(l.components, component)
+}
+
+// Abort makes Run shut everything down and return err, e.g. when a server fails to serve
+func (l *Lifecycle) Abort(err error) {
+	l.abortOnce.Do(func() {
+		l.abortErr = err
+		close(l.aborted)
+	})
+}
+
+// Ready fails once Run has started shutting down, so readiness probes take the process
+// out of load balancing while components drain
+func (l *Lifecycle) Ready(ctx context.Context) error {
+	if l.shuttingDown.Load() {
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// Run starts all components and blocks until ctx is done or Abort is called. It then cancels
+// the root context passed to every Start and stops the components one by one.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	rootCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	for i, component := range l.components {
+		if component.Start == nil {
+			continue
+		}
+		if err := component.Start(rootCtx); err != nil {
+			l.shuttingDown.Store(true)
+			cancel()
+			stopErr := l.stop(l.components[:i])
+			return errors.Join(fmt.Errorf("failed to start %s: %w", component.Name, err), stopErr)
+		}
+		l.logger.Info().Str("component", component.Name).Msg("started")
+	}
+
+	select {
+	case <-ctx.Done():
+		l.logger.Info().AnErr("cause", context.Cause(ctx)).Msg("shutting down")
+	case <-l.aborted:
+		l.logger.Error().AnErr("cause", l.abortErr).Msg("shutting down")
+	}
+	l.shuttingDown.Store(true)
+	cancel()
+	return errors.Join(l.abortErr, l.stop(l.components))
+}
+
+// Release stops, in reverse order, the components without a Start, i.e. resources such as connections
+// opened while wiring. It is meant for wiring that fails before Run, when no component has started yet.
+func (l *Lifecycle) Release() error {
+	var resources []Component
+	for _, component := range l.components {
+		if component.Start == nil {
+			resources = append(resources, component)
+		}
+	}
+	return l.stop(resources)
+}
+
+// stop stops components in reverse order, each bounded by its own timeout
+func (l *Lifecycle) stop(components []Component) error {
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		if component.Stop == nil {
+			continue
+		}
+		timeout := component.StopTimeout
+		if timeout <= 0 {
+			timeout = DefaultStopTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		started := time.Now()
+		err := component.Stop(ctx)
+		cancel()
+		if err != nil {
+			l.logger.Error().Err(err).Str("component", component.Name).Dur("duration", time.Since(started)).Msg("stopped")
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", component.Name, err))
+			continue
+		}
+		l.logger.Info().Str("component", component.Name).Dur("duration", time.Since(started)).Msg("stopped")
+	}
+	return errors.Join(errs...)
+}
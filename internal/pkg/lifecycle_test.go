@@ -0,0 +1,167 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedComponent appends its start and stop calls to calls
+func recordedComponent(name string, calls *[]string, startErr error) Component {
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			*calls = append(*calls, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			*calls = append(*calls, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestLifecycle_Run(t *testing.T) {
+	t.Run("Should stop components in reverse order once context is done", func(t *testing.T) {
+		// Given
+		var calls []string
+		lifecycle := NewLifecycle(zerolog.Nop())
+		lifecycle.Add(recordedComponent("db", &calls, nil))
+		lifecycle.Add(recordedComponent("queue", &calls, nil))
+		lifecycle.Add(recordedComponent("http", &calls, nil))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// When
+		err := lifecycle.Run(ctx)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"start db", "start queue", "start http",
+			"stop http", "stop queue", "stop db",
+		}, calls)
+	})
+
+	t.Run("Should stop already started components when start fails", func(t *testing.T) {
+		// Given
+		var calls []string
+		lifecycle := NewLifecycle(zerolog.Nop())
+		lifecycle.Add(recordedComponent("db", &calls, nil))
+		lifecycle.Add(recordedComponent("queue", &calls, errors.New("connection refused")))
+		lifecycle.Add(recordedComponent("http", &calls, nil))
+
+		// When
+		err := lifecycle.Run(context.Background())
+
+		// Then
+		require.ErrorContains(t, err, "failed to start queue: connection refused")
+		require.Equal(t, []string{"start db", "start queue", "stop db"}, calls)
+	})
+
+	t.Run("Should cancel root context and return abort error", func(t *testing.T) {
+		// Given
+		lifecycle := NewLifecycle(zerolog.Nop())
+		rootCanceled := make(chan struct{})
+		lifecycle.Add(Component{
+			Name: "http",
+			Start: func(ctx context.Context) error {
+				go func() {
+					<-ctx.Done()
+					close(rootCanceled)
+				}()
+				return nil
+			},
+		})
+		abortErr := errors.New("address already in use")
+
+		// When
+		lifecycle.Abort(abortErr)
+		err := lifecycle.Run(context.Background())
+
+		// Then
+		require.ErrorIs(t, err, abortErr)
+		select {
+		case <-rootCanceled:
+		case <-time.After(time.Second):
+			t.Fatal("root context was not canceled")
+		}
+	})
+
+	t.Run("Should bound stop with timeout", func(t *testing.T) {
+		// Given
+		lifecycle := NewLifecycle(zerolog.Nop())
+		lifecycle.Add(Component{
+			Name: "workers",
+			Stop: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			StopTimeout: 10 * time.Millisecond,
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// When
+		err := lifecycle.Run(ctx)
+
+		// Then
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestLifecycle_Release(t *testing.T) {
+	// Given
+	var calls []string
+	lifecycle := NewLifecycle(zerolog.Nop())
+	lifecycle.Add(Component{
+		Name: "db",
+		Stop: func(ctx context.Context) error {
+			calls = append(calls, "stop db")
+			return nil
+		},
+	})
+	lifecycle.Add(recordedComponent("workers", &calls, nil))
+	lifecycle.Add(Component{
+		Name: "queue",
+		Stop: func(ctx context.Context) error {
+			calls = append(calls, "stop queue")
+			return errors.New("channel already closed")
+		},
+	})
+
+	// When
+	err := lifecycle.Release()
+
+	// Then
+	require.ErrorContains(t, err, "failed to stop queue: channel already closed")
+	require.Equal(t, []string{"stop queue", "stop db"}, calls)
+}
+
+func TestLifecycle_Ready(t *testing.T) {
+	// Given
+	lifecycle := NewLifecycle(zerolog.Nop())
+	var readyWhileStopping error
+	lifecycle.Add(Component{
+		Name: "http",
+		Stop: func(ctx context.Context) error {
+			readyWhileStopping = lifecycle.Ready(ctx)
+			return nil
+		},
+	})
+	readyBeforeRun := lifecycle.Ready(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// When
+	err := lifecycle.Run(ctx)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, readyBeforeRun)
+	require.ErrorIs(t, readyWhileStopping, ErrShuttingDown)
+}
@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// DefaultMemoryQueueCapacity is how many commands MemoryQueue buffers before PublishCommand blocks
+const DefaultMemoryQueueCapacity = 1024
+
+var ErrQueueClosed = errors.New("command queue is closed")
+
+// MemoryQueue is an in-process command queue for tests and single binary mode.
+// Commands are lost when the process exits.
+type MemoryQueue struct {
+	mu        sync.RWMutex
+	closed    bool
+	messages  chan domain.CommandMessage
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewMemoryQueue(capacity int) *MemoryQueue {
+	return &MemoryQueue{
+		messages: make(chan domain.CommandMessage, capacity),
+		done:     make(chan struct{}),
+	}
+}
+
+func (q *MemoryQueue) PublishCommand(ctx context.Context, command domain.SolCommand) error {
+	body, err := json.Marshal(command)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command: %w", err)
+	}
+	return q.enqueue(ctx, body, tracing.Inject(ctx))
+}
+
+func (q *MemoryQueue) enqueue(ctx context.Context, body []byte, headers map[string]string) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.messages <- domain.NewCommandMessage(body, memoryAcknowledger{queue: q, body: body, headers: headers}).WithHeaders(headers):
+		return nil
+	case <-q.done:
+		return ErrQueueClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetMessage returns the channel of queued commands. It is closed by Close.
+func (q *MemoryQueue) GetMessage() <-chan domain.CommandMessage {
+	return q.messages
+}
+
+// Ready reports whether the queue still accepts commands
+func (q *MemoryQueue) Ready() error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	return nil
+}
+
+// Close stops accepting commands and closes the message channel. Buffered commands are dropped.
+func (q *MemoryQueue) Close() {
+	q.closeOnce.Do(func() {
+		// Wake up blocked publishers first, they hold the read lock
+		close(q.done)
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.closed = true
+		close(q.messages)
+	})
+}
+
+type memoryAcknowledger struct {
+	queue   *MemoryQueue
+	body    []byte
+	headers map[string]string
+}
+
+func (a memoryAcknowledger) Ack() error {
+	return nil
+}
+
+func (a memoryAcknowledger) Nack(requeue bool) error {
+	if !requeue {
+		return nil
+	}
+	// Requeue asynchronously so a consumer never blocks on its own full queue
+	go func() {
+		_ = a.queue.enqueue(context.Background(), a.body, a.headers)
+	}()
+	return nil
+}
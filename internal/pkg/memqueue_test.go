@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func receive(t *testing.T, messages <-chan domain.CommandMessage) domain.CommandMessage {
+	t.Helper()
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for command")
+		return domain.CommandMessage{}
+	}
+}
+
+func TestMemoryQueue(t *testing.T) {
+	t.Run("Should deliver published command", func(t *testing.T) {
+		// Given
+		queue := NewMemoryQueue(1)
+		defer queue.Close()
+
+		// When
+		err := queue.PublishCommand(context.Background(), domain.SolCommand{JobID: "job-1", Sol: 1000})
+
+		// Then
+		require.NoError(t, err)
+		message := receive(t, queue.GetMessage())
+		require.JSONEq(t, `{"job_id":"job-1","sol":1000}`, string(message.GetBody()))
+		require.NoError(t, message.Ack())
+	})
+
+	t.Run("Should redeliver command nacked with requeue", func(t *testing.T) {
+		// Given
+		queue := NewMemoryQueue(1)
+		defer queue.Close()
+		require.NoError(t, queue.PublishCommand(context.Background(), domain.SolCommand{JobID: "job-1", Sol: 1000}))
+		message := receive(t, queue.GetMessage())
+
+		// When
+		require.NoError(t, message.Nack(true))
+
+		// Then
+		redelivered := receive(t, queue.GetMessage())
+		require.Equal(t, message.GetBody(), redelivered.GetBody())
+	})
+
+	t.Run("Should carry trace context of publisher through requeue", func(t *testing.T) {
+		// Given
+		_, err := tracing.Setup(context.Background(), nil, 1)
+		require.NoError(t, err)
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}))
+		queue := NewMemoryQueue(1)
+		defer queue.Close()
+
+		// When
+		require.NoError(t, queue.PublishCommand(ctx, domain.SolCommand{JobID: "job-1", Sol: 1000}))
+		message := receive(t, queue.GetMessage())
+		require.NoError(t, message.Nack(true))
+		redelivered := receive(t, queue.GetMessage())
+
+		// Then
+		expected := map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+		require.Equal(t, expected, message.GetHeaders())
+		require.Equal(t, expected, redelivered.GetHeaders())
+	})
+
+	t.Run("Should reject commands after close", func(t *testing.T) {
+		// Given
+		queue := NewMemoryQueue(1)
+
+		// When
+		queue.Close()
+
+		// Then
+		require.ErrorIs(t, queue.PublishCommand(context.Background(), domain.SolCommand{Sol: 1}), ErrQueueClosed)
+		require.ErrorIs(t, queue.Ready(), ErrQueueClosed)
+		_, open := <-queue.GetMessage()
+		require.False(t, open)
+	})
+
+	t.Run("Should unblock publisher waiting on full queue when closed", func(t *testing.T) {
+		// Given
+		queue := NewMemoryQueue(1)
+		require.NoError(t, queue.PublishCommand(context.Background(), domain.SolCommand{Sol: 1}))
+		published := make(chan error, 1)
+		go func() {
+			published <- queue.PublishCommand(context.Background(), domain.SolCommand{Sol: 2})
+		}()
+
+		// When
+		time.Sleep(10 * time.Millisecond)
+		queue.Close()
+
+		// Then
+		require.ErrorIs(t, <-published, ErrQueueClosed)
+	})
+}
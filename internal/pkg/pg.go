@@ -9,6 +9,8 @@ import (
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/extra/bundebug"
+	"github.com/uptrace/bun/extra/bunotel"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
 
 	"time"
 )
@@ -32,6 +34,8 @@ func Dial(dsn string) (*DB, error) {
 
 	bunDb := bun.NewDB(db, pgdialect.New())
 
+	bunDb.AddQueryHook(metrics.QueryHook{})
+	bunDb.AddQueryHook(bunotel.NewQueryHook(bunotel.WithDBName("postgres")))
 	bunDb.AddQueryHook(bundebug.NewQueryHook(
 		bundebug.WithVerbose(true),
 		bundebug.FromEnv("BUNDEBUG"),
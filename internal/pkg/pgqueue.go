@@ -0,0 +1,217 @@
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+const (
+	DefaultPostgresQueuePollInterval = time.Second
+	// PostgresQueueLeaseMargin is added to the job timeout to get the visibility timeout, so a lease
+	// outlives the job it was claimed for including settling its outcome
+	PostgresQueueLeaseMargin = time.Minute
+
+	settleTimeout = 5 * time.Second
+)
+
+// PostgresQueue is a work queue on top of the command_queue table.
+// A claimed command is leased for the visibility timeout from the moment a consumer receives it:
+// it is deleted on Ack, released on Nack and becomes visible again if the consumer dies before
+// settling it. The visibility timeout must exceed the time a consumer takes to settle a command.
+type PostgresQueue struct {
+	db                *DB
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	logger            zerolog.Logger
+
+	messages  chan domain.CommandMessage
+	done      chan struct{}
+	startOnce sync.Once
+	closeOnce sync.Once
+	poller    sync.WaitGroup
+}
+
+func NewPostgresQueue(db *DB, pollInterval, visibilityTimeout time.Duration, logger zerolog.Logger) *PostgresQueue {
+	return &PostgresQueue{
+		db:                db,
+		pollInterval:      pollInterval,
+		visibilityTimeout: visibilityTimeout,
+		logger:            logger,
+		messages:          make(chan domain.CommandMessage),
+		done:              make(chan struct{}),
+	}
+}
+
+func (q *PostgresQueue) PublishCommand(ctx context.Context, command domain.SolCommand) error {
+	payload, err := json.Marshal(command)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command: %w", err)
+	}
+	headers, err := json.Marshal(tracing.Inject(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to marshal headers: %w", err)
+	}
+	_, err = q.db.NewRaw(
+		"INSERT INTO command_queue (job_id, payload, headers) VALUES (?, ?, ?)",
+		command.JobID, string(payload), string(headers),
+	).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue command: %w", err)
+	}
+	return nil
+}
+
+// GetMessage starts polling the table once and returns the channel of claimed commands.
+// The channel is closed by Close.
+func (q *PostgresQueue) GetMessage() <-chan domain.CommandMessage {
+	q.startOnce.Do(func() {
+		q.poller.Add(1)
+		go q.poll()
+	})
+	return q.messages
+}
+
+func (q *PostgresQueue) poll() {
+	defer q.poller.Done()
+	for {
+		id, body, headers, err := q.claim()
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				q.logger.Error().Err(err).Msg("failed to claim command from postgres queue")
+			}
+			select {
+			case <-q.done:
+				return
+			case <-time.After(q.pollInterval):
+			}
+			continue
+		}
+		if !q.handOver(id, domain.NewCommandMessage(body, postgresAcknowledger{queue: q, id: id}).WithHeaders(headers)) {
+			return
+		}
+	}
+}
+
+// handOver waits for a consumer to take message, renewing the lease meanwhile so that no other
+// consumer claims it, and renews the lease once more when it is taken. It returns false when
+// the queue was closed first.
+func (q *PostgresQueue) handOver(id int64, message domain.CommandMessage) bool {
+	renew := time.NewTicker(q.visibilityTimeout / 2)
+	defer renew.Stop()
+	for {
+		select {
+		case q.messages <- message:
+			if err := q.extend(id); err != nil {
+				q.logger.Error().Err(err).Int64("id", id).Msg("failed to renew lease of command")
+			}
+			return true
+		case <-renew.C:
+			if err := q.extend(id); err != nil {
+				q.logger.Error().Err(err).Int64("id", id).Msg("failed to renew lease of command")
+			}
+		case <-q.done:
+			// Hand the claimed command back instead of waiting for the lease to expire
+			_ = q.release(id)
+			return false
+		}
+	}
+}
+
+// claim leases the oldest visible command. SKIP LOCKED lets several workers claim concurrently.
+func (q *PostgresQueue) claim() (int64, []byte, map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+	var id int64
+	var payload string
+	var headers sql.NullString
+	err := q.db.NewRaw(`
+		UPDATE command_queue
+		SET locked_until = now() + ?::interval, attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM command_queue
+			WHERE locked_until IS NULL OR locked_until < now()
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, payload, headers`,
+		fmt.Sprintf("%d milliseconds", q.visibilityTimeout.Milliseconds()),
+	).Scan(ctx, &id, &payload, &headers)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	var headerMap map[string]string
+	if headers.Valid {
+		// Headers only carry trace context, a command is still processed without them
+		_ = json.Unmarshal([]byte(headers.String), &headerMap)
+	}
+	return id, []byte(payload), headerMap, nil
+}
+
+func (q *PostgresQueue) delete(id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+	_, err := q.db.NewRaw("DELETE FROM command_queue WHERE id = ?", id).Exec(ctx)
+	return err
+}
+
+func (q *PostgresQueue) extend(id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+	_, err := q.db.NewRaw(
+		"UPDATE command_queue SET locked_until = now() + ?::interval WHERE id = ?",
+		fmt.Sprintf("%d milliseconds", q.visibilityTimeout.Milliseconds()), id,
+	).Exec(ctx)
+	return err
+}
+
+func (q *PostgresQueue) release(id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+	_, err := q.db.NewRaw("UPDATE command_queue SET locked_until = NULL WHERE id = ?", id).Exec(ctx)
+	return err
+}
+
+// Ready reports whether the queue table is reachable
+func (q *PostgresQueue) Ready() error {
+	select {
+	case <-q.done:
+		return ErrQueueClosed
+	default:
+	}
+	return q.db.Ping()
+}
+
+// Close stops polling and closes the message channel. It does not close the DB.
+func (q *PostgresQueue) Close() {
+	q.closeOnce.Do(func() {
+		close(q.done)
+		q.poller.Wait()
+		close(q.messages)
+	})
+}
+
+type postgresAcknowledger struct {
+	queue *PostgresQueue
+	id    int64
+}
+
+func (a postgresAcknowledger) Ack() error {
+	return a.queue.delete(a.id)
+}
+
+func (a postgresAcknowledger) Nack(requeue bool) error {
+	if requeue {
+		return a.queue.release(a.id)
+	}
+	return a.queue.delete(a.id)
+}
@@ -2,39 +2,179 @@ package pkg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/tracing"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
 )
 
+// RabbitMQ owns a broker connection with separate publish and consume channels.
+// A supervisor goroutine watches the connection and transparently reconnects,
+// redeclares the topology and resumes consuming after a broker restart.
 type RabbitMQ struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
+	url    string
+	logger zerolog.Logger
+
+	mu             sync.RWMutex
+	conn           *amqp.Connection
+	publishChannel *amqp.Channel
+	publisher      confirmPublisher
+	consumeChannel *amqp.Channel
+	returns        chan amqp.Return
+	connected      bool
+	lastErr        error
+	consuming      bool
+	closed         bool
+
+	// deliveries survives reconnects, so consumers never see it closed until Close is called
+	deliveries chan domain.CommandMessage
+	forwarders sync.WaitGroup
+	done       chan struct{}
+
+	// prefetch limits unacked deliveries to match the number of workers
+	prefetch int
+
+	// publishMu serializes publishes so a basic.return can be attributed to the message awaiting confirm
+	publishMu      sync.Mutex
+	confirmTimeout time.Duration
 }
 
 const RabbitmqQueue = "command-queue"
 const RabbitmqExchange = "command-exchange"
 const RabbitmqRoutingKey = "command-routing-key"
 
-func ConnectRabbitMQ(rabbitMQURL string) (*RabbitMQ, error) {
-	// Establish a connection to RabbitMQ
-	conn, err := amqp.Dial(rabbitMQURL)
+// DefaultConfirmTimeout is how long PublishCommand waits for the broker to ack a message
+const DefaultConfirmTimeout = 5 * time.Second
+
+const (
+	minReconnectBackoff = 500 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// confirmPublisher publishes on a channel in confirm mode, it is *amqp.Channel outside of tests
+type confirmPublisher interface {
+	publishWithConfirm(ctx context.Context, exchange, key string, mandatory bool, msg amqp.Publishing) (publishConfirmation, error)
+}
+
+// publishConfirmation resolves once the broker acked or nacked a message, see amqp.DeferredConfirmation
+type publishConfirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// amqpPublisher adapts *amqp.Channel to confirmPublisher
+type amqpPublisher struct {
+	channel *amqp.Channel
+}
+
+func (p amqpPublisher) publishWithConfirm(ctx context.Context, exchange, key string, mandatory bool, msg amqp.Publishing) (publishConfirmation, error) {
+	confirmation, err := p.channel.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, false, msg)
 	if err != nil {
 		return nil, err
 	}
+	return confirmation, nil
+}
+
+var (
+	ErrCommandUnroutable = errors.New("command was returned by broker as unroutable")
+	ErrCommandNacked     = errors.New("command was nacked by broker")
+	ErrNotConnected      = errors.New("rabbitmq is not connected")
+)
+
+// closeNotifications fire when the connection or one of its channels goes away
+type closeNotifications struct {
+	conn    chan *amqp.Error
+	publish chan *amqp.Error
+	consume chan *amqp.Error
+}
 
-	// Open a channel on the connection
-	channel, err := conn.Channel()
+// ConnectRabbitMQ connects to the broker. The consumer receives at most prefetch unacked messages at once.
+func ConnectRabbitMQ(rabbitMQURL string, prefetch int, logger zerolog.Logger) (*RabbitMQ, error) {
+	r := &RabbitMQ{
+		url:            rabbitMQURL,
+		logger:         logger,
+		prefetch:       prefetch,
+		deliveries:     make(chan domain.CommandMessage),
+		done:           make(chan struct{}),
+		confirmTimeout: DefaultConfirmTimeout,
+	}
+	// The first connection attempt fails fast so misconfiguration is reported at startup
+	notifications, err := r.connect()
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
+	go r.supervise(notifications)
+	return r, nil
+}
 
+// connect dials the broker, declares the topology and opens both channels.
+// It resumes consuming if GetMessage was called before.
+func (r *RabbitMQ) connect() (closeNotifications, error) {
+	// Establish a connection to RabbitMQ
+	conn, err := amqp.Dial(r.url)
+	if err != nil {
+		return closeNotifications{}, err
+	}
+
+	publishChannel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return closeNotifications{}, err
+	}
+	if err := declareTopology(publishChannel); err != nil {
+		_ = conn.Close()
+		return closeNotifications{}, err
+	}
+	// Put channel into confirm mode so every publish is acked or nacked by the broker
+	if err := publishChannel.Confirm(false); err != nil {
+		_ = conn.Close()
+		return closeNotifications{}, errors.New("could not put channel into confirm mode")
+	}
+
+	consumeChannel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return closeNotifications{}, err
+	}
+	if err := consumeChannel.Qos(r.prefetch, 0, false); err != nil {
+		_ = conn.Close()
+		return closeNotifications{}, fmt.Errorf("could not set prefetch: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		_ = conn.Close()
+		return closeNotifications{}, errors.New("rabbitmq connection is closed")
+	}
+	r.conn = conn
+	r.publishChannel = publishChannel
+	r.publisher = amqpPublisher{channel: publishChannel}
+	r.consumeChannel = consumeChannel
+	r.returns = publishChannel.NotifyReturn(make(chan amqp.Return, 1))
+	if r.consuming {
+		if err := r.startConsumingLocked(); err != nil {
+			_ = conn.Close()
+			return closeNotifications{}, err
+		}
+	}
+	r.connected = true
+	r.lastErr = nil
+	return closeNotifications{
+		conn:    conn.NotifyClose(make(chan *amqp.Error, 1)),
+		publish: publishChannel.NotifyClose(make(chan *amqp.Error, 1)),
+		consume: consumeChannel.NotifyClose(make(chan *amqp.Error, 1)),
+	}, nil
+}
+
+func declareTopology(channel *amqp.Channel) error {
 	// Declare the exchange
-	err = channel.ExchangeDeclare(
+	err := channel.ExchangeDeclare(
 		RabbitmqExchange, // Name
 		"direct",         // Type (options: "direct")
 		true,             // Durable
@@ -44,7 +184,7 @@ func ConnectRabbitMQ(rabbitMQURL string) (*RabbitMQ, error) {
 		nil,              // Arguments
 	)
 	if err != nil {
-		return nil, errors.New("could not declare exchange")
+		return errors.New("could not declare exchange")
 	}
 
 	_, err = channel.QueueDeclare(
@@ -56,7 +196,7 @@ func ConnectRabbitMQ(rabbitMQURL string) (*RabbitMQ, error) {
 		nil,           // Arguments
 	)
 	if err != nil {
-		return nil, errors.New("could not declare queue")
+		return errors.New("could not declare queue")
 	}
 
 	// Bind the queue to the exchange with a routing key
@@ -68,53 +208,248 @@ func ConnectRabbitMQ(rabbitMQURL string) (*RabbitMQ, error) {
 		nil,                // Arguments
 	)
 	if err != nil {
-		return nil, errors.New("could not bind queue to exchange")
+		return errors.New("could not bind queue to exchange")
 	}
+	return nil
+}
 
-	return &RabbitMQ{
-		conn:    conn,
-		channel: channel,
-	}, nil
+// supervise waits for the connection or a channel to drop and reconnects with exponential backoff
+func (r *RabbitMQ) supervise(notifications closeNotifications) {
+	for {
+		var amqpErr *amqp.Error
+		select {
+		case <-r.done:
+			return
+		case amqpErr = <-notifications.conn:
+		case amqpErr = <-notifications.publish:
+		case amqpErr = <-notifications.consume:
+		}
+		select {
+		case <-r.done:
+			// Graceful close initiated by Close
+			return
+		default:
+		}
+		r.logger.Error().Err(amqpErr).Msg("rabbitmq connection lost")
+		r.teardown(amqpErr)
+
+		backoff := minReconnectBackoff
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-time.After(backoff):
+			}
+			var err error
+			notifications, err = r.connect()
+			if err == nil {
+				r.logger.Info().Msg("rabbitmq connection re-established")
+				break
+			}
+			r.logger.Error().Err(err).Dur("backoff", backoff).Msg("rabbitmq reconnect failed, retrying")
+			r.setDisconnected(err)
+			backoff = min(2*backoff, maxReconnectBackoff)
+		}
+	}
+}
+
+// teardown marks the client disconnected and closes what is left of the old connection,
+// so a single failed channel results in a full reconnect
+func (r *RabbitMQ) teardown(amqpErr *amqp.Error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connected = false
+	r.lastErr = errors.New("connection or channel closed")
+	if amqpErr != nil {
+		r.lastErr = amqpErr
+	}
+	_ = r.conn.Close()
+}
+
+func (r *RabbitMQ) setDisconnected(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connected = false
+	r.lastErr = err
+}
+
+// Ready reports whether the connection and both channels are usable
+func (r *RabbitMQ) Ready() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return errors.New("rabbitmq connection is closed")
+	}
+	if !r.connected || r.conn.IsClosed() || r.publishChannel.IsClosed() || r.consumeChannel.IsClosed() {
+		if r.lastErr != nil {
+			return fmt.Errorf("%w: %v", ErrNotConnected, r.lastErr)
+		}
+		return ErrNotConnected
+	}
+	return nil
 }
 
-func (r *RabbitMQ) PublishCommand(ctx context.Context, solCommand int) error {
-	byteArrayCommand := []byte(strconv.Itoa(solCommand))
-	err := r.channel.PublishWithContext(ctx, RabbitmqExchange, // Exchange
+// PublishCommand publishes command as a mandatory message and waits for the broker confirm.
+// It returns nil only when the broker has acked the message and routed it to a queue.
+func (r *RabbitMQ) PublishCommand(ctx context.Context, command domain.SolCommand) error {
+	byteArrayCommand, err := json.Marshal(command)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command: %w", err)
+	}
+
+	r.publishMu.Lock()
+	defer r.publishMu.Unlock()
+
+	r.mu.RLock()
+	publisher, returns, connected := r.publisher, r.returns, r.connected
+	r.mu.RUnlock()
+	if !connected {
+		return ErrNotConnected
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.confirmTimeout)
+	defer cancel()
+
+	discardStaleReturns(returns)
+	confirmation, err := publisher.publishWithConfirm(ctx, RabbitmqExchange, // Exchange
 		RabbitmqRoutingKey, // Routing key (queue)
-		false,              // Mandatory
-		false,              // Immediate
+		true,               // Mandatory
 		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        byteArrayCommand,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    command.JobID,
+			Headers:      amqpHeaders(tracing.Inject(ctx)),
+			Body:         byteArrayCommand,
 		})
-	return fmt.Errorf("failed to publish command: %w", err)
+	if err != nil {
+		return fmt.Errorf("failed to publish command: %w", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for publish confirm: %w", err)
+	}
+	if !acked {
+		return ErrCommandNacked
+	}
+	// The broker sends basic.return before basic.ack, so an unroutable message is already here
+	select {
+	case returned := <-returns:
+		return fmt.Errorf("%w: %s (%d)", ErrCommandUnroutable, returned.ReplyText, returned.ReplyCode)
+	default:
+		return nil
+	}
 }
 
-// GetMessage messages from the queue
-func (r *RabbitMQ) GetMessage() <-chan amqp.Delivery {
-	messages, err := r.channel.Consume(
+// amqpHeaders turns trace context into AMQP message headers
+func amqpHeaders(headers map[string]string) amqp.Table {
+	if len(headers) == 0 {
+		return nil
+	}
+	table := make(amqp.Table, len(headers))
+	for key, value := range headers {
+		table[key] = value
+	}
+	return table
+}
+
+// stringHeaders keeps the string valued headers of a delivery, trace context is sent as strings
+func stringHeaders(table amqp.Table) map[string]string {
+	headers := make(map[string]string, len(table))
+	for key, value := range table {
+		if s, ok := value.(string); ok {
+			headers[key] = s
+		}
+	}
+	return headers
+}
+
+func discardStaleReturns(returns chan amqp.Return) {
+	for {
+		select {
+		case <-returns:
+		default:
+			return
+		}
+	}
+}
+
+// GetMessage messages from the queue. The returned channel stays open across reconnects
+// and is closed only by Close.
+func (r *RabbitMQ) GetMessage() <-chan domain.CommandMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.consuming {
+		return r.deliveries
+	}
+	r.consuming = true
+	if err := r.startConsumingLocked(); err != nil {
+		// The supervisor resumes consuming once the connection is re-established
+		r.logger.Error().Err(err).Msg("failed to consume messages from rabbitmq")
+	}
+	return r.deliveries
+}
+
+func (r *RabbitMQ) startConsumingLocked() error {
+	messages, err := r.consumeChannel.Consume(
 		RabbitmqQueue, // Queue name
 		"",            // Consumer name
-		true,          // Auto-acknowledge
+		false,         // Auto-acknowledge, consumers settle every message via Ack or Nack
 		false,         // Exclusive
 		false,         // No-local
 		false,         // No-wait
 		nil,           // Arguments
 	)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to consume messages from RabbitMQ")
+		return fmt.Errorf("failed to consume messages: %w", err)
 	}
-	return messages
+	r.forwarders.Add(1)
+	go func() {
+		defer r.forwarders.Done()
+		// messages is closed when the channel dies, the supervisor then starts a new forwarder
+		for message := range messages {
+			select {
+			case r.deliveries <- domain.NewCommandMessage(message.Body, deliveryAcknowledger{delivery: message}).
+				WithHeaders(stringHeaders(message.Headers)):
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// deliveryAcknowledger settles a delivery on the channel it arrived on. After a reconnect
+// the old channel is gone, settling fails and the broker redelivers the message.
+type deliveryAcknowledger struct {
+	delivery amqp.Delivery
+}
+
+func (a deliveryAcknowledger) Ack() error {
+	return a.delivery.Ack(false)
+}
+
+func (a deliveryAcknowledger) Nack(requeue bool) error {
+	return a.delivery.Nack(false, requeue)
 }
 
 // Close RabbitMQ connection and channel
 func (r *RabbitMQ) Close() {
-	err := r.channel.Close()
-	if err != nil {
-		return
-	}
-	err = r.conn.Close()
-	if err != nil {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
 		return
 	}
+	r.closed = true
+	r.connected = false
+	close(r.done)
+
+	_ = r.publishChannel.Close()
+	_ = r.consumeChannel.Close()
+	_ = r.conn.Close()
+	r.mu.Unlock()
+
+	// Forwarders stop once the consume channel is closed, only then deliveries can be closed
+	r.forwarders.Wait()
+	close(r.deliveries)
 }
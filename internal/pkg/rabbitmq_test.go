@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/domain"
+)
+
+// fakePublisher stands in for a channel in confirm mode, a returned message is put on returns before the confirm
+type fakePublisher struct {
+	returns   chan amqp.Return
+	err       error
+	returned  bool
+	acked     bool
+	hang      bool
+	published []amqp.Publishing
+	mandatory bool
+}
+
+func (p *fakePublisher) publishWithConfirm(ctx context.Context, exchange, key string, mandatory bool, msg amqp.Publishing) (publishConfirmation, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.published = append(p.published, msg)
+	p.mandatory = mandatory
+	if p.returned {
+		p.returns <- amqp.Return{ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE", Exchange: exchange, RoutingKey: key}
+	}
+	return fakeConfirmation{acked: p.acked, hang: p.hang}, nil
+}
+
+type fakeConfirmation struct {
+	acked bool
+	hang  bool
+}
+
+func (c fakeConfirmation) WaitContext(ctx context.Context) (bool, error) {
+	if c.hang {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return c.acked, nil
+}
+
+func TestRabbitMQ_PublishCommand(t *testing.T) {
+	testCases := []struct {
+		name          string
+		publisher     *fakePublisher
+		staleReturn   bool
+		disconnected  bool
+		expectedError error
+		errorLike     string
+	}{
+		{
+			name:      "Should succeed when broker acks routed message",
+			publisher: &fakePublisher{acked: true},
+		},
+		{
+			name:        "Should ignore return left over from an earlier publish",
+			publisher:   &fakePublisher{acked: true},
+			staleReturn: true,
+		},
+		{
+			name:          "Should fail when broker nacks message",
+			publisher:     &fakePublisher{acked: false},
+			expectedError: ErrCommandNacked,
+		},
+		{
+			name:          "Should fail when broker returns unroutable message",
+			publisher:     &fakePublisher{acked: true, returned: true},
+			expectedError: ErrCommandUnroutable,
+			errorLike:     "NO_ROUTE (312)",
+		},
+		{
+			name:          "Should fail when confirm does not arrive in time",
+			publisher:     &fakePublisher{hang: true},
+			expectedError: context.DeadlineExceeded,
+			errorLike:     "failed to wait for publish confirm",
+		},
+		{
+			name:      "Should fail when channel rejects publish",
+			publisher: &fakePublisher{err: amqp.ErrClosed},
+			errorLike: "failed to publish command",
+		},
+		{
+			name:          "Should fail fast while disconnected",
+			publisher:     &fakePublisher{acked: true},
+			disconnected:  true,
+			expectedError: ErrNotConnected,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Given
+			returns := make(chan amqp.Return, 1)
+			tc.publisher.returns = returns
+			if tc.staleReturn {
+				returns <- amqp.Return{ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE"}
+			}
+			r := &RabbitMQ{
+				logger:         zerolog.Nop(),
+				publisher:      tc.publisher,
+				returns:        returns,
+				connected:      !tc.disconnected,
+				confirmTimeout: 20 * time.Millisecond,
+			}
+
+			// When
+			err := r.PublishCommand(context.Background(), domain.SolCommand{JobID: "job-1", Sol: 1000})
+
+			// Then
+			if tc.expectedError == nil && tc.errorLike == "" {
+				require.NoError(t, err)
+				require.Len(t, tc.publisher.published, 1)
+				message := tc.publisher.published[0]
+				require.True(t, tc.publisher.mandatory)
+				require.Equal(t, amqp.Persistent, message.DeliveryMode)
+				require.Equal(t, "job-1", message.MessageId)
+				require.JSONEq(t, `{"job_id":"job-1","sol":1000}`, string(message.Body))
+				return
+			}
+			require.Error(t, err)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+			}
+			if tc.errorLike != "" {
+				require.ErrorContains(t, err, tc.errorLike)
+			}
+		})
+	}
+}
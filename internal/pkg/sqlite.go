@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/extra/bundebug"
+	"github.com/uptrace/bun/extra/bunotel"
+	"github.com/yuriyfomin17/largest-picture-nasa-api/internal/app/common/metrics"
+	_ "modernc.org/sqlite"
+)
+
+// DialSQLite opens a pure Go SQLite database at path, ":memory:" keeps the database in memory
+func DialSQLite(path string) (*DB, error) {
+	if path == "" {
+		return nil, errors.New("no sqlite path provided")
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	// SQLite has a single writer and every connection to ":memory:" is a separate database
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to connect to sqlite: %w", err)
+	}
+
+	bunDb := bun.NewDB(db, sqlitedialect.New())
+
+	bunDb.AddQueryHook(metrics.QueryHook{})
+	bunDb.AddQueryHook(bunotel.NewQueryHook(bunotel.WithDBName("sqlite")))
+	bunDb.AddQueryHook(bundebug.NewQueryHook(
+		bundebug.WithVerbose(true),
+		bundebug.FromEnv("BUNDEBUG"),
+	))
+	return &DB{bunDb}, nil
+}